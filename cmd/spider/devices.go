@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/spider-crawler/spider/internal/config"
+	"github.com/spider-crawler/spider/internal/fetcher"
+	"github.com/spider-crawler/spider/internal/parser"
+	"github.com/spider-crawler/spider/internal/renderer"
+)
+
+// runCompareDevices fetches each URL as desktop and as mobile and reports
+// content, link and structured-data parity.
+func runCompareDevices(args []string) error {
+	fs := flag.NewFlagSet("devices", flag.ExitOnError)
+	desktopName := fs.String("desktop", "", "Desktop device profile (default: config device, or desktop)")
+	mobileName := fs.String("mobile", "", "Mobile device profile (default: config compare_device, or googlebot-smartphone)")
+	render := fs.Bool("render", false, "Render pages with Chromium instead of fetching HTML")
+	minRatio := fs.Float64("min-ratio", parser.DefaultMinWordCountRatio, "Minimum mobile/desktop word ratio")
+	configPath := fs.String("config", "", "Crawl config file (JSON)")
+	fs.Usage = func() {
+		fmt.Println("Usage: spider devices [options] <url> [url...]")
+		fmt.Printf("Built-in profiles: %s\n", strings.Join(config.DefaultConfig().DeviceNames(), ", "))
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return fmt.Errorf("at least one URL is required")
+	}
+
+	cfg := config.DefaultConfig()
+	if *configPath != "" {
+		loaded, err := loadConfig(*configPath)
+		if err != nil {
+			return err
+		}
+		cfg = loaded
+	}
+
+	// The config's dual-crawl devices apply unless overridden
+	if *desktopName == "" {
+		*desktopName = firstNonEmpty(cfg.Device, config.DeviceDesktop)
+	}
+	if *mobileName == "" {
+		*mobileName = firstNonEmpty(cfg.CompareDevice, config.DeviceGooglebotSmartphone)
+	}
+
+	desktopCfg, err := cfg.ForDevice(*desktopName)
+	if err != nil {
+		return err
+	}
+	mobileCfg, err := cfg.ForDevice(*mobileName)
+	if err != nil {
+		return err
+	}
+
+	desktop, err := newPageLoader(desktopCfg, *render)
+	if err != nil {
+		return err
+	}
+	defer desktop.close()
+	mobile, err := newPageLoader(mobileCfg, *render)
+	if err != nil {
+		return err
+	}
+	defer mobile.close()
+
+	issues := 0
+	for _, u := range fs.Args() {
+		desktopPage, err := desktop.load(u)
+		if err != nil {
+			fmt.Printf("[warn] %s (%s): %v\n", u, *desktopName, err)
+			continue
+		}
+		mobilePage, err := mobile.load(u)
+		if err != nil {
+			fmt.Printf("[warn] %s (%s): %v\n", u, *mobileName, err)
+			continue
+		}
+
+		p := parser.CompareDevices(desktopPage, mobilePage)
+		status := "OK"
+		if p.MissingMobileContent(*minRatio) {
+			status = "MISSING MOBILE CONTENT"
+			issues++
+		}
+
+		fmt.Printf("%s\n", u)
+		fmt.Printf("  words: desktop=%d mobile=%d (%.0f%%)\n", p.DesktopWordCount, p.MobileWordCount, p.WordCountRatio*100)
+		fmt.Printf("  links: desktop=%d mobile=%d missing=%d extra=%d\n", p.DesktopLinks, p.MobileLinks, len(p.MissingLinks), len(p.ExtraLinks))
+		if len(p.MissingStructuredData) > 0 {
+			fmt.Printf("  structured data missing on mobile: %s\n", strings.Join(p.MissingStructuredData, ", "))
+		}
+		if !p.TitleMatch || !p.MetaDescriptionMatch || !p.CanonicalMatch || !p.H1Match {
+			fmt.Printf("  metadata differs (title=%v description=%v canonical=%v h1=%v)\n",
+				p.TitleMatch, p.MetaDescriptionMatch, p.CanonicalMatch, p.H1Match)
+		}
+		fmt.Printf("  status: %s\n", status)
+	}
+
+	fmt.Printf("\n%d of %d URLs are missing mobile content\n", issues, fs.NArg())
+	return nil
+}
+
+// pageLoader fetches and parses pages under one device profile.
+type pageLoader struct {
+	fetcher  *fetcher.Fetcher
+	renderer *renderer.Renderer
+}
+
+func newPageLoader(cfg *config.CrawlConfig, render bool) (*pageLoader, error) {
+	if !render {
+		return &pageLoader{fetcher: fetcher.NewFetcher(cfg)}, nil
+	}
+	cfg.RenderMode = config.RenderJS
+	cfg.Concurrency = 1
+	r, err := renderer.NewRenderer(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start renderer: %w", err)
+	}
+	return &pageLoader{renderer: r}, nil
+}
+
+func (l *pageLoader) load(u string) (*parser.PageData, error) {
+	if l.renderer != nil {
+		result := l.renderer.Render(u)
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		return parser.ParseHTML(result.FinalURL, []byte(result.HTML))
+	}
+
+	resp := l.fetcher.Fetch(context.Background(), u)
+	if resp.Error != nil {
+		return nil, resp.Error
+	}
+	return parser.ParseHTML(resp.FinalURL, resp.Body)
+}
+
+func (l *pageLoader) close() {
+	if l.renderer != nil {
+		l.renderer.Close()
+	}
+	if l.fetcher != nil {
+		l.fetcher.Close()
+	}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
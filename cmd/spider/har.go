@@ -0,0 +1,77 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/spider-crawler/spider/internal/config"
+	"github.com/spider-crawler/spider/internal/renderer"
+)
+
+// runHAR renders the given URLs and writes their network activity as HAR.
+func runHAR(args []string) error {
+	fs := flag.NewFlagSet("har", flag.ExitOnError)
+	output := fs.String("o", "crawl.har", "Output HAR file (combined mode)")
+	perURL := fs.String("dir", "", "Write one HAR file per URL into this directory")
+	configPath := fs.String("config", "", "Crawl config file (JSON)")
+	includeCredentials := fs.Bool("include-credentials", false, "Keep Authorization and cookie headers, cookies and post data (redacted by default)")
+	fs.Usage = func() {
+		fmt.Println("Usage: spider har [options] <url> [url...]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return fmt.Errorf("at least one URL is required")
+	}
+
+	cfg := config.DefaultConfig()
+	if *configPath != "" {
+		loaded, err := loadConfig(*configPath)
+		if err != nil {
+			return err
+		}
+		cfg = loaded
+	}
+	cfg.RenderMode = config.RenderJS
+	cfg.CaptureHAR = true
+	if *includeCredentials {
+		cfg.HARIncludeCredentials = true
+	}
+
+	r, err := renderer.NewRenderer(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to start renderer: %w", err)
+	}
+	defer r.Close()
+
+	results := r.RenderBatch(fs.Args())
+	for _, result := range results {
+		if result.Error != nil {
+			fmt.Printf("[warn] %v\n", result.Error)
+		}
+	}
+
+	if *perURL != "" {
+		paths, err := renderer.ExportHARFiles(*perURL, results, cfg.HARIncludeCredentials)
+		if err != nil {
+			return err
+		}
+		for _, p := range paths {
+			fmt.Printf("Wrote %s\n", p)
+		}
+		return nil
+	}
+
+	har := renderer.NewHAR()
+	har.IncludeCredentials = cfg.HARIncludeCredentials
+	for _, result := range results {
+		har.AddPage(result)
+	}
+	if err := har.Save(*output); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote %s (%d pages, %d entries)\n", *output, len(har.Log.Pages), len(har.Log.Entries))
+	return nil
+}
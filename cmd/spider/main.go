@@ -16,6 +16,16 @@ import (
 )
 
 func main() {
+	// Dispatch subcommands
+	if len(os.Args) > 1 {
+		if cmd, ok := commands[os.Args[1]]; ok {
+			if err := cmd(os.Args[2:]); err != nil {
+				log.Fatalf("%s: %v", os.Args[1], err)
+			}
+			return
+		}
+	}
+
 	// Create configuration
 	cfg := config.DefaultConfig()
 	cfg.Concurrency = 3
@@ -28,7 +38,17 @@ func main() {
 	// Example seed URL (replace with actual URL to test)
 	if len(os.Args) < 2 {
 		fmt.Println("Usage: spider <url>")
+		fmt.Println("       spider <command> [options]")
 		fmt.Println("Example: spider https://example.com")
+		fmt.Println()
+		fmt.Println("Commands:")
+		fmt.Println("  har          Render URLs and export their network activity as HAR 1.2")
+		fmt.Println("  devices      Compare desktop and mobile versions of URLs")
+		fmt.Println("  screenshot   Render URLs and store their screenshots")
+		fmt.Println("  visual-diff  Compare screenshots of two crawls")
+		fmt.Println("  secrets      Manage the encrypted secrets vault")
+		fmt.Println("  psl          Update or inspect the Public Suffix List")
+		fmt.Println("  apply-patch  Merge a generated config patch into a config file")
 		os.Exit(1)
 	}
 	seedURL := os.Args[1]
@@ -39,6 +59,11 @@ func main() {
 	// Set worker function (placeholder - will be replaced with actual HTTP fetcher)
 	sched.SetWorkerFunc(placeholderWorker)
 
+	// Report crawl traps as they are detected
+	sched.OnTrap(func(trap *scheduler.Trap) {
+		fmt.Printf("[trap] %s [%s] %s\n", trap.Pattern, trap.Reason, trap.Detail)
+	})
+
 	// Add seed URL
 	if err := sched.AddSeed(seedURL); err != nil {
 		log.Fatalf("Failed to add seed URL: %v", err)
@@ -107,9 +132,42 @@ func main() {
 	fmt.Printf("Failed: %d\n", stats.URLsFailed)
 	fmt.Printf("Retried: %d\n", stats.URLsRetried)
 	fmt.Printf("Duplicates Skipped: %d\n", stats.TotalDuplicates)
+	if stats.Reauthentications > 0 {
+		fmt.Printf("Re-authentications: %d\n", stats.Reauthentications)
+	}
+	if stats.URLsRewritten > 0 {
+		fmt.Printf("URLs Rewritten: %d\n", stats.URLsRewritten)
+	}
+	if stats.TrapsDetected > 0 {
+		fmt.Printf("Crawl Traps: %d (%d URLs excluded)\n", stats.TrapsDetected, stats.URLsTrapExcluded)
+		for _, trap := range sched.Traps() {
+			fmt.Printf("  - %s [%s] %s\n", trap.Pattern, trap.Reason, trap.Detail)
+		}
+	}
+	if len(stats.Quotas) > 0 {
+		fmt.Println("Quotas:")
+		for _, q := range stats.Quotas {
+			status := "not reached"
+			if q.Hit() {
+				status = fmt.Sprintf("HIT, %d skipped (%d over limit, %d too deep)", q.Skipped(), q.SkippedURLs, q.SkippedDepth)
+			}
+			fmt.Printf("  - %s: %d queued (max %d URLs, depth %d) %s\n", q.Rule, q.Queued, q.MaxURLs, q.MaxDepth, status)
+		}
+	}
 	fmt.Printf("Total Time: %v\n", stats.ElapsedTime.Round(time.Millisecond))
 }
 
+// commands maps subcommand names to their handlers.
+var commands = map[string]func(args []string) error{
+	"har":         runHAR,
+	"devices":     runCompareDevices,
+	"screenshot":  runScreenshots,
+	"visual-diff": runVisualDiff,
+	"secrets":     runSecrets,
+	"psl":         runPSL,
+	"apply-patch": runApplyPatch,
+}
+
 // placeholderWorker is a placeholder worker function.
 // This will be replaced with actual HTTP fetching logic in the next phase.
 func placeholderWorker(ctx context.Context, item *frontier.URLItem) (*scheduler.CrawlResult, error) {
@@ -146,4 +204,7 @@ func printResult(result *scheduler.CrawlResult) {
 		result.Item.Depth,
 		result.ResponseTime.Round(time.Millisecond),
 		status)
+	if result.Item.OriginalURL != "" {
+		fmt.Printf("      rewritten from %s\n", result.Item.OriginalURL)
+	}
 }
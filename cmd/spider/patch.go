@@ -0,0 +1,51 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/spider-crawler/spider/internal/config"
+)
+
+// runApplyPatch merges a generated config patch, such as the query
+// parameter recommendations, into a config file.
+func runApplyPatch(args []string) error {
+	fs := flag.NewFlagSet("apply-patch", flag.ExitOnError)
+	output := fs.String("o", "", "Output config file (default: overwrite the input)")
+	fs.Usage = func() {
+		fmt.Println("Usage: spider apply-patch [options] <config.json> <patch.json>")
+		fmt.Println()
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return fmt.Errorf("a config file and a patch file are required")
+	}
+	configPath, patchPath := fs.Arg(0), fs.Arg(1)
+
+	// Load without resolving secrets so that references are written back
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return err
+	}
+	patch, err := config.LoadPatch(patchPath)
+	if err != nil {
+		return err
+	}
+
+	added, err := cfg.ApplyPatch(patch)
+	if err != nil {
+		return err
+	}
+
+	if *output == "" {
+		*output = configPath
+	}
+	if err := cfg.Save(*output); err != nil {
+		return err
+	}
+	fmt.Printf("Added %d entries to %s\n", added, *output)
+	return nil
+}
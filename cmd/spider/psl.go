@@ -0,0 +1,74 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/spider-crawler/spider/internal/urlutil"
+)
+
+// runPSL manages the Public Suffix List used for domain decisions.
+func runPSL(args []string) error {
+	fs := flag.NewFlagSet("psl", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Println("Usage: spider psl <command> [args]")
+		fmt.Println()
+		fmt.Println("Commands:")
+		fmt.Println("  update <file>    Install a downloaded public_suffix_list.dat")
+		fmt.Println("  info             Show the list in use")
+		fmt.Println("  domain <host>... Show the public suffix and registrable domain of hosts")
+		fmt.Println()
+		fmt.Printf("The list is installed to %s (set %s to change).\n", urlutil.SuffixListPath(), urlutil.EnvSuffixList)
+		fmt.Println("Download the latest list from https://publicsuffix.org/list/public_suffix_list.dat")
+	}
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return fmt.Errorf("a command is required")
+	}
+
+	command, rest := fs.Arg(0), fs.Args()[1:]
+	switch command {
+	case "update":
+		if len(rest) != 1 {
+			return fmt.Errorf("update requires a list file")
+		}
+		l, err := urlutil.InstallSuffixList(rest[0])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Installed %s (%d ICANN rules, %d private rules)\n", l.Source, l.ICANN, l.Private)
+		return nil
+
+	case "info":
+		l := urlutil.DefaultSuffixList()
+		fmt.Printf("Source:        %s\n", l.Source)
+		fmt.Printf("ICANN rules:   %d\n", l.ICANN)
+		fmt.Printf("Private rules: %d\n", l.Private)
+		return nil
+
+	case "domain":
+		if len(rest) == 0 {
+			return fmt.Errorf("domain requires at least one host")
+		}
+		l := urlutil.DefaultSuffixList()
+		for _, host := range rest {
+			suffix, private := l.PublicSuffix(host)
+			section := "icann"
+			if private {
+				section = "private"
+			}
+			domain := l.Domain(host)
+			if domain == "" {
+				domain = "(public suffix)"
+			}
+			fmt.Printf("%-40s suffix=%s (%s) domain=%s\n", host, suffix, section, domain)
+		}
+		return nil
+
+	default:
+		fs.Usage()
+		return fmt.Errorf("unknown command: %s", command)
+	}
+}
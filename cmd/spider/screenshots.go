@@ -0,0 +1,134 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+
+	"github.com/spider-crawler/spider/internal/config"
+	"github.com/spider-crawler/spider/internal/renderer"
+	"github.com/spider-crawler/spider/internal/screenshot"
+)
+
+// runScreenshots renders the given URLs and stores their screenshots.
+func runScreenshots(args []string) error {
+	fs := flag.NewFlagSet("screenshot", flag.ExitOnError)
+	dir := fs.String("dir", "", "Screenshot directory, with a subdirectory per run (default from config)")
+	full := fs.Bool("full", false, "Capture the full page instead of above the fold")
+	quality := fs.Int("quality", 0, "JPEG quality 1-100 (default from config)")
+	configPath := fs.String("config", "", "Crawl config file (JSON)")
+	fs.Usage = func() {
+		fmt.Println("Usage: spider screenshot [options] <url> [url...]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return fmt.Errorf("at least one URL is required")
+	}
+
+	cfg := config.DefaultConfig()
+	if *configPath != "" {
+		loaded, err := loadConfig(*configPath)
+		if err != nil {
+			return err
+		}
+		cfg = loaded
+	}
+	cfg.RenderMode = config.RenderJS
+	cfg.Screenshots = config.ScreenshotViewport
+	if *full {
+		cfg.Screenshots = config.ScreenshotFullPage
+	}
+	if *dir != "" {
+		cfg.ScreenshotDir = *dir
+	}
+	if *quality > 0 {
+		cfg.ScreenshotQuality = *quality
+	}
+
+	// The renderer stores each screenshot as it renders
+	r, err := renderer.NewRenderer(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to start renderer: %w", err)
+	}
+	defer r.Close()
+
+	for _, result := range r.RenderBatch(fs.Args()) {
+		if result.Error != nil {
+			fmt.Printf("[warn] %v\n", result.Error)
+			continue
+		}
+		if result.ScreenshotError != nil {
+			fmt.Printf("[warn] %s: %v\n", result.FinalURL, result.ScreenshotError)
+		}
+	}
+
+	store := r.Screenshots()
+	pages, unique := store.Stats()
+	fmt.Printf("Stored %d screenshots (%d unique) in %s\n", pages, unique, store.Dir())
+	return nil
+}
+
+// runVisualDiff compares screenshots of two crawls and lists pages that
+// changed more than the threshold.
+func runVisualDiff(args []string) error {
+	fs := flag.NewFlagSet("visual-diff", flag.ExitOnError)
+	threshold := fs.Float64("threshold", 0.01, "Fraction of changed pixels that flags a page")
+	diffDir := fs.String("out", "", "Write highlighted diff images into this directory")
+	fs.Usage = func() {
+		fmt.Println("Usage: spider visual-diff [options] <before-dir> <after-dir>")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return fmt.Errorf("two screenshot directories are required")
+	}
+
+	before, err := screenshot.Open(fs.Arg(0), 0)
+	if err != nil {
+		return err
+	}
+	after, err := screenshot.Open(fs.Arg(1), 0)
+	if err != nil {
+		return err
+	}
+
+	diffs, err := screenshot.CompareStores(before, after)
+	if err != nil {
+		return err
+	}
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffs[i].Diff.PixelRatio > diffs[j].Diff.PixelRatio
+	})
+
+	changed := 0
+	for _, pd := range diffs {
+		if !pd.Diff.Changed(*threshold) {
+			continue
+		}
+		changed++
+
+		fmt.Printf("[changed] %s pixels=%.2f%% perceptual=%.2f", pd.URL, pd.Diff.PixelRatio*100, pd.Diff.Perceptual)
+		if pd.Diff.SizeChanged {
+			fmt.Printf(" size=%dx%d->%dx%d", pd.Before.Width, pd.Before.Height, pd.After.Width, pd.After.Height)
+		}
+		fmt.Println()
+
+		if *diffDir != "" {
+			path, err := screenshot.WriteDiffImage(*diffDir, pd, before, after)
+			if err != nil {
+				fmt.Printf("[warn] %s: %v\n", pd.URL, err)
+				continue
+			}
+			pd.DiffImage = path
+			fmt.Printf("          %s\n", path)
+		}
+	}
+
+	fmt.Printf("\n%d of %d pages changed more than %.2f%%\n", changed, len(diffs), *threshold*100)
+	return nil
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+
+	"github.com/spider-crawler/spider/internal/config"
+	"github.com/spider-crawler/spider/internal/vault"
+)
+
+// runSecrets manages the encrypted secrets vault.
+func runSecrets(args []string) error {
+	fs := flag.NewFlagSet("secrets", flag.ExitOnError)
+	vaultPath := fs.String("vault", vault.PathFromEnv(), "Vault file")
+	fs.Usage = func() {
+		fmt.Println("Usage: spider secrets [options] <command> [args]")
+		fmt.Println()
+		fmt.Println("Commands:")
+		fmt.Println("  add <name>     Store a secret")
+		fmt.Println("  list           List secret names and versions")
+		fmt.Println("  rotate <name>  Replace the value of an existing secret")
+		fmt.Println("  rekey          Re-encrypt the vault with a new key")
+		fmt.Println()
+		fmt.Println("Secret values are prompted for without echo, or read from stdin when it")
+		fmt.Println("is not a terminal, so they never appear in the process list or shell history.")
+		fmt.Println()
+		fmt.Printf("The key is read from %s or %s. For rekey, the new key is read\n", vault.EnvPassphrase, vault.EnvKeyFile)
+		fmt.Printf("from %s_NEW or %s_NEW.\n", vault.EnvPassphrase, vault.EnvKeyFile)
+		fmt.Printf("Reference secrets in config files as %sname.\n", vault.Scheme)
+		fmt.Println()
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return fmt.Errorf("a command is required")
+	}
+
+	key, err := vault.KeyFromEnv()
+	if err != nil {
+		return err
+	}
+
+	command, rest := fs.Arg(0), fs.Args()[1:]
+	switch command {
+	case "add":
+		v, err := vault.OpenOrCreate(*vaultPath, key)
+		if err != nil {
+			return err
+		}
+		return setSecret(v, rest, false, os.Stdin)
+
+	case "rotate":
+		v, err := vault.Open(*vaultPath, key)
+		if err != nil {
+			return err
+		}
+		return setSecret(v, rest, true, os.Stdin)
+
+	case "list":
+		v, err := vault.Open(*vaultPath, key)
+		if err != nil {
+			return err
+		}
+		entries := v.List()
+		if len(entries) == 0 {
+			fmt.Println("No secrets")
+			return nil
+		}
+		for _, e := range entries {
+			fmt.Printf("%s%-40s v%-3d updated %s\n", vault.Scheme, e.Name, e.Version, e.Updated.Format("2006-01-02 15:04"))
+		}
+		return nil
+
+	case "rekey":
+		v, err := vault.Open(*vaultPath, key)
+		if err != nil {
+			return err
+		}
+		newKey := vault.Key{
+			Passphrase: os.Getenv(vault.EnvPassphrase + "_NEW"),
+			KeyFile:    os.Getenv(vault.EnvKeyFile + "_NEW"),
+		}
+		if newKey.Passphrase == "" && newKey.KeyFile == "" {
+			return fmt.Errorf("no new key: set %s_NEW or %s_NEW", vault.EnvPassphrase, vault.EnvKeyFile)
+		}
+		if err := v.Rekey(newKey); err != nil {
+			return err
+		}
+		if err := v.Save(); err != nil {
+			return err
+		}
+		fmt.Printf("Re-encrypted %d secrets in %s\n", len(v.List()), v.Path())
+		return nil
+
+	default:
+		fs.Usage()
+		return fmt.Errorf("unknown command: %s", command)
+	}
+}
+
+// setSecret stores args[0] with a value read from in.
+func setSecret(v *vault.Vault, args []string, mustExist bool, in io.Reader) error {
+	if len(args) == 0 {
+		return fmt.Errorf("a secret name is required")
+	}
+	if len(args) > 1 {
+		return fmt.Errorf("secret values are not accepted as arguments; enter the value at the prompt or pipe it on stdin")
+	}
+	name := strings.TrimPrefix(args[0], vault.Scheme)
+
+	if mustExist {
+		if _, err := v.Get(name); err != nil {
+			return err
+		}
+	}
+
+	value, err := readSecretValue(name, in)
+	if err != nil {
+		return err
+	}
+
+	if err := v.Set(name, value); err != nil {
+		return err
+	}
+	if err := v.Save(); err != nil {
+		return err
+	}
+	fmt.Printf("Stored %s%s\n", vault.Scheme, name)
+	return nil
+}
+
+// readSecretValue prompts for a value without echo when in is a terminal,
+// and otherwise reads its first line.
+func readSecretValue(name string, in io.Reader) (string, error) {
+	if f, ok := in.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		fmt.Fprintf(os.Stderr, "Value for %s: ", name)
+		value, err := term.ReadPassword(int(f.Fd()))
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", fmt.Errorf("failed to read value: %w", err)
+		}
+		return string(value), nil
+	}
+
+	line, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("failed to read value: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// loadConfig loads a config file and resolves its secret:// references
+// from the vault.
+func loadConfig(path string) (*config.CrawlConfig, error) {
+	cfg, err := config.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	if !cfg.HasSecretRefs() {
+		return cfg, nil
+	}
+
+	key, err := vault.KeyFromEnv()
+	if err != nil {
+		return nil, fmt.Errorf("config references secrets: %w", err)
+	}
+	v, err := vault.Open(vault.PathFromEnv(), key)
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.ResolveSecrets(v.Resolve); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
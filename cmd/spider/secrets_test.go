@@ -0,0 +1,61 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spider-crawler/spider/internal/vault"
+)
+
+func TestSetSecret(t *testing.T) {
+	key := vault.Key{Passphrase: "correct horse"}
+	v, err := vault.Create(filepath.Join(t.TempDir(), "vault.json"), key)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		args      []string
+		mustExist bool
+		stdin     string
+		wantErr   bool
+		want      string
+	}{
+		{"value from stdin", []string{"client-a/password"}, false, "hunter2\n", false, "hunter2"},
+		{"CRLF line ending", []string{vault.Scheme + "client-a/user"}, false, "crawler\r\n", false, "crawler"},
+		{"rotate existing", []string{"client-a/password"}, true, "hunter3\n", false, "hunter3"},
+		{"rotate unknown", []string{"client-b/password"}, true, "hunter2\n", true, ""},
+		{"value on the command line", []string{"client-a/password", "hunter4"}, false, "", true, ""},
+		{"missing name", nil, false, "hunter2\n", true, ""},
+		{"empty stdin", []string{"client-a/token"}, false, "", true, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := setSecret(v, tt.args, tt.mustExist, strings.NewReader(tt.stdin))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("setSecret: %v", err)
+			}
+
+			reopened, err := vault.Open(v.Path(), key)
+			if err != nil {
+				t.Fatalf("Open: %v", err)
+			}
+			if got, err := reopened.Get(tt.args[0]); err != nil || got != tt.want {
+				t.Errorf("Get(%q) = %q, %v, want %q", tt.args[0], got, err, tt.want)
+			}
+		})
+	}
+
+	if got, _ := v.Get("client-a/password"); got != "hunter3" {
+		t.Errorf("command line value was stored: %q", got)
+	}
+}
@@ -8,6 +8,8 @@ require (
 	github.com/chromedp/chromedp v0.9.3
 	github.com/mattn/go-sqlite3 v1.14.19
 	github.com/xuri/excelize/v2 v2.8.0
+	golang.org/x/crypto v0.16.0
 	golang.org/x/net v0.19.0
+	golang.org/x/term v0.15.0
 	golang.org/x/time v0.5.0
 )
@@ -2,6 +2,7 @@
 package analyzer
 
 import (
+	"github.com/spider-crawler/spider/internal/parser"
 	"github.com/spider-crawler/spider/internal/storage"
 )
 
@@ -57,31 +58,39 @@ type FilterDef struct {
 
 // Thresholds for SEO analysis
 var Thresholds = struct {
-	TitleMinLength       int
-	TitleMaxLength       int
-	TitleMaxPixels       int
-	MetaDescMinLength    int
-	MetaDescMaxLength    int
-	MetaDescMaxPixels    int
-	H1MaxLength          int
-	URLMaxLength         int
-	ThinContentWordCount int
-	LargeImageSize       int64
-	SlowResponseTime     int64 // milliseconds
-	MaxRedirectChain     int
+	TitleMinLength          int
+	TitleMaxLength          int
+	TitleMaxPixels          int
+	MetaDescMinLength       int
+	MetaDescMaxLength       int
+	MetaDescMaxPixels       int
+	H1MaxLength             int
+	URLMaxLength            int
+	ThinContentWordCount    int
+	LargeImageSize          int64
+	SlowResponseTime        int64 // milliseconds
+	MaxRedirectChain        int
+	MobileWordCountRatio    float64 // minimum mobile/desktop word ratio
+	NearDuplicateSimilarity float64 // minimum shingle similarity for near duplicates
+	DifficultReadingEase    float64 // Flesch Reading Ease below which text is difficult
+	DifficultGradeLevel     float64 // Flesch-Kincaid grade above which English text is difficult
 }{
-	TitleMinLength:       30,
-	TitleMaxLength:       60,
-	TitleMaxPixels:       580,
-	MetaDescMinLength:    70,
-	MetaDescMaxLength:    155,
-	MetaDescMaxPixels:    920,
-	H1MaxLength:          70,
-	URLMaxLength:         115,
-	ThinContentWordCount: 200,
-	LargeImageSize:       100 * 1024, // 100KB
-	SlowResponseTime:     500,        // 500ms
-	MaxRedirectChain:     2,
+	TitleMinLength:          30,
+	TitleMaxLength:          60,
+	TitleMaxPixels:          580,
+	MetaDescMinLength:       70,
+	MetaDescMaxLength:       155,
+	MetaDescMaxPixels:       920,
+	H1MaxLength:             70,
+	URLMaxLength:            115,
+	ThinContentWordCount:    200,
+	LargeImageSize:          100 * 1024, // 100KB
+	SlowResponseTime:        500,        // 500ms
+	MaxRedirectChain:        2,
+	MobileWordCountRatio:    parser.DefaultMinWordCountRatio,
+	NearDuplicateSimilarity: 0.9,
+	DifficultReadingEase:    50,
+	DifficultGradeLevel:     12,
 }
 
 // Helper function to create an issue
@@ -2,19 +2,86 @@ package analyzer
 
 import (
 	"fmt"
+	"math"
+	"sort"
+	"strings"
 
+	"github.com/spider-crawler/spider/internal/langdetect"
+	"github.com/spider-crawler/spider/internal/parser"
 	"github.com/spider-crawler/spider/internal/storage"
 )
 
-// ContentAnalyzer analyzes page content.
+// ContentAnalyzer analyzes page content. Duplicates are detected on both
+// the full visible text and the main content, so that pages sharing an
+// article behind different navigation are found too.
 type ContentAnalyzer struct {
 	contentHashes map[string][]int64 // For exact duplicate detection
+	mainHashes    map[string][]int64
+
+	// Content area selectors for main content extraction
+	includeSelectors []string
+	excludeSelectors []string
+
+	// Readability thresholds
+	difficultEase  float64
+	difficultGrade float64
+
+	// Near duplicate detection, by scope
+	threshold      float64
+	signatures     map[string]map[int64]MinHash
+	results        map[int64]*AnalysisResult
+	nearDuplicates []*storage.NearDuplicate
+}
+
+// Average characters per word of languages written without spaces. Their
+// word counts are estimates, so thin content is judged on characters
+// instead, against the word threshold scaled by these ratios.
+var charsPerWord = map[string]float64{
+	"zh": 1.7,
+	"ja": 2.0,
+	"th": 4.5,
 }
 
 func NewContentAnalyzer() *ContentAnalyzer {
-	return &ContentAnalyzer{
-		contentHashes: make(map[string][]int64),
+	a := &ContentAnalyzer{
+		threshold:      Thresholds.NearDuplicateSimilarity,
+		difficultEase:  Thresholds.DifficultReadingEase,
+		difficultGrade: Thresholds.DifficultGradeLevel,
 	}
+	a.Reset()
+	return a
+}
+
+// SetNearDuplicateThreshold sets the minimum similarity (0-1) for pages to
+// be clustered as near duplicates.
+func (a *ContentAnalyzer) SetNearDuplicateThreshold(threshold float64) {
+	if threshold > 0 && threshold <= 1 {
+		a.threshold = threshold
+	}
+}
+
+// SetReadabilityThresholds sets the Flesch Reading Ease below which, and
+// the Flesch-Kincaid grade above which, content is difficult to read. The
+// grade level only applies to English.
+func (a *ContentAnalyzer) SetReadabilityThresholds(readingEase, gradeLevel float64) {
+	if readingEase > 0 && readingEase <= 100 {
+		a.difficultEase = readingEase
+	}
+	if gradeLevel > 0 {
+		a.difficultGrade = gradeLevel
+	}
+}
+
+// SetContentSelectors sets the CSS selectors of the main content area and
+// of elements excluded from it. Without include selectors the main content
+// is detected automatically.
+func (a *ContentAnalyzer) SetContentSelectors(include, exclude []string) error {
+	if _, err := parser.ParseSelectors(append(append([]string{}, include...), exclude...)); err != nil {
+		return err
+	}
+	a.includeSelectors = include
+	a.excludeSelectors = exclude
+	return nil
 }
 
 func (a *ContentAnalyzer) Name() string {
@@ -25,20 +92,41 @@ func (a *ContentAnalyzer) Columns() []ColumnDef {
 	return []ColumnDef{
 		{ID: "url", Title: "Address", Width: 300, Sortable: true, DataKey: "url"},
 		{ID: "word_count", Title: "Word Count", Width: 90, Sortable: true, DataKey: "word_count"},
+		{ID: "main_word_count", Title: "Main Content Word Count", Width: 90, Sortable: true, DataKey: "main_word_count"},
+		{ID: "char_count", Title: "Character Count", Width: 90, Sortable: true, DataKey: "char_count"},
+		{ID: "main_char_count", Title: "Main Content Character Count", Width: 90, Sortable: true, DataKey: "main_char_count"},
 		{ID: "content_hash", Title: "Content Hash", Width: 120, Sortable: true, DataKey: "content_hash"},
 		{ID: "status", Title: "Status", Width: 100, Sortable: true, DataKey: "status"},
 		{ID: "occurrences", Title: "Duplicates", Width: 80, Sortable: true, DataKey: "occurrences"},
+		{ID: "main_occurrences", Title: "Main Content Duplicates", Width: 80, Sortable: true, DataKey: "main_occurrences"},
+		{ID: "closest_match", Title: "Closest Match", Width: 300, Sortable: true, DataKey: "closest_match"},
+		{ID: "closest_similarity", Title: "Closest Similarity", Width: 110, Sortable: true, DataKey: "closest_similarity"},
+		{ID: "near_duplicates", Title: "Near Duplicates", Width: 100, Sortable: true, DataKey: "near_duplicates"},
+		{ID: "main_closest_match", Title: "Main Content Closest Match", Width: 300, Sortable: true, DataKey: "main_closest_match"},
+		{ID: "main_closest_similarity", Title: "Main Content Closest Similarity", Width: 110, Sortable: true, DataKey: "main_closest_similarity"},
+		{ID: "main_near_duplicates", Title: "Main Content Near Duplicates", Width: 100, Sortable: true, DataKey: "main_near_duplicates"},
+		{ID: "language", Title: "Readability Language", Width: 70, Sortable: true, DataKey: "language"},
+		{ID: "declared_language", Title: "Declared Language", Width: 80, Sortable: true, DataKey: "declared_language"},
+		{ID: "content_language_header", Title: "Content-Language", Width: 80, Sortable: true, DataKey: "content_language_header"},
+		{ID: "detected_language", Title: "Detected Language", Width: 80, Sortable: true, DataKey: "detected_language"},
+		{ID: "language_confidence", Title: "Language Confidence", Width: 80, Sortable: true, DataKey: "language_confidence"},
+		{ID: "language_mismatch", Title: "Language Mismatch", Width: 150, Sortable: true, DataKey: "language_mismatch"},
+		{ID: "flesch_reading_ease", Title: "Flesch Reading Ease", Width: 90, Sortable: true, DataKey: "flesch_reading_ease"},
+		{ID: "flesch_kincaid_grade", Title: "Flesch-Kincaid Grade", Width: 90, Sortable: true, DataKey: "flesch_kincaid_grade"},
+		{ID: "atesman", Title: "Ateşman", Width: 80, Sortable: true, DataKey: "atesman"},
+		{ID: "readability", Title: "Readability", Width: 110, Sortable: true, DataKey: "readability"},
+		{ID: "sentence_count", Title: "Sentences", Width: 80, Sortable: true, DataKey: "sentence_count"},
+		{ID: "avg_sentence_length", Title: "Avg Words per Sentence", Width: 90, Sortable: true, DataKey: "avg_sentence_length"},
+		{ID: "syllable_count", Title: "Syllables", Width: 80, Sortable: true, DataKey: "syllable_count"},
+		{ID: "avg_syllables", Title: "Avg Syllables per Word", Width: 90, Sortable: true, DataKey: "avg_syllables"},
 	}
 }
 
 func (a *ContentAnalyzer) Filters() []FilterDef {
 	return []FilterDef{
 		{ID: "all", Label: "All", Description: "All URLs"},
-		{ID: "thin", Label: "Low Word Count", Description: "Pages with < 200 words", FilterFunc: func(r *AnalysisResult) bool {
-			if wc, ok := r.Data["word_count"].(int); ok {
-				return wc > 0 && wc < Thresholds.ThinContentWordCount
-			}
-			return false
+		{ID: "thin", Label: "Low Word Count", Description: "Pages with < 200 words of main content, or the equivalent in characters for Chinese, Japanese and Thai", FilterFunc: func(r *AnalysisResult) bool {
+			return r.Data["status"] == "Thin"
 		}},
 		{ID: "duplicate", Label: "Duplicate Content", Description: "Exact duplicate content", FilterFunc: func(r *AnalysisResult) bool {
 			if occ, ok := r.Data["occurrences"].(int); ok {
@@ -46,6 +134,36 @@ func (a *ContentAnalyzer) Filters() []FilterDef {
 			}
 			return false
 		}},
+		{ID: "main_duplicate", Label: "Duplicate Main Content", Description: "Exact duplicate main content, with different boilerplate", FilterFunc: func(r *AnalysisResult) bool {
+			occ, _ := r.Data["occurrences"].(int)
+			mainOcc, _ := r.Data["main_occurrences"].(int)
+			return mainOcc > 1 && occ <= 1
+		}},
+		{ID: "near_duplicate", Label: "Near Duplicates", Description: "Pages in a near-duplicate cluster, including exact duplicates", FilterFunc: func(r *AnalysisResult) bool {
+			if n, ok := r.Data["near_duplicates"].(int); ok {
+				return n > 0
+			}
+			return false
+		}},
+		{ID: "near_duplicate_only", Label: "Near Duplicates (Not Exact)", Description: "Near duplicates missed by the exact content hash", FilterFunc: func(r *AnalysisResult) bool {
+			n, _ := r.Data["near_duplicates"].(int)
+			occ, _ := r.Data["occurrences"].(int)
+			return n > 0 && occ <= 1
+		}},
+		{ID: "main_near_duplicate", Label: "Main Content Near Duplicates", Description: "Pages in a near-duplicate cluster by main content", FilterFunc: func(r *AnalysisResult) bool {
+			if n, ok := r.Data["main_near_duplicates"].(int); ok {
+				return n > 0
+			}
+			return false
+		}},
+		{ID: "difficult", Label: "Difficult to Read", Description: "Pages below the reading ease or, in English, above the grade level threshold", FilterFunc: func(r *AnalysisResult) bool {
+			difficult, _ := r.Data["difficult_to_read"].(bool)
+			return difficult
+		}},
+		{ID: "language_mismatch", Label: "Language Mismatch", Description: "Detected language contradicts the lang attribute or Content-Language header", FilterFunc: func(r *AnalysisResult) bool {
+			_, ok := r.Data["language_mismatch"].(string)
+			return ok
+		}},
 		{ID: "empty", Label: "No Content", Description: "Pages with no content", FilterFunc: func(r *AnalysisResult) bool {
 			if wc, ok := r.Data["word_count"].(int); ok {
 				return wc == 0
@@ -66,6 +184,9 @@ func (a *ContentAnalyzer) Analyze(ctx *AnalysisContext) *AnalysisResult {
 
 	if ctx.HTMLFeatures == nil {
 		result.Data["word_count"] = 0
+		result.Data["main_word_count"] = 0
+		result.Data["char_count"] = 0
+		result.Data["main_char_count"] = 0
 		result.Data["content_hash"] = ""
 		result.Data["status"] = "No HTML"
 		return result
@@ -73,9 +194,49 @@ func (a *ContentAnalyzer) Analyze(ctx *AnalysisContext) *AnalysisResult {
 
 	wordCount := ctx.HTMLFeatures.WordCount
 	contentHash := ctx.HTMLFeatures.ContentHash
+	mainWordCount := ctx.HTMLFeatures.MainWordCount
+	mainHash := ctx.HTMLFeatures.MainContentHash
+	hasMain := mainHash != "" || mainWordCount > 0
+	charCount := ctx.HTMLFeatures.CharCount
+	mainCharCount := ctx.HTMLFeatures.MainCharCount
+	lang := ctx.HTMLFeatures.Language
+
+	// Main content and near duplicate signatures need the HTML
+	if ctx.RawHTML != nil {
+		if page, err := a.parse(ctx.URL.URL, ctx.RawHTML); err == nil {
+			if !hasMain {
+				mainWordCount = page.MainWordCount
+				mainHash = page.MainContentHash
+				hasMain = true
+			}
+			if charCount == 0 {
+				charCount = page.CharCount
+				mainCharCount = page.MainCharCount
+			}
+			text := page.MainContent
+			if strings.TrimSpace(text) == "" {
+				text = page.TextContent
+			}
+			result.Issues = append(result.Issues, a.analyzeLanguage(ctx, text, page.Language, result)...)
+
+			// Score readability in the language the text is written in
+			lang = page.Language
+			if reliable, _ := result.Data["language_reliable"].(bool); reliable {
+				lang = result.Data["detected_language"].(string)
+			}
+			result.Issues = append(result.Issues, a.analyzeReadability(ctx.URL.ID, text, lang, result)...)
+			a.addSignature(storage.NearDuplicateScopeFull, ctx.URL.ID, page.TextContent)
+			a.addSignature(storage.NearDuplicateScopeMain, ctx.URL.ID, page.MainContent)
+			a.results[ctx.URL.ID] = result
+		}
+	}
 
 	result.Data["word_count"] = wordCount
 	result.Data["content_hash"] = contentHash
+	result.Data["main_word_count"] = mainWordCount
+	result.Data["char_count"] = charCount
+	result.Data["main_char_count"] = mainCharCount
+	result.Data["main_content_hash"] = mainHash
 
 	// Track for duplicate detection
 	if contentHash != "" {
@@ -84,19 +245,41 @@ func (a *ContentAnalyzer) Analyze(ctx *AnalysisContext) *AnalysisResult {
 	} else {
 		result.Data["occurrences"] = 0
 	}
+	if mainHash != "" {
+		a.mainHashes[mainHash] = append(a.mainHashes[mainHash], ctx.URL.ID)
+		result.Data["main_occurrences"] = len(a.mainHashes[mainHash])
+	} else {
+		result.Data["main_occurrences"] = 0
+	}
 
-	// Determine status and generate issues
+	// Determine status and generate issues. Thin content is judged on the
+	// main content when it is known, as navigation inflates the word count,
+	// and on characters for languages written without spaces.
+	total, main, unit := wordCount, mainWordCount, "words"
+	minimum := Thresholds.ThinContentWordCount
+	if ratio, ok := charsPerWord[langdetect.Base(lang)]; ok && charCount > 0 {
+		total, main, unit = charCount, mainCharCount, "characters"
+		minimum = int(math.Round(float64(minimum) * ratio))
+	}
+	thinCount := total
+	if hasMain {
+		thinCount = main
+	}
 	if wordCount == 0 {
 		result.Data["status"] = "Empty"
-	} else if wordCount < Thresholds.ThinContentWordCount {
+	} else if thinCount < minimum {
 		result.Data["status"] = "Thin"
+		message := fmt.Sprintf("Thin content: only %d %s (recommended min: %d)", total, unit, minimum)
+		if hasMain {
+			message = fmt.Sprintf("Thin content: only %d %s of main content, %d in total (recommended min: %d)", main, unit, total, minimum)
+		}
 		result.Issues = append(result.Issues, NewIssue(
 			ctx.URL.ID,
 			storage.IssueThinContent,
 			storage.IssueTypeWarning,
 			storage.SeverityMedium,
 			"content",
-			fmt.Sprintf("Thin content: only %d words (recommended min: %d)", wordCount, Thresholds.ThinContentWordCount),
+			message,
 		))
 	} else {
 		result.Data["status"] = "OK"
@@ -105,13 +288,151 @@ func (a *ContentAnalyzer) Analyze(ctx *AnalysisContext) *AnalysisResult {
 	return result
 }
 
-// AnalyzeDuplicates detects duplicate content.
+// analyzeReadability adds the readability scores of the page text to the
+// result, and raises an issue when the text is difficult to read.
+func (a *ContentAnalyzer) analyzeReadability(urlID int64, text, lang string, result *AnalysisResult) []*storage.Issue {
+	r := AnalyzeReadability(text, lang)
+	if r == nil {
+		return nil
+	}
+
+	result.Data["language"] = r.Language
+	result.Data["sentence_count"] = r.Sentences
+	result.Data["avg_sentence_length"] = round1(r.AvgSentence)
+	if !r.HasReadingEase() {
+		return nil
+	}
+	result.Data["flesch_reading_ease"] = round1(r.ReadingEase)
+	if r.HasGradeLevel() {
+		result.Data["flesch_kincaid_grade"] = round1(r.GradeLevel)
+	}
+	if r.Language == "tr" {
+		result.Data["atesman"] = round1(r.Atesman)
+	}
+	result.Data["readability"] = r.Difficulty
+	result.Data["syllable_count"] = r.Syllables
+	result.Data["avg_syllables"] = round1(r.AvgSyllables)
+
+	difficult := r.ReadingEase < a.difficultEase || (r.HasGradeLevel() && r.GradeLevel > a.difficultGrade)
+	if !difficult || r.Words < readabilityMinWords {
+		return nil
+	}
+	result.Data["difficult_to_read"] = true
+	message := fmt.Sprintf("Difficult to read: reading ease %.1f (%s)", r.ReadingEase, r.Difficulty)
+	if r.HasGradeLevel() {
+		message += fmt.Sprintf(", grade level %.1f", r.GradeLevel)
+	}
+	return []*storage.Issue{NewIssue(
+		urlID,
+		storage.IssueDifficultToRead,
+		storage.IssueTypeNotice,
+		storage.SeverityLow,
+		"content",
+		message,
+	)}
+}
+
+// analyzeLanguage detects the language of the page text and flags a lang
+// attribute or Content-Language header naming another language. Declared
+// languages that cannot be detected are not checked.
+func (a *ContentAnalyzer) analyzeLanguage(ctx *AnalysisContext, text, declared string, result *AnalysisResult) []*storage.Issue {
+	header := ""
+	if ctx.Fetch != nil {
+		for name, value := range ctx.Fetch.Headers {
+			if strings.EqualFold(name, "Content-Language") {
+				header = strings.TrimSpace(value)
+			}
+		}
+	}
+	result.Data["declared_language"] = declared
+	result.Data["content_language_header"] = header
+
+	detected := langdetect.Detect(text)
+	if detected.Language == "" {
+		return nil
+	}
+	result.Data["detected_language"] = detected.Language
+	result.Data["language_confidence"] = fmt.Sprintf("%.0f%%", detected.Confidence*100)
+	if detected.Confidence < languageMinConfidence || len(readabilityWords(text)) < languageMinWords {
+		return nil
+	}
+	result.Data["language_reliable"] = true
+
+	issues := make([]*storage.Issue, 0)
+	mismatches := make([]string, 0)
+	if declared != "" && langdetect.Known(declared) && !langdetect.Matches(declared, detected.Language) {
+		mismatches = append(mismatches, "lang="+declared)
+		issues = append(issues, NewIssue(
+			ctx.URL.ID,
+			storage.IssueLangMismatch,
+			storage.IssueTypeWarning,
+			storage.SeverityMedium,
+			"content",
+			fmt.Sprintf("Declared lang %q but the content is detected as %s (%.0f%% confidence)", declared, detected.Language, detected.Confidence*100),
+		))
+	}
+
+	// The header may list several languages; any of them may match
+	if header != "" {
+		known, matched := false, false
+		for _, tag := range strings.Split(header, ",") {
+			tag = strings.TrimSpace(tag)
+			if langdetect.Known(tag) {
+				known = true
+				matched = matched || langdetect.Matches(tag, detected.Language)
+			}
+		}
+		if known && !matched {
+			mismatches = append(mismatches, "Content-Language: "+header)
+			issues = append(issues, NewIssue(
+				ctx.URL.ID,
+				storage.IssueContentLanguageMismatch,
+				storage.IssueTypeWarning,
+				storage.SeverityMedium,
+				"content",
+				fmt.Sprintf("Content-Language header %q but the content is detected as %s (%.0f%% confidence)", header, detected.Language, detected.Confidence*100),
+			))
+		}
+	}
+
+	if len(mismatches) > 0 {
+		result.Data["language_mismatch"] = strings.Join(mismatches, "; ")
+	}
+	return issues
+}
+
+func round1(v float64) float64 {
+	return math.Round(v*10) / 10
+}
+
+// parse parses a page with the content area selectors.
+func (a *ContentAnalyzer) parse(pageURL string, html []byte) (*parser.PageData, error) {
+	p, err := parser.NewParser(pageURL)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.SetContentSelectors(a.includeSelectors, a.excludeSelectors); err != nil {
+		return nil, err
+	}
+	return p.Parse(html)
+}
+
+func (a *ContentAnalyzer) addSignature(scope string, urlID int64, text string) {
+	if sig := NewMinHash(text); sig != nil {
+		a.signatures[scope][urlID] = sig
+	}
+}
+
+// AnalyzeDuplicates detects duplicate content. Pages sharing only their
+// main content are reported separately from full duplicates.
 func (a *ContentAnalyzer) AnalyzeDuplicates() []*storage.Issue {
 	issues := make([]*storage.Issue, 0)
 
+	duplicates := make(map[int64]bool)
 	for hash, urlIDs := range a.contentHashes {
 		if len(urlIDs) > 1 {
 			for _, urlID := range urlIDs {
+				duplicates[urlID] = true
 				issues = append(issues, NewIssue(
 					urlID,
 					storage.IssueDuplicateContent,
@@ -124,11 +445,192 @@ func (a *ContentAnalyzer) AnalyzeDuplicates() []*storage.Issue {
 		}
 	}
 
+	for hash, urlIDs := range a.mainHashes {
+		if len(urlIDs) > 1 {
+			for _, urlID := range urlIDs {
+				if duplicates[urlID] {
+					continue
+				}
+				issues = append(issues, NewIssue(
+					urlID,
+					storage.IssueDuplicateMainContent,
+					storage.IssueTypeWarning,
+					storage.SeverityHigh,
+					"content",
+					fmt.Sprintf("Duplicate main content found on %d pages (hash: %s)", len(urlIDs), hash[:8]),
+				))
+			}
+		}
+	}
+
+	return issues
+}
+
+// AnalyzeNearDuplicates finds each page's closest match by MinHash LSH and
+// clusters the pages whose similarity reaches the threshold, first by full
+// text and then by main content. The closest match and similarity are added
+// to the page results, main content ones with a "main_" prefix.
+func (a *ContentAnalyzer) AnalyzeNearDuplicates() []*storage.Issue {
+	a.nearDuplicates = make([]*storage.NearDuplicate, 0)
+	issues := a.analyzeNearDuplicates(storage.NearDuplicateScopeFull)
+	return append(issues, a.analyzeNearDuplicates(storage.NearDuplicateScopeMain)...)
+}
+
+func (a *ContentAnalyzer) analyzeNearDuplicates(scope string) []*storage.Issue {
+	issues := make([]*storage.Issue, 0)
+	signatures := a.signatures[scope]
+	key := func(name string) string {
+		if scope == storage.NearDuplicateScopeMain {
+			return "main_" + name
+		}
+		return name
+	}
+
+	ids := make([]int64, 0, len(signatures))
+	for id := range signatures {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	sigs := make([]MinHash, len(ids))
+	for i, id := range ids {
+		sigs[i] = signatures[id]
+	}
+
+	// Pages sharing any band are candidates. Ties go to the lowest URL ID,
+	// whatever order the candidates come in.
+	closest := make([]int, len(ids))
+	similarity := make([]float64, len(ids))
+	for i := range closest {
+		closest[i] = -1
+	}
+	closer := func(i, j int, sim float64) bool {
+		return sim > similarity[i] || sim == similarity[i] && closest[i] >= 0 && j < closest[i]
+	}
+	clusters := newUnionFind(len(ids))
+	candidatePairs(sigs, a.threshold, func(i, j int) {
+		sim := sigs[i].Similarity(sigs[j])
+		if closer(i, j, sim) {
+			closest[i], similarity[i] = j, sim
+		}
+		if closer(j, i, sim) {
+			closest[j], similarity[j] = i, sim
+		}
+		if sim >= a.threshold {
+			clusters.union(i, j)
+		}
+	})
+
+	// Number clusters by their lowest URL ID
+	sizes := make(map[int]int)
+	for i := range ids {
+		sizes[clusters.find(i)]++
+	}
+	clusterIDs := make(map[int]int)
+	for i, id := range ids {
+		if closest[i] < 0 {
+			continue
+		}
+		match := &storage.NearDuplicate{
+			URLID:        id,
+			Scope:        scope,
+			ClosestURLID: ids[closest[i]],
+			Similarity:   similarity[i],
+		}
+		root := clusters.find(i)
+		if sizes[root] > 1 {
+			if _, ok := clusterIDs[root]; !ok {
+				clusterIDs[root] = len(clusterIDs) + 1
+			}
+			match.ClusterID = clusterIDs[root]
+			match.ClusterSize = sizes[root]
+		}
+		a.nearDuplicates = append(a.nearDuplicates, match)
+
+		result := a.results[id]
+		result.Data[key("closest_match_id")] = match.ClosestURLID
+		if closestResult := a.results[match.ClosestURLID]; closestResult != nil {
+			result.Data[key("closest_match")] = closestResult.Data["url"]
+		}
+		result.Data[key("closest_similarity")] = fmt.Sprintf("%.0f%%", match.Similarity*100)
+		result.Data[key("similarity")] = match.Similarity
+		if match.ClusterID == 0 {
+			continue
+		}
+		result.Data[key("near_duplicate_cluster")] = match.ClusterID
+		result.Data[key("near_duplicates")] = match.ClusterSize - 1
+
+		// Pages already near duplicates by full text need no second issue
+		if scope == storage.NearDuplicateScopeMain {
+			if n, _ := result.Data["near_duplicates"].(int); n > 0 {
+				continue
+			}
+			issues = append(issues, NewIssue(
+				id,
+				storage.IssueNearDuplicateMain,
+				storage.IssueTypeWarning,
+				storage.SeverityMedium,
+				"content",
+				fmt.Sprintf("Near duplicate main content: %d similar pages (closest %.0f%% similar: %v)", match.ClusterSize-1, match.Similarity*100, result.Data[key("closest_match")]),
+			))
+			continue
+		}
+		issues = append(issues, NewIssue(
+			id,
+			storage.IssueNearDuplicate,
+			storage.IssueTypeWarning,
+			storage.SeverityMedium,
+			"content",
+			fmt.Sprintf("Near duplicate content: %d similar pages (closest %.0f%% similar: %v)", match.ClusterSize-1, match.Similarity*100, result.Data["closest_match"]),
+		))
+	}
+
 	return issues
 }
 
+// NearDuplicates returns the matches found by AnalyzeNearDuplicates for
+// storage.
+func (a *ContentAnalyzer) NearDuplicates() []*storage.NearDuplicate {
+	return a.nearDuplicates
+}
+
 func (a *ContentAnalyzer) Reset() {
 	a.contentHashes = make(map[string][]int64)
+	a.mainHashes = make(map[string][]int64)
+	a.signatures = map[string]map[int64]MinHash{
+		storage.NearDuplicateScopeFull: make(map[int64]MinHash),
+		storage.NearDuplicateScopeMain: make(map[int64]MinHash),
+	}
+	a.results = make(map[int64]*AnalysisResult)
+	a.nearDuplicates = nil
+}
+
+// unionFind groups indexes into disjoint sets.
+type unionFind []int
+
+func newUnionFind(n int) unionFind {
+	u := make(unionFind, n)
+	for i := range u {
+		u[i] = i
+	}
+	return u
+}
+
+func (u unionFind) find(i int) int {
+	for u[i] != i {
+		u[i] = u[u[i]]
+		i = u[i]
+	}
+	return i
+}
+
+func (u unionFind) union(i, j int) {
+	ri, rj := u.find(i), u.find(j)
+	if ri < rj {
+		u[rj] = ri
+	} else if rj < ri {
+		u[ri] = rj
+	}
 }
 
 func (a *ContentAnalyzer) ExportRow(result *AnalysisResult) []string {
@@ -139,8 +641,32 @@ func (a *ContentAnalyzer) ExportRow(result *AnalysisResult) []string {
 	return []string{
 		fmt.Sprintf("%v", result.Data["url"]),
 		fmt.Sprintf("%v", result.Data["word_count"]),
+		fmt.Sprintf("%v", result.Data["main_word_count"]),
+		fmt.Sprintf("%v", result.Data["char_count"]),
+		fmt.Sprintf("%v", result.Data["main_char_count"]),
 		hash,
 		fmt.Sprintf("%v", result.Data["status"]),
 		fmt.Sprintf("%v", result.Data["occurrences"]),
+		fmt.Sprintf("%v", result.Data["main_occurrences"]),
+		fmt.Sprintf("%v", result.Data["closest_match"]),
+		fmt.Sprintf("%v", result.Data["closest_similarity"]),
+		fmt.Sprintf("%v", result.Data["near_duplicates"]),
+		fmt.Sprintf("%v", result.Data["main_closest_match"]),
+		fmt.Sprintf("%v", result.Data["main_closest_similarity"]),
+		fmt.Sprintf("%v", result.Data["main_near_duplicates"]),
+		fmt.Sprintf("%v", result.Data["language"]),
+		fmt.Sprintf("%v", result.Data["declared_language"]),
+		fmt.Sprintf("%v", result.Data["content_language_header"]),
+		fmt.Sprintf("%v", result.Data["detected_language"]),
+		fmt.Sprintf("%v", result.Data["language_confidence"]),
+		fmt.Sprintf("%v", result.Data["language_mismatch"]),
+		fmt.Sprintf("%v", result.Data["flesch_reading_ease"]),
+		fmt.Sprintf("%v", result.Data["flesch_kincaid_grade"]),
+		fmt.Sprintf("%v", result.Data["atesman"]),
+		fmt.Sprintf("%v", result.Data["readability"]),
+		fmt.Sprintf("%v", result.Data["sentence_count"]),
+		fmt.Sprintf("%v", result.Data["avg_sentence_length"]),
+		fmt.Sprintf("%v", result.Data["syllable_count"]),
+		fmt.Sprintf("%v", result.Data["avg_syllables"]),
 	}
 }
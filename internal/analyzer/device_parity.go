@@ -0,0 +1,182 @@
+package analyzer
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/spider-crawler/spider/internal/parser"
+	"github.com/spider-crawler/spider/internal/storage"
+)
+
+// DeviceParityAnalyzer reports differences between the desktop and mobile
+// versions of pages fetched in a dual-device crawl.
+type DeviceParityAnalyzer struct {
+	mu          sync.RWMutex
+	comparisons map[string]*parser.DeviceParity
+}
+
+func NewDeviceParityAnalyzer() *DeviceParityAnalyzer {
+	return &DeviceParityAnalyzer{
+		comparisons: make(map[string]*parser.DeviceParity),
+	}
+}
+
+func (a *DeviceParityAnalyzer) Name() string {
+	return "Device Parity"
+}
+
+func (a *DeviceParityAnalyzer) Columns() []ColumnDef {
+	return []ColumnDef{
+		{ID: "url", Title: "Address", Width: 300, Sortable: true, DataKey: "url"},
+		{ID: "desktop_words", Title: "Desktop Words", Width: 90, Sortable: true, DataKey: "desktop_word_count"},
+		{ID: "mobile_words", Title: "Mobile Words", Width: 90, Sortable: true, DataKey: "mobile_word_count"},
+		{ID: "word_ratio", Title: "Word Ratio", Width: 80, Sortable: true, DataKey: "word_ratio"},
+		{ID: "missing_links", Title: "Missing Links", Width: 90, Sortable: true, DataKey: "missing_link_count"},
+		{ID: "missing_sd", Title: "Missing Structured Data", Width: 160, Sortable: true, DataKey: "missing_structured_data"},
+		{ID: "status", Title: "Status", Width: 100, Sortable: true, DataKey: "status"},
+	}
+}
+
+func (a *DeviceParityAnalyzer) Filters() []FilterDef {
+	return []FilterDef{
+		{ID: "all", Label: "All", Description: "All compared URLs"},
+		{ID: "missing_content", Label: "Missing Mobile Content", Description: "Mobile has less text, links or structured data than desktop", FilterFunc: func(r *AnalysisResult) bool {
+			return r.Data["status"] == "Missing Content"
+		}},
+		{ID: "missing_links", Label: "Missing Mobile Links", Description: "Links only present on desktop", FilterFunc: func(r *AnalysisResult) bool {
+			if count, ok := r.Data["missing_link_count"].(int); ok {
+				return count > 0
+			}
+			return false
+		}},
+		{ID: "missing_sd", Label: "Missing Mobile Structured Data", Description: "Structured data only present on desktop", FilterFunc: func(r *AnalysisResult) bool {
+			if types, ok := r.Data["missing_structured_data"].(string); ok {
+				return types != ""
+			}
+			return false
+		}},
+		{ID: "metadata_mismatch", Label: "Metadata Mismatch", Description: "Title, description, canonical or H1 differ", FilterFunc: func(r *AnalysisResult) bool {
+			if match, ok := r.Data["metadata_match"].(bool); ok {
+				return !match
+			}
+			return false
+		}},
+	}
+}
+
+// AddComparison records the desktop/mobile comparison for a URL.
+func (a *DeviceParityAnalyzer) AddComparison(url string, parity *parser.DeviceParity) {
+	a.mu.Lock()
+	a.comparisons[url] = parity
+	a.mu.Unlock()
+}
+
+func (a *DeviceParityAnalyzer) Analyze(ctx *AnalysisContext) *AnalysisResult {
+	result := &AnalysisResult{
+		URLID:  ctx.URL.ID,
+		Issues: make([]*storage.Issue, 0),
+		Data:   make(map[string]interface{}),
+	}
+
+	result.Data["url"] = ctx.URL.URL
+
+	a.mu.RLock()
+	p, ok := a.comparisons[ctx.URL.URL]
+	a.mu.RUnlock()
+	if !ok {
+		result.Data["status"] = "Not Compared"
+		return result
+	}
+
+	metadataMatch := p.TitleMatch && p.MetaDescriptionMatch && p.CanonicalMatch && p.H1Match
+
+	result.Data["desktop_word_count"] = p.DesktopWordCount
+	result.Data["mobile_word_count"] = p.MobileWordCount
+	result.Data["word_ratio"] = p.WordCountRatio
+	result.Data["desktop_link_count"] = p.DesktopLinks
+	result.Data["mobile_link_count"] = p.MobileLinks
+	result.Data["missing_link_count"] = len(p.MissingLinks)
+	result.Data["missing_links"] = p.MissingLinks
+	result.Data["extra_link_count"] = len(p.ExtraLinks)
+	result.Data["missing_structured_data"] = strings.Join(p.MissingStructuredData, ", ")
+	result.Data["extra_structured_data"] = strings.Join(p.ExtraStructuredData, ", ")
+	result.Data["metadata_match"] = metadataMatch
+
+	// Mobile-first indexing: content missing on mobile is not indexed
+	if p.WordCountRatio < Thresholds.MobileWordCountRatio {
+		result.Issues = append(result.Issues, NewIssue(
+			ctx.URL.ID,
+			storage.IssueMobileMissingContent,
+			storage.IssueTypeWarning,
+			storage.SeverityHigh,
+			"mobile",
+			fmt.Sprintf("Mobile page has %d words vs %d on desktop (%.0f%%)", p.MobileWordCount, p.DesktopWordCount, p.WordCountRatio*100),
+		))
+	}
+
+	if len(p.MissingLinks) > 0 {
+		result.Issues = append(result.Issues, NewIssue(
+			ctx.URL.ID,
+			storage.IssueMobileMissingLinks,
+			storage.IssueTypeWarning,
+			storage.SeverityMedium,
+			"mobile",
+			fmt.Sprintf("%d links on desktop are missing on mobile", len(p.MissingLinks)),
+		))
+	}
+
+	if len(p.MissingStructuredData) > 0 {
+		result.Issues = append(result.Issues, NewIssue(
+			ctx.URL.ID,
+			storage.IssueMobileMissingStructuredData,
+			storage.IssueTypeWarning,
+			storage.SeverityMedium,
+			"mobile",
+			fmt.Sprintf("Structured data missing on mobile: %s", strings.Join(p.MissingStructuredData, ", ")),
+		))
+	}
+
+	if !metadataMatch {
+		result.Issues = append(result.Issues, NewIssue(
+			ctx.URL.ID,
+			storage.IssueMobileMetadataMismatch,
+			storage.IssueTypeNotice,
+			storage.SeverityLow,
+			"mobile",
+			"Title, meta description, canonical or H1 differ between mobile and desktop",
+		))
+	}
+
+	if p.MissingMobileContent(Thresholds.MobileWordCountRatio) {
+		result.Data["status"] = "Missing Content"
+	} else if !metadataMatch {
+		result.Data["status"] = "Metadata Mismatch"
+	} else {
+		result.Data["status"] = "OK"
+	}
+
+	return result
+}
+
+func (a *DeviceParityAnalyzer) Reset() {
+	a.mu.Lock()
+	a.comparisons = make(map[string]*parser.DeviceParity)
+	a.mu.Unlock()
+}
+
+func (a *DeviceParityAnalyzer) ExportRow(result *AnalysisResult) []string {
+	ratio := ""
+	if r, ok := result.Data["word_ratio"].(float64); ok {
+		ratio = fmt.Sprintf("%.2f", r)
+	}
+	return []string{
+		fmt.Sprintf("%v", result.Data["url"]),
+		fmt.Sprintf("%v", result.Data["desktop_word_count"]),
+		fmt.Sprintf("%v", result.Data["mobile_word_count"]),
+		ratio,
+		fmt.Sprintf("%v", result.Data["missing_link_count"]),
+		fmt.Sprintf("%v", result.Data["missing_structured_data"]),
+		fmt.Sprintf("%v", result.Data["status"]),
+	}
+}
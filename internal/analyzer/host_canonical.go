@@ -0,0 +1,353 @@
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spider-crawler/spider/internal/fetcher"
+	"github.com/spider-crawler/spider/internal/storage"
+)
+
+// Host variant kinds probed for every seed.
+const (
+	VariantScheme        = "scheme"         // http <-> https
+	VariantWWW           = "www"            // www <-> non-www
+	VariantTrailingSlash = "trailing_slash" // /page <-> /page/
+	VariantUppercase     = "uppercase"      // /PAGE
+	VariantIndexFile     = "index_file"     // /index.html
+	VariantEmptyQuery    = "empty_query"    // /page?
+)
+
+// Host variant verdicts.
+const (
+	VerdictOK           = "ok"            // Single 301 to the canonical URL
+	VerdictNotServed    = "not_served"    // 404, 410, no DNS record or connection refused, so no duplicate exists
+	VerdictDuplicate    = "duplicate"     // Served without redirecting
+	VerdictChain        = "chain"         // Reaches the canonical URL in several hops
+	VerdictNotPermanent = "not_permanent" // Single hop, but not a 301
+	VerdictWrongTarget  = "wrong_target"  // Redirects somewhere else
+	VerdictError        = "error"
+)
+
+// HostCanonicalAnalyzer probes the common duplicate variants of a seed URL
+// and checks that each one 301-redirects to the canonical form in a single
+// hop.
+type HostCanonicalAnalyzer struct {
+	responseCodes *ResponseCodesAnalyzer
+}
+
+func NewHostCanonicalAnalyzer(responseCodes *ResponseCodesAnalyzer) *HostCanonicalAnalyzer {
+	return &HostCanonicalAnalyzer{responseCodes: responseCodes}
+}
+
+func (a *HostCanonicalAnalyzer) Name() string {
+	return "Host Canonicalisation"
+}
+
+func (a *HostCanonicalAnalyzer) Columns() []ColumnDef {
+	return []ColumnDef{
+		{ID: "host", Title: "Host", Width: 180, Sortable: true, DataKey: "host"},
+		{ID: "variant", Title: "Variant", Width: 110, Sortable: true, DataKey: "variant"},
+		{ID: "url", Title: "Variant URL", Width: 300, Sortable: true, DataKey: "url"},
+		{ID: "status_code", Title: "Status Code", Width: 80, Sortable: true, DataKey: "status_code"},
+		{ID: "redirect_url", Title: "Redirects To", Width: 250, Sortable: true, DataKey: "redirect_url"},
+		{ID: "hops", Title: "Hops", Width: 60, Sortable: true, DataKey: "hops"},
+		{ID: "verdict", Title: "Verdict", Width: 110, Sortable: true, DataKey: "verdict"},
+		{ID: "detail", Title: "Detail", Width: 250, Sortable: false, DataKey: "detail"},
+	}
+}
+
+func (a *HostCanonicalAnalyzer) Filters() []FilterDef {
+	return []FilterDef{
+		{ID: "all", Label: "All", Description: "All probed variants"},
+		{ID: "failed", Label: "Failed", Description: "Variants not redirecting to the canonical URL with a single 301", FilterFunc: func(r *AnalysisResult) bool {
+			passed, _ := r.Data["passed"].(bool)
+			return !passed
+		}},
+		{ID: "duplicate", Label: "Duplicates", Description: "Variants served without redirecting", FilterFunc: func(r *AnalysisResult) bool {
+			return r.Data["verdict"] == VerdictDuplicate
+		}},
+		{ID: "chain", Label: "Redirect Chains", Description: "Variants reaching the canonical URL in several hops", FilterFunc: func(r *AnalysisResult) bool {
+			return r.Data["verdict"] == VerdictChain
+		}},
+	}
+}
+
+// Probe fetches the seed to find its canonical URL, then fetches every
+// duplicate variant of it. The fetcher should follow redirects so that
+// chains can be told apart from redirects to the wrong URL.
+func (a *HostCanonicalAnalyzer) Probe(ctx context.Context, f *fetcher.Fetcher, seedURL string) ([]*storage.HostVariant, error) {
+	seed := f.Fetch(ctx, seedURL)
+	if seed.Error != nil {
+		return nil, fmt.Errorf("failed to fetch seed: %w", seed.Error)
+	}
+	cu, err := url.Parse(seed.FinalURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid canonical URL: %w", err)
+	}
+	if cu.Path == "" {
+		cu.Path = "/"
+	}
+	canonical := cu.String()
+
+	variants := make([]*storage.HostVariant, 0)
+	for _, v := range hostVariants(cu) {
+		if ctx.Err() != nil {
+			return variants, ctx.Err()
+		}
+		variant := &storage.HostVariant{
+			Host:         cu.Hostname(),
+			CanonicalURL: canonical,
+			Variant:      v.kind,
+			URL:          v.url,
+			CheckedAt:    time.Now(),
+		}
+		a.classify(variant, f.Fetch(ctx, v.url))
+		variants = append(variants, variant)
+	}
+	return variants, nil
+}
+
+// classify sets the verdict of a variant from its response.
+func (a *HostCanonicalAnalyzer) classify(v *storage.HostVariant, resp *fetcher.Response) {
+	v.FinalURL = resp.FinalURL
+	v.Hops = resp.RedirectCount()
+	if resp.HasRedirects() {
+		first := resp.RedirectChain[0]
+		v.StatusCode = first.StatusCode
+		v.RedirectURL = resolveLocation(v.URL, first.Location)
+	} else {
+		v.StatusCode = resp.StatusCode
+	}
+
+	switch {
+	case resp.Error != nil && !resp.HasRedirects() && notServed(resp.Error) != "":
+		v.Verdict = VerdictNotServed
+		v.Passed = true
+		v.Detail = notServed(resp.Error)
+
+	case resp.Error != nil && !resp.HasRedirects():
+		v.Verdict = VerdictError
+		v.Detail = resp.Error.Error()
+
+	case !resp.HasRedirects() && (resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone):
+		v.Verdict = VerdictNotServed
+		v.Passed = true
+		v.Detail = "variant is not served"
+
+	case !resp.HasRedirects() && resp.StatusCode >= 200 && resp.StatusCode < 300:
+		v.Verdict = VerdictDuplicate
+		v.Detail = fmt.Sprintf("served with %d instead of redirecting", resp.StatusCode)
+
+	case !resp.HasRedirects():
+		v.Verdict = VerdictError
+		v.Detail = fmt.Sprintf("unexpected status %d", resp.StatusCode)
+
+	case !sameURL(v.RedirectURL, v.CanonicalURL) && sameURL(resp.FinalURL, v.CanonicalURL):
+		v.Verdict = VerdictChain
+		v.Detail = fmt.Sprintf("reaches the canonical URL in %d hops", v.Hops)
+
+	case !sameURL(v.RedirectURL, v.CanonicalURL):
+		v.Verdict = VerdictWrongTarget
+		v.Detail = "redirects to " + v.RedirectURL
+
+	case v.StatusCode != http.StatusMovedPermanently:
+		v.Verdict = VerdictNotPermanent
+		v.Detail = a.responseCodes.getRedirectType(v.StatusCode) + " instead of Permanent (301)"
+
+	default:
+		v.Verdict = VerdictOK
+		v.Passed = true
+	}
+}
+
+// notServed describes a fetch error showing that nothing answers for a
+// variant: its host does not resolve or refuses connections. It returns ""
+// for other errors.
+func notServed(err error) string {
+	var dnsErr *net.DNSError
+	switch {
+	case errors.As(err, &dnsErr) && dnsErr.IsNotFound:
+		return "host does not resolve"
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return "connection refused"
+	}
+	return ""
+}
+
+// AnalyzeVariant converts a probed variant for display and raises an issue
+// if it fails the check.
+func (a *HostCanonicalAnalyzer) AnalyzeVariant(v *storage.HostVariant) *AnalysisResult {
+	result := &AnalysisResult{
+		Issues: make([]*storage.Issue, 0),
+		Data:   make(map[string]interface{}),
+	}
+
+	result.Data["host"] = v.Host
+	result.Data["canonical_url"] = v.CanonicalURL
+	result.Data["variant"] = v.Variant
+	result.Data["url"] = v.URL
+	result.Data["status_code"] = v.StatusCode
+	result.Data["redirect_url"] = v.RedirectURL
+	result.Data["hops"] = v.Hops
+	result.Data["verdict"] = v.Verdict
+	result.Data["passed"] = v.Passed
+	result.Data["detail"] = v.Detail
+
+	switch {
+	case v.Passed:
+	case v.Verdict == VerdictDuplicate:
+		result.Issues = append(result.Issues, NewIssue(
+			0,
+			storage.IssueHostVariantDuplicate,
+			storage.IssueTypeError,
+			storage.SeverityHigh,
+			"canonicalisation",
+			fmt.Sprintf("%s variant %s is served instead of redirecting to %s", v.Variant, v.URL, v.CanonicalURL),
+		))
+	default:
+		result.Issues = append(result.Issues, NewIssue(
+			0,
+			storage.IssueHostVariantRedirect,
+			storage.IssueTypeWarning,
+			storage.SeverityMedium,
+			"canonicalisation",
+			fmt.Sprintf("%s variant %s: %s", v.Variant, v.URL, v.Detail),
+		))
+	}
+
+	return result
+}
+
+// ExportRow returns a row for CSV/Excel export.
+func (a *HostCanonicalAnalyzer) ExportRow(result *AnalysisResult) []string {
+	return []string{
+		fmt.Sprintf("%v", result.Data["host"]),
+		fmt.Sprintf("%v", result.Data["variant"]),
+		fmt.Sprintf("%v", result.Data["url"]),
+		fmt.Sprintf("%v", result.Data["status_code"]),
+		fmt.Sprintf("%v", result.Data["redirect_url"]),
+		fmt.Sprintf("%v", result.Data["hops"]),
+		fmt.Sprintf("%v", result.Data["verdict"]),
+		fmt.Sprintf("%v", result.Data["detail"]),
+	}
+}
+
+type hostVariant struct {
+	kind string
+	url  string
+}
+
+// hostVariants returns the duplicate variants of a canonical URL. Variants
+// that need a path, such as the trailing slash, are skipped for the home
+// page.
+func hostVariants(canonical *url.URL) []hostVariant {
+	variants := make([]hostVariant, 0, 6)
+	seen := map[string]bool{canonical.String(): true}
+	add := func(kind string, u *url.URL) {
+		s := u.String()
+		if !seen[s] {
+			seen[s] = true
+			variants = append(variants, hostVariant{kind: kind, url: s})
+		}
+	}
+	variant := func() *url.URL {
+		u := *canonical
+		u.Fragment = ""
+		return &u
+	}
+	path := canonical.Path
+	if path == "" {
+		path = "/"
+	}
+
+	u := variant()
+	if u.Scheme == "https" {
+		u.Scheme = "http"
+	} else {
+		u.Scheme = "https"
+	}
+	add(VariantScheme, u)
+
+	if host := canonical.Hostname(); net.ParseIP(host) == nil && strings.Contains(host, ".") {
+		u = variant()
+		if strings.HasPrefix(host, "www.") {
+			u.Host = strings.TrimPrefix(u.Host, "www.")
+		} else {
+			u.Host = "www." + u.Host
+		}
+		add(VariantWWW, u)
+	}
+
+	if path != "/" {
+		u = variant()
+		if strings.HasSuffix(path, "/") {
+			u.Path = strings.TrimSuffix(path, "/")
+		} else {
+			u.Path = path + "/"
+		}
+		u.RawPath = ""
+		add(VariantTrailingSlash, u)
+	}
+
+	if upper := strings.ToUpper(path); upper != path {
+		u = variant()
+		u.Path = upper
+		u.RawPath = ""
+		add(VariantUppercase, u)
+	}
+
+	if !strings.HasSuffix(path, "/index.html") {
+		u = variant()
+		u.Path = strings.TrimSuffix(path, "/") + "/index.html"
+		u.RawPath = ""
+		u.RawQuery = ""
+		add(VariantIndexFile, u)
+	}
+
+	if canonical.RawQuery == "" {
+		u = variant()
+		u.ForceQuery = true
+		add(VariantEmptyQuery, u)
+	}
+
+	return variants
+}
+
+// resolveLocation resolves a Location header against the request URL.
+func resolveLocation(requestURL, location string) string {
+	base, err := url.Parse(requestURL)
+	if err != nil {
+		return location
+	}
+	ref, err := url.Parse(location)
+	if err != nil {
+		return location
+	}
+	return base.ResolveReference(ref).String()
+}
+
+// sameURL compares two URLs, treating an empty path as "/" and the scheme
+// and host case-insensitively.
+func sameURL(a, b string) bool {
+	ua, errA := url.Parse(a)
+	ub, errB := url.Parse(b)
+	if errA != nil || errB != nil {
+		return a == b
+	}
+	for _, u := range []*url.URL{ua, ub} {
+		u.Scheme = strings.ToLower(u.Scheme)
+		u.Host = strings.ToLower(u.Host)
+		if u.Path == "" {
+			u.Path = "/"
+		}
+	}
+	return ua.String() == ub.String()
+}
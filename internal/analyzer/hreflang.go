@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/spider-crawler/spider/internal/langdetect"
 	"github.com/spider-crawler/spider/internal/storage"
 )
 
@@ -12,6 +13,9 @@ import (
 type HreflangAnalyzer struct {
 	// Map of URL -> hreflang entries for return link validation
 	urlHreflangs map[string][]HreflangEntry
+
+	// Map of URL -> language detected from the page content
+	pageLanguages map[string]string
 }
 
 // HreflangEntry represents a single hreflang entry.
@@ -22,10 +26,17 @@ type HreflangEntry struct {
 
 func NewHreflangAnalyzer() *HreflangAnalyzer {
 	return &HreflangAnalyzer{
-		urlHreflangs: make(map[string][]HreflangEntry),
+		urlHreflangs:  make(map[string][]HreflangEntry),
+		pageLanguages: make(map[string]string),
 	}
 }
 
+// SetPageLanguage records the language detected from a page's content, so
+// that hreflang annotations of and pointing to the page can be checked.
+func (a *HreflangAnalyzer) SetPageLanguage(pageURL, lang string) {
+	a.pageLanguages[pageURL] = lang
+}
+
 func (a *HreflangAnalyzer) Name() string {
 	return "Hreflang"
 }
@@ -37,6 +48,7 @@ func (a *HreflangAnalyzer) Columns() []ColumnDef {
 		{ID: "href", Title: "Href", Width: 250, Sortable: true, DataKey: "href"},
 		{ID: "status", Title: "Status", Width: 100, Sortable: true, DataKey: "status"},
 		{ID: "return_link", Title: "Return Link", Width: 80, Sortable: true, DataKey: "return_link"},
+		{ID: "detected_language", Title: "Detected Language", Width: 80, Sortable: true, DataKey: "detected_language"},
 	}
 }
 
@@ -61,6 +73,10 @@ func (a *HreflangAnalyzer) Filters() []FilterDef {
 			}
 			return false
 		}},
+		{ID: "language_mismatch", Label: "Language Mismatch", Description: "Hreflang language contradicts the detected content language", FilterFunc: func(r *AnalysisResult) bool {
+			mismatch, _ := r.Data["language_mismatch"].(bool)
+			return mismatch
+		}},
 		{ID: "invalid_code", Label: "Invalid Language Code", Description: "Invalid hreflang code", FilterFunc: func(r *AnalysisResult) bool {
 			if invalid, ok := r.Data["invalid_code"].(bool); ok {
 				return invalid
@@ -78,6 +94,8 @@ func (a *HreflangAnalyzer) Analyze(ctx *AnalysisContext) *AnalysisResult {
 	}
 
 	result.Data["url"] = ctx.URL.URL
+	detected := a.pageLanguages[ctx.URL.URL]
+	result.Data["detected_language"] = detected
 
 	if ctx.HTMLFeatures == nil || ctx.HTMLFeatures.Hreflangs == "" {
 		result.Data["hreflang_count"] = 0
@@ -103,11 +121,15 @@ func (a *HreflangAnalyzer) Analyze(ctx *AnalysisContext) *AnalysisResult {
 	hasSelfRef := false
 	hasXDefault := false
 	invalidCodes := make([]string, 0)
+	mismatchedCodes := make([]string, 0)
 
 	for _, entry := range entries {
-		// Check for self-reference
+		// Check for self-reference, and that it names the page's language
 		if entry.Href == ctx.URL.URL {
 			hasSelfRef = true
+			if detected != "" && langdetect.Known(entry.Hreflang) && !langdetect.Matches(entry.Hreflang, detected) {
+				mismatchedCodes = append(mismatchedCodes, entry.Hreflang)
+			}
 		}
 
 		// Check for x-default
@@ -157,6 +179,18 @@ func (a *HreflangAnalyzer) Analyze(ctx *AnalysisContext) *AnalysisResult {
 		result.Data["invalid_code"] = false
 	}
 
+	if len(mismatchedCodes) > 0 {
+		result.Data["language_mismatch"] = true
+		result.Issues = append(result.Issues, NewIssue(
+			ctx.URL.ID,
+			"hreflang_language_mismatch",
+			storage.IssueTypeWarning,
+			storage.SeverityMedium,
+			"hreflang",
+			fmt.Sprintf("Self-referencing hreflang %s but the content is detected as %s", strings.Join(mismatchedCodes, ", "), detected),
+		))
+	}
+
 	result.Data["status"] = "Has Hreflang"
 
 	return result
@@ -202,6 +236,42 @@ func (a *HreflangAnalyzer) AnalyzeReturnLinks() []*storage.Issue {
 	return issues
 }
 
+// AnalyzeLanguages checks after all pages are analyzed that each hreflang
+// annotation points to content in the language it names.
+func (a *HreflangAnalyzer) AnalyzeLanguages() []*storage.Issue {
+	issues := make([]*storage.Issue, 0)
+	seen := make(map[string]bool)
+
+	for pageURL, entries := range a.urlHreflangs {
+		for _, entry := range entries {
+			if entry.Href == pageURL {
+				continue // Checked with the page itself
+			}
+			detected, ok := a.pageLanguages[entry.Href]
+			if !ok || !langdetect.Known(entry.Hreflang) || langdetect.Matches(entry.Hreflang, detected) {
+				continue
+			}
+
+			// Pages of a cluster repeat the same annotations
+			key := entry.Hreflang + " " + entry.Href
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			issues = append(issues, &storage.Issue{
+				IssueCode: "hreflang_language_mismatch",
+				IssueType: storage.IssueTypeWarning,
+				Severity:  storage.SeverityMedium,
+				Category:  "hreflang",
+				Message:   fmt.Sprintf("Hreflang %s points to %s content: %s -> %s", entry.Hreflang, detected, pageURL, entry.Href),
+			})
+		}
+	}
+
+	return issues
+}
+
 // isValidHreflangCode validates hreflang language/region codes.
 func (a *HreflangAnalyzer) isValidHreflangCode(code string) bool {
 	if code == "x-default" {
@@ -229,6 +299,7 @@ func (a *HreflangAnalyzer) isValidHreflangCode(code string) bool {
 
 func (a *HreflangAnalyzer) Reset() {
 	a.urlHreflangs = make(map[string][]HreflangEntry)
+	a.pageLanguages = make(map[string]string)
 }
 
 func (a *HreflangAnalyzer) ExportRow(result *AnalysisResult) []string {
@@ -238,6 +309,7 @@ func (a *HreflangAnalyzer) ExportRow(result *AnalysisResult) []string {
 		fmt.Sprintf("%v", result.Data["href"]),
 		fmt.Sprintf("%v", result.Data["status"]),
 		fmt.Sprintf("%v", result.Data["return_link"]),
+		fmt.Sprintf("%v", result.Data["detected_language"]),
 	}
 }
 
@@ -276,6 +348,15 @@ func (a *HreflangAnalyzer) ExpandHreflangRows(ctx *AnalysisContext) []*AnalysisR
 			result.Data["status"] = "Invalid Code"
 		}
 
+		// Check the language of the target's content
+		if detected, ok := a.pageLanguages[entry.Href]; ok {
+			result.Data["detected_language"] = detected
+			if langdetect.Known(entry.Hreflang) && !langdetect.Matches(entry.Hreflang, detected) {
+				result.Data["language_mismatch"] = true
+				result.Data["status"] = "Language Mismatch"
+			}
+		}
+
 		results = append(results, result)
 	}
 
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/spider-crawler/spider/internal/storage"
+	"github.com/spider-crawler/spider/internal/urlutil"
 )
 
 // JavaScriptAnalyzer analyzes JavaScript resources.
@@ -113,9 +114,9 @@ func (a *JavaScriptAnalyzer) AnalyzeScript(resource *storage.Resource, foundOnUR
 		result.Data["type"] = "application/javascript"
 	}
 
-	// Check if external (different host)
+	// Check if external (different registrable domain)
 	scriptHost, _ := extractHostFromURL(resource.URL)
-	isExternal := scriptHost != "" && scriptHost != pageHost
+	isExternal := scriptHost != "" && !urlutil.IsSameSite(scriptHost, pageHost)
 	result.Data["is_external"] = isExternal
 
 	// Issues
@@ -0,0 +1,159 @@
+package analyzer
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/spider-crawler/spider/internal/renderer"
+	"github.com/spider-crawler/spider/internal/storage"
+)
+
+// LazyLoadAnalyzer reports links and images that only appear after the
+// renderer scrolls the page or clicks "load more". Crawlers that do not
+// interact with the page will not discover them.
+type LazyLoadAnalyzer struct {
+	mu      sync.RWMutex
+	reports map[string]*renderer.ScrollReport
+}
+
+func NewLazyLoadAnalyzer() *LazyLoadAnalyzer {
+	return &LazyLoadAnalyzer{
+		reports: make(map[string]*renderer.ScrollReport),
+	}
+}
+
+func (a *LazyLoadAnalyzer) Name() string {
+	return "Lazy Load"
+}
+
+func (a *LazyLoadAnalyzer) Columns() []ColumnDef {
+	return []ColumnDef{
+		{ID: "url", Title: "Address", Width: 300, Sortable: true, DataKey: "url"},
+		{ID: "initial_links", Title: "Initial Links", Width: 90, Sortable: true, DataKey: "initial_links"},
+		{ID: "interaction_links", Title: "Links After Scroll", Width: 110, Sortable: true, DataKey: "interaction_link_count"},
+		{ID: "initial_images", Title: "Initial Images", Width: 90, Sortable: true, DataKey: "initial_images"},
+		{ID: "interaction_images", Title: "Images After Scroll", Width: 110, Sortable: true, DataKey: "interaction_image_count"},
+		{ID: "steps", Title: "Scroll Steps", Width: 80, Sortable: true, DataKey: "steps"},
+		{ID: "status", Title: "Status", Width: 100, Sortable: true, DataKey: "status"},
+		{ID: "scroll_error", Title: "Scroll Error", Width: 200, Sortable: true, DataKey: "scroll_error"},
+	}
+}
+
+func (a *LazyLoadAnalyzer) Filters() []FilterDef {
+	return []FilterDef{
+		{ID: "all", Label: "All", Description: "All scrolled URLs"},
+		{ID: "links_after_interaction", Label: "Links After Interaction", Description: "Links that appear only after scrolling or clicking", FilterFunc: func(r *AnalysisResult) bool {
+			if count, ok := r.Data["interaction_link_count"].(int); ok {
+				return count > 0
+			}
+			return false
+		}},
+		{ID: "images_after_interaction", Label: "Images After Interaction", Description: "Images that appear only after scrolling or clicking", FilterFunc: func(r *AnalysisResult) bool {
+			if count, ok := r.Data["interaction_image_count"].(int); ok {
+				return count > 0
+			}
+			return false
+		}},
+		{ID: "load_more", Label: "Load More Clicked", Description: "Pages where a load more element was clicked", FilterFunc: func(r *AnalysisResult) bool {
+			if clicks, ok := r.Data["load_more_clicks"].(int); ok {
+				return clicks > 0
+			}
+			return false
+		}},
+	}
+}
+
+// AddScrollReport records the scroll report of a rendered URL.
+func (a *LazyLoadAnalyzer) AddScrollReport(url string, report *renderer.ScrollReport) {
+	if report == nil {
+		return
+	}
+	a.mu.Lock()
+	a.reports[url] = report
+	a.mu.Unlock()
+}
+
+func (a *LazyLoadAnalyzer) Analyze(ctx *AnalysisContext) *AnalysisResult {
+	result := &AnalysisResult{
+		URLID:  ctx.URL.ID,
+		Issues: make([]*storage.Issue, 0),
+		Data:   make(map[string]interface{}),
+	}
+
+	result.Data["url"] = ctx.URL.URL
+
+	a.mu.RLock()
+	report, ok := a.reports[ctx.URL.URL]
+	a.mu.RUnlock()
+	if !ok {
+		result.Data["status"] = "Not Scrolled"
+		return result
+	}
+
+	clicks := 0
+	for _, step := range report.Steps {
+		if step.Clicked {
+			clicks++
+		}
+	}
+
+	result.Data["initial_links"] = report.InitialLinks
+	result.Data["initial_images"] = report.InitialImages
+	result.Data["interaction_link_count"] = len(report.InteractionLinks)
+	result.Data["interaction_links"] = report.InteractionLinks
+	result.Data["interaction_image_count"] = len(report.InteractionImages)
+	result.Data["interaction_images"] = report.InteractionImages
+	result.Data["steps"] = len(report.Steps)
+	result.Data["load_more_clicks"] = clicks
+	if report.Error != nil {
+		result.Data["scroll_error"] = report.Error.Error()
+	}
+
+	if len(report.InteractionLinks) > 0 {
+		result.Issues = append(result.Issues, NewIssue(
+			ctx.URL.ID,
+			storage.IssueLinksAfterInteraction,
+			storage.IssueTypeWarning,
+			storage.SeverityMedium,
+			"javascript",
+			fmt.Sprintf("%d links only appear after scrolling or clicking", len(report.InteractionLinks)),
+		))
+	}
+
+	if len(report.InteractionImages) > 0 {
+		result.Issues = append(result.Issues, NewIssue(
+			ctx.URL.ID,
+			storage.IssueImagesAfterInteraction,
+			storage.IssueTypeNotice,
+			storage.SeverityLow,
+			"javascript",
+			fmt.Sprintf("%d images only appear after scrolling or clicking", len(report.InteractionImages)),
+		))
+	}
+
+	if len(report.InteractionLinks) > 0 || len(report.InteractionImages) > 0 {
+		result.Data["status"] = "Lazy Content"
+	} else {
+		result.Data["status"] = "OK"
+	}
+
+	return result
+}
+
+func (a *LazyLoadAnalyzer) Reset() {
+	a.mu.Lock()
+	a.reports = make(map[string]*renderer.ScrollReport)
+	a.mu.Unlock()
+}
+
+func (a *LazyLoadAnalyzer) ExportRow(result *AnalysisResult) []string {
+	return []string{
+		fmt.Sprintf("%v", result.Data["url"]),
+		fmt.Sprintf("%v", result.Data["initial_links"]),
+		fmt.Sprintf("%v", result.Data["interaction_link_count"]),
+		fmt.Sprintf("%v", result.Data["initial_images"]),
+		fmt.Sprintf("%v", result.Data["interaction_image_count"]),
+		fmt.Sprintf("%v", result.Data["steps"]),
+		fmt.Sprintf("%v", result.Data["status"]),
+	}
+}
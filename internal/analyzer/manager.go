@@ -1,12 +1,16 @@
 package analyzer
 
 import (
+	"context"
 	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"os"
 	"sync"
 
+	"github.com/spider-crawler/spider/internal/config"
+	"github.com/spider-crawler/spider/internal/fetcher"
+	"github.com/spider-crawler/spider/internal/renderer"
 	"github.com/spider-crawler/spider/internal/storage"
 )
 
@@ -33,9 +37,13 @@ type Manager struct {
 	Sitemaps         *SitemapsAnalyzer
 	PageSpeed        *PageSpeedAnalyzer
 	Mobile           *MobileAnalyzer
+	DeviceParity     *DeviceParityAnalyzer
+	LazyLoad         *LazyLoadAnalyzer
 	Accessibility    *AccessibilityAnalyzer
 	CustomSearch     *CustomSearchAnalyzer
 	CustomExtraction *CustomExtractionAnalyzer
+	HostCanonical    *HostCanonicalAnalyzer
+	Soft404          *Soft404Analyzer
 
 	// Results storage
 	Results map[string][]*AnalysisResult // analyzer name -> results
@@ -46,8 +54,11 @@ type Manager struct {
 
 // NewManager creates a new analyzer manager.
 func NewManager() *Manager {
+	responseCodes := NewResponseCodesAnalyzer()
+	soft404 := NewSoft404Analyzer()
+	responseCodes.SetSoft404Detector(soft404)
 	return &Manager{
-		ResponseCodes:    NewResponseCodesAnalyzer(),
+		ResponseCodes:    responseCodes,
 		PageTitles:       NewPageTitlesAnalyzer(),
 		MetaDescription:  NewMetaDescriptionAnalyzer(),
 		H1:               NewH1Analyzer(),
@@ -65,14 +76,32 @@ func NewManager() *Manager {
 		Sitemaps:         NewSitemapsAnalyzer(),
 		PageSpeed:        NewPageSpeedAnalyzer(),
 		Mobile:           NewMobileAnalyzer(),
+		DeviceParity:     NewDeviceParityAnalyzer(),
+		LazyLoad:         NewLazyLoadAnalyzer(),
 		Accessibility:    NewAccessibilityAnalyzer(),
 		CustomSearch:     NewCustomSearchAnalyzer(),
 		CustomExtraction: NewCustomExtractionAnalyzer(),
+		HostCanonical:    NewHostCanonicalAnalyzer(responseCodes),
+		Soft404:          soft404,
 		Results:          make(map[string][]*AnalysisResult),
 		AllIssues:        make([]*storage.Issue, 0),
 	}
 }
 
+// Configure applies the analysis settings of a crawl configuration. Call
+// it before the first AnalyzePage. It fails on invalid content selectors.
+func (m *Manager) Configure(cfg *config.CrawlConfig) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.Content.SetNearDuplicateThreshold(cfg.NearDuplicateThreshold)
+	m.Content.SetReadabilityThresholds(cfg.DifficultReadingEase, cfg.DifficultGradeLevel)
+	if err := m.Content.SetContentSelectors(cfg.ContentAreaSelectors, cfg.ContentExcludeSelectors); err != nil {
+		return fmt.Errorf("invalid content selector: %w", err)
+	}
+	return nil
+}
+
 // AnalyzePage runs all analyzers on a single page.
 func (m *Manager) AnalyzePage(ctx *AnalysisContext) {
 	m.mu.Lock()
@@ -107,6 +136,9 @@ func (m *Manager) AnalyzePage(ctx *AnalysisContext) {
 	contentResult := m.Content.Analyze(ctx)
 	m.Results["content"] = append(m.Results["content"], contentResult)
 	m.AllIssues = append(m.AllIssues, contentResult.Issues...)
+	if reliable, _ := contentResult.Data["language_reliable"].(bool); reliable {
+		m.Hreflang.SetPageLanguage(ctx.URL.URL, contentResult.Data["detected_language"].(string))
+	}
 
 	// Canonicals
 	canResult := m.Canonicals.Analyze(ctx)
@@ -138,6 +170,11 @@ func (m *Manager) AnalyzePage(ctx *AnalysisContext) {
 	m.Results["javascript"] = append(m.Results["javascript"], jsResult)
 	m.AllIssues = append(m.AllIssues, jsResult.Issues...)
 
+	// Lazy Load (rendered with scrolling)
+	lazyResult := m.LazyLoad.Analyze(ctx)
+	m.Results["lazy_load"] = append(m.Results["lazy_load"], lazyResult)
+	m.AllIssues = append(m.AllIssues, lazyResult.Issues...)
+
 	// AMP
 	ampResult := m.AMP.Analyze(ctx)
 	m.Results["amp"] = append(m.Results["amp"], ampResult)
@@ -153,6 +190,11 @@ func (m *Manager) AnalyzePage(ctx *AnalysisContext) {
 	m.Results["mobile"] = append(m.Results["mobile"], mobileResult)
 	m.AllIssues = append(m.AllIssues, mobileResult.Issues...)
 
+	// Device Parity (dual-device crawls)
+	parityResult := m.DeviceParity.Analyze(ctx)
+	m.Results["device_parity"] = append(m.Results["device_parity"], parityResult)
+	m.AllIssues = append(m.AllIssues, parityResult.Issues...)
+
 	// Accessibility
 	a11yResult := m.Accessibility.Analyze(ctx)
 	m.Results["accessibility"] = append(m.Results["accessibility"], a11yResult)
@@ -194,6 +236,49 @@ func (m *Manager) AnalyzeLink(link *storage.Link, fromURL string, targetStatus i
 	m.AllIssues = append(m.AllIssues, result.Issues...)
 }
 
+// AddRenderResult records the measurements taken while rendering a page
+// for the analyzers that report them. Call it before AnalyzePage for the
+// same URL: lab Web Vitals then replace the untested PageSpeed row.
+func (m *Manager) AddRenderResult(pageURL string, result *renderer.RenderResult) {
+	if result == nil || result.Error != nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if result.Metrics != nil {
+		m.PageSpeed.AddLabResult(pageURL, result.Metrics)
+	}
+	if result.Scroll != nil {
+		m.LazyLoad.AddScrollReport(pageURL, result.Scroll)
+	}
+}
+
+// ProbeHost probes the duplicate variants of a seed URL. The variants are
+// returned for storage; their results and issues are collected under
+// "host_canonicalisation".
+func (m *Manager) ProbeHost(ctx context.Context, f *fetcher.Fetcher, seedURL string) ([]*storage.HostVariant, error) {
+	variants, err := m.HostCanonical.Probe(ctx, f, seedURL)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, v := range variants {
+		result := m.HostCanonical.AnalyzeVariant(v)
+		m.Results["host_canonicalisation"] = append(m.Results["host_canonicalisation"], result)
+		m.AllIssues = append(m.AllIssues, result.Issues...)
+	}
+	return variants, err
+}
+
+// LearnErrorPages records the error page fingerprint of a host, used to
+// flag soft 404s among the pages analyzed afterwards.
+func (m *Manager) LearnErrorPages(ctx context.Context, f *fetcher.Fetcher, siteURL string) error {
+	_, err := m.Soft404.ProbeHost(ctx, f, siteURL)
+	return err
+}
+
 // FinalizeDuplicateAnalysis runs duplicate detection after all pages are analyzed.
 func (m *Manager) FinalizeDuplicateAnalysis() {
 	m.mu.Lock()
@@ -211,11 +296,15 @@ func (m *Manager) FinalizeDuplicateAnalysis() {
 	// Content duplicates
 	m.AllIssues = append(m.AllIssues, m.Content.AnalyzeDuplicates()...)
 
+	// Near duplicate content
+	m.AllIssues = append(m.AllIssues, m.Content.AnalyzeNearDuplicates()...)
+
 	// URL duplicates
 	m.AllIssues = append(m.AllIssues, m.URLHealth.AnalyzeDuplicates()...)
 
 	// Hreflang return links
 	m.AllIssues = append(m.AllIssues, m.Hreflang.AnalyzeReturnLinks()...)
+	m.AllIssues = append(m.AllIssues, m.Hreflang.AnalyzeLanguages()...)
 }
 
 // GetResults returns results for a specific analyzer.
@@ -253,6 +342,13 @@ func (m *Manager) GetFilteredResults(analyzerName string, filterID string) []*An
 				break
 			}
 		}
+	case "host_canonicalisation":
+		for _, f := range m.HostCanonical.Filters() {
+			if f.ID == filterID && f.FilterFunc != nil {
+				filterFunc = f.FilterFunc
+				break
+			}
+		}
 	// Add other analyzers...
 	}
 
@@ -316,6 +412,9 @@ func (m *Manager) Reset() {
 	m.Hreflang.Reset()
 	m.URLHealth.Reset()
 	m.Sitemaps.Reset()
+	m.DeviceParity.Reset()
+	m.LazyLoad.Reset()
+	m.Soft404.Reset()
 	m.CustomSearch.ClearRules()
 	m.CustomExtraction.ClearRules()
 }
@@ -419,6 +518,16 @@ func (m *Manager) ExportCSV(analyzerName, filePath string) error {
 			headers = append(headers, col.Title)
 		}
 		exportFunc = m.Mobile.ExportRow
+	case "lazy_load":
+		for _, col := range m.LazyLoad.Columns() {
+			headers = append(headers, col.Title)
+		}
+		exportFunc = m.LazyLoad.ExportRow
+	case "device_parity":
+		for _, col := range m.DeviceParity.Columns() {
+			headers = append(headers, col.Title)
+		}
+		exportFunc = m.DeviceParity.ExportRow
 	case "accessibility":
 		for _, col := range m.Accessibility.Columns() {
 			headers = append(headers, col.Title)
@@ -434,6 +543,11 @@ func (m *Manager) ExportCSV(analyzerName, filePath string) error {
 			headers = append(headers, col.Title)
 		}
 		exportFunc = m.CustomExtraction.ExportRow
+	case "host_canonicalisation":
+		for _, col := range m.HostCanonical.Columns() {
+			headers = append(headers, col.Title)
+		}
+		exportFunc = m.HostCanonical.ExportRow
 	default:
 		return fmt.Errorf("unknown analyzer: %s", analyzerName)
 	}
@@ -447,6 +561,44 @@ func (m *Manager) ExportCSV(analyzerName, filePath string) error {
 	return nil
 }
 
+// ExportNearDuplicateClustersCSV exports the near-duplicate clusters found
+// by FinalizeDuplicateAnalysis, one row per page.
+func (m *Manager) ExportNearDuplicateClustersCSV(filePath string) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	urls := make(map[int64]string)
+	for _, r := range m.Results["content"] {
+		urls[r.URLID], _ = r.Data["url"].(string)
+	}
+
+	file, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+
+	writer.Write([]string{"Scope", "Cluster", "Cluster Size", "URL", "Closest Match", "Similarity"})
+	for _, match := range m.Content.NearDuplicates() {
+		if match.ClusterID == 0 {
+			continue
+		}
+		writer.Write([]string{
+			match.Scope,
+			fmt.Sprintf("%d", match.ClusterID),
+			fmt.Sprintf("%d", match.ClusterSize),
+			urls[match.URLID],
+			urls[match.ClosestURLID],
+			fmt.Sprintf("%.2f", match.Similarity),
+		})
+	}
+
+	return nil
+}
+
 // ExportJSON exports results to JSON.
 func (m *Manager) ExportJSON(analyzerName, filePath string) error {
 	m.mu.RLock()
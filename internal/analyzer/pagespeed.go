@@ -3,14 +3,22 @@ package analyzer
 import (
 	"encoding/json"
 	"fmt"
+	"math"
 	"net/http"
 	"net/url"
 	"sync"
 	"time"
 
+	"github.com/spider-crawler/spider/internal/renderer"
 	"github.com/spider-crawler/spider/internal/storage"
 )
 
+// PageSpeed result sources.
+const (
+	SourcePSI = "psi" // PageSpeed Insights API
+	SourceLab = "lab" // Measured locally by the renderer
+)
+
 // PageSpeedAnalyzer integrates with Google PageSpeed Insights API.
 type PageSpeedAnalyzer struct {
 	apiKey      string
@@ -34,6 +42,9 @@ type PageSpeedResult struct {
 	TTFB          float64 // Time to First Byte (ms)
 	TBT           float64 // Total Blocking Time (ms)
 	SpeedIndex    float64
+	Source        string // "psi" or "lab"
+	LCPElement    string   // lab only: CSS selector of the LCP element
+	ShiftElements []string // lab only: elements moved by layout shifts
 	Error         string
 }
 
@@ -61,6 +72,8 @@ func (a *PageSpeedAnalyzer) Columns() []ColumnDef {
 		{ID: "cls", Title: "CLS", Width: 70, Sortable: true, DataKey: "cls"},
 		{ID: "fcp", Title: "FCP", Width: 70, Sortable: true, DataKey: "fcp"},
 		{ID: "ttfb", Title: "TTFB", Width: 70, Sortable: true, DataKey: "ttfb"},
+		{ID: "source", Title: "Source", Width: 70, Sortable: true, DataKey: "source"},
+		{ID: "lcp_element", Title: "LCP Element", Width: 200, Sortable: true, DataKey: "lcp_element"},
 		{ID: "status", Title: "Status", Width: 80, Sortable: true, DataKey: "status"},
 	}
 }
@@ -98,6 +111,9 @@ func (a *PageSpeedAnalyzer) Filters() []FilterDef {
 			}
 			return false
 		}},
+		{ID: "lab", Label: "Local Lab", Description: "Measured locally by the renderer", FilterFunc: func(r *AnalysisResult) bool {
+			return r.Data["source"] == SourceLab
+		}},
 	}
 }
 
@@ -108,12 +124,88 @@ func (a *PageSpeedAnalyzer) Analyze(ctx *AnalysisContext) *AnalysisResult {
 		Data:   make(map[string]interface{}),
 	}
 
+	// Prefer locally measured lab data when the page was rendered
+	a.cacheMu.RLock()
+	lab, ok := a.cache[SourceLab+":"+ctx.URL.URL]
+	a.cacheMu.RUnlock()
+	if ok {
+		return a.AnalyzePageSpeedResult(lab, ctx.URL.ID)
+	}
+
 	result.Data["url"] = ctx.URL.URL
 	result.Data["status"] = "Not Tested"
 
 	return result
 }
 
+// AddLabResult records Web Vitals measured by the renderer for a URL.
+// Lab runs have no user interaction, so INP is left unmeasured.
+func (a *PageSpeedAnalyzer) AddLabResult(targetURL string, m *renderer.PerformanceMetrics) *PageSpeedResult {
+	result := &PageSpeedResult{
+		URL:           targetURL,
+		FetchedAt:     time.Now(),
+		Strategy:      SourceLab,
+		Source:        SourceLab,
+		LCP:           m.LCP,
+		CLS:           m.CLS,
+		FCP:           m.FirstContentfulPaint,
+		TTFB:          m.TTFB,
+		TBT:           m.TBT,
+		LCPElement:    m.LCPElement,
+		ShiftElements: m.LayoutShiftElements,
+	}
+	result.Score = labScore(result)
+
+	a.cacheMu.Lock()
+	a.cache[SourceLab+":"+targetURL] = result
+	a.cacheMu.Unlock()
+
+	return result
+}
+
+// labCurve is a Lighthouse log-normal scoring curve.
+type labCurve struct {
+	p10, median, weight float64
+}
+
+// labScore approximates the Lighthouse performance score from lab metrics
+// using the mobile scoring curves. Speed Index is not measured locally and
+// paint timings are missing when the page never painted, so only the measured
+// metrics are weighted and their weights renormalised. TBT and CLS are
+// legitimately zero on a quiet, stable page.
+func labScore(r *PageSpeedResult) int {
+	metrics := []struct {
+		value    float64
+		measured bool
+		curve    labCurve
+	}{
+		{r.FCP, r.FCP > 0, labCurve{1800, 3000, 0.10}},
+		{r.LCP, r.LCP > 0, labCurve{2500, 4000, 0.25}},
+		{r.TBT, true, labCurve{200, 600, 0.30}},
+		{r.CLS, true, labCurve{0.1, 0.25, 0.25}},
+	}
+
+	var total, weights float64
+	for _, m := range metrics {
+		if !m.measured {
+			continue
+		}
+		total += m.curve.weight * logNormalScore(m.value, m.curve)
+		weights += m.curve.weight
+	}
+	return int(math.Round(total / weights * 100))
+}
+
+// logNormalScore maps a metric value to 0..1, scoring 0.9 at p10 and 0.5 at
+// the median. A zero value is a perfect score.
+func logNormalScore(value float64, c labCurve) float64 {
+	if value <= 0 {
+		return 1
+	}
+	sigma := (math.Log(c.median) - math.Log(c.p10)) / 1.2815515655446004
+	return 0.5 * math.Erfc((math.Log(value)-math.Log(c.median))/(sigma*math.Sqrt2))
+}
+
 // FetchPageSpeed fetches PageSpeed data for a URL.
 func (a *PageSpeedAnalyzer) FetchPageSpeed(targetURL string, strategy string) *PageSpeedResult {
 	// Check cache first
@@ -131,6 +223,7 @@ func (a *PageSpeedAnalyzer) FetchPageSpeed(targetURL string, strategy string) *P
 	result := &PageSpeedResult{
 		URL:       targetURL,
 		Strategy:  strategy,
+		Source:    SourcePSI,
 		FetchedAt: time.Now(),
 	}
 
@@ -219,6 +312,7 @@ func (a *PageSpeedAnalyzer) AnalyzePageSpeedResult(psi *PageSpeedResult, urlID i
 
 	result.Data["url"] = psi.URL
 	result.Data["strategy"] = psi.Strategy
+	result.Data["source"] = psi.Source
 
 	if psi.Error != "" {
 		result.Data["status"] = "Error"
@@ -233,11 +327,17 @@ func (a *PageSpeedAnalyzer) AnalyzePageSpeedResult(psi *PageSpeedResult, urlID i
 	result.Data["fcp_ms"] = psi.FCP
 	result.Data["fcp"] = fmt.Sprintf("%.1fs", psi.FCP/1000)
 	result.Data["cls"] = psi.CLS
-	result.Data["inp_ms"] = psi.INP
-	result.Data["inp"] = fmt.Sprintf("%.0fms", psi.INP)
+	result.Data["inp"] = ""
+	if psi.INP > 0 {
+		result.Data["inp_ms"] = psi.INP
+		result.Data["inp"] = fmt.Sprintf("%.0fms", psi.INP)
+	}
 	result.Data["ttfb_ms"] = psi.TTFB
 	result.Data["ttfb"] = fmt.Sprintf("%.0fms", psi.TTFB)
 	result.Data["tbt_ms"] = psi.TBT
+	result.Data["tbt"] = fmt.Sprintf("%.0fms", psi.TBT)
+	result.Data["lcp_element"] = psi.LCPElement
+	result.Data["shift_elements"] = psi.ShiftElements
 
 	// Generate issues based on thresholds
 	// LCP: Good < 2.5s, Needs Improvement < 4s, Poor >= 4s
@@ -306,6 +406,8 @@ func (a *PageSpeedAnalyzer) ExportRow(result *AnalysisResult) []string {
 		fmt.Sprintf("%v", result.Data["cls"]),
 		fmt.Sprintf("%v", result.Data["fcp"]),
 		fmt.Sprintf("%v", result.Data["ttfb"]),
+		fmt.Sprintf("%v", result.Data["source"]),
+		fmt.Sprintf("%v", result.Data["lcp_element"]),
 		fmt.Sprintf("%v", result.Data["status"]),
 	}
 }
@@ -0,0 +1,52 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/spider-crawler/spider/internal/renderer"
+)
+
+func TestLabScore(t *testing.T) {
+	tests := []struct {
+		name    string
+		metrics renderer.PerformanceMetrics
+		min     int
+		max     int
+	}{
+		{"fast page", renderer.PerformanceMetrics{FirstContentfulPaint: 900, LCP: 1200, TBT: 0, CLS: 0}, 95, 100},
+		{"slow page", renderer.PerformanceMetrics{FirstContentfulPaint: 6000, LCP: 9000, TBT: 2000, CLS: 0.5}, 0, 10},
+		{"slow page without paint timings", renderer.PerformanceMetrics{TBT: 2000, CLS: 0.5}, 0, 15},
+		{"missing LCP is not a perfect LCP", renderer.PerformanceMetrics{FirstContentfulPaint: 6000, TBT: 2000, CLS: 0.5}, 0, 15},
+	}
+
+	a := NewPageSpeedAnalyzer("")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := a.AddLabResult("https://example.com/", &tt.metrics)
+			if r.Score < tt.min || r.Score > tt.max {
+				t.Errorf("score = %d, want %d..%d", r.Score, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestLabResultLeavesINPUnmeasured(t *testing.T) {
+	a := NewPageSpeedAnalyzer("")
+	r := a.AddLabResult("https://example.com/", &renderer.PerformanceMetrics{LCP: 1200, TBT: 800})
+	if r.INP != 0 {
+		t.Fatalf("INP = %v, want 0 for a lab run", r.INP)
+	}
+
+	result := a.AnalyzePageSpeedResult(r, 1)
+	if result.Data["inp"] != "" {
+		t.Errorf("inp = %q, want empty", result.Data["inp"])
+	}
+	if result.Data["tbt"] != "800ms" {
+		t.Errorf("tbt = %q, want 800ms", result.Data["tbt"])
+	}
+	for _, issue := range result.Issues {
+		if issue.IssueCode == "pagespeed_poor_inp" {
+			t.Errorf("unexpected INP issue: %s", issue.Message)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package analyzer
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/spider-crawler/spider/internal/langdetect"
+)
+
+// Readability holds the readability scores and text statistics of a text.
+type Readability struct {
+	Language     string // Language the scores were computed for
+	Detected     bool   // Language was detected rather than declared
+	Sentences    int
+	Words        int
+	Syllables    int     // Counted only with a reading ease formula
+	AvgSentence  float64 // Words per sentence
+	AvgSyllables float64 // Syllables per word
+	ReadingEase  float64 // Flesch Reading Ease, adapted to the language (see HasReadingEase)
+	GradeLevel   float64 // Flesch-Kincaid grade level (English only)
+	Atesman      float64 // Ateşman readability (Turkish only)
+	Difficulty   string
+}
+
+// readabilityFormula computes the reading ease from the average sentence
+// length (words) and word length (syllables).
+type readabilityFormula func(asl, asw float64) float64
+
+// Reading ease formulas by language. Languages without an adaptation get
+// no reading ease: the English formula would score them on a scale that
+// does not apply.
+var readabilityFormulas = map[string]readabilityFormula{
+	// Flesch (1948)
+	"en": func(asl, asw float64) float64 { return 206.835 - 1.015*asl - 84.6*asw },
+	// Amstad (1978)
+	"de": func(asl, asw float64) float64 { return 180 - asl - 58.5*asw },
+	// Fernández Huerta (1959)
+	"es": func(asl, asw float64) float64 { return 206.84 - 0.60*(asw*100) - 1.02*(100/asl) },
+	// Kandel & Moles (1958)
+	"fr": func(asl, asw float64) float64 { return 207 - 1.015*asl - 73.6*asw },
+	// Flesch-Vacca (1972)
+	"it": func(asl, asw float64) float64 { return 206 - asl - 65*asw },
+	// Douma (1960)
+	"nl": func(asl, asw float64) float64 { return 206.835 - 0.93*asl - 77*asw },
+	// Ateşman (1997)
+	"tr": atesman,
+}
+
+// Vowels of each language, for counting syllables as vowel groups.
+var languageVowels = map[string]string{
+	"en": "aeiouy",
+	"de": "aeiouyäöü",
+	"es": "aeiouáéíóúü",
+	"fr": "aeiouyàâéèêëîïôûùüÿœæ",
+	"it": "aeiouàèéìíòóùú",
+	"nl": "aeiouyëïéèáóú",
+	"tr": "aeıioöuüâîû",
+}
+
+var (
+	sentenceEnd     = regexp.MustCompile(`[.!?…。！？]+(?:["'”’)\]]*)(?:\s|$)`)
+	englishSilentE  = regexp.MustCompile(`(?:[^laeiouy]es|[^laeiouytd]ed|[^laeiouy]e)$`)
+	englishLeadingY = regexp.MustCompile(`^y`)
+)
+
+// Reading ease bands, from the Flesch scale.
+var readingEaseBands = []struct {
+	min   float64
+	label string
+}{
+	{90, "Very Easy"},
+	{80, "Easy"},
+	{70, "Fairly Easy"},
+	{60, "Standard"},
+	{50, "Fairly Difficult"},
+	{30, "Difficult"},
+}
+
+// Word counts below which scores and detected languages are shown but too
+// unreliable to raise issues.
+const (
+	readabilityMinWords = 100
+	languageMinWords    = 30
+)
+
+// languageMinConfidence is the detection confidence needed to flag a
+// language mismatch.
+const languageMinConfidence = 0.5
+
+// AnalyzeReadability computes the readability of a text. The language is
+// the declared one, such as the page's lang attribute, or detected from the
+// text when missing. It returns nil for a text without words, and only the
+// sentence and word counts for a language without a reading ease formula.
+func AnalyzeReadability(text, declaredLang string) *Readability {
+	words := readabilityWords(text)
+	if len(words) == 0 {
+		return nil
+	}
+
+	r := &Readability{Language: langdetect.Base(declaredLang)}
+	if r.Language == "" {
+		r.Language = langdetect.Detect(text).Language
+		r.Detected = r.Language != ""
+	}
+	r.Words = len(words)
+	r.Sentences = countSentences(text)
+	r.AvgSentence = float64(r.Words) / float64(r.Sentences)
+
+	formula, ok := readabilityFormulas[r.Language]
+	if !ok {
+		return r
+	}
+	for _, w := range words {
+		r.Syllables += countSyllables(w, r.Language)
+	}
+	r.AvgSyllables = float64(r.Syllables) / float64(r.Words)
+
+	r.ReadingEase = formula(r.AvgSentence, r.AvgSyllables)
+	if r.HasGradeLevel() {
+		r.GradeLevel = 0.39*r.AvgSentence + 11.8*r.AvgSyllables - 15.59
+	}
+	if r.Language == "tr" {
+		r.Atesman = r.ReadingEase
+	}
+	r.Difficulty = readingEaseLabel(r.ReadingEase)
+	return r
+}
+
+// HasReadingEase reports whether the reading ease was computed, which
+// needs a formula adapted to the language.
+func (r *Readability) HasReadingEase() bool {
+	_, ok := readabilityFormulas[r.Language]
+	return ok
+}
+
+// HasGradeLevel reports whether the grade level was computed. The
+// Flesch-Kincaid formula is calibrated on English text and US school
+// grades; other languages have no comparable scale.
+func (r *Readability) HasGradeLevel() bool {
+	return r.Language == "en"
+}
+
+// atesman is the Ateşman (1997) reading ease for Turkish, on the same 0-100
+// scale as Flesch.
+func atesman(asl, asw float64) float64 {
+	return 198.825 - 40.175*asw - 2.610*asl
+}
+
+func readingEaseLabel(score float64) string {
+	for _, band := range readingEaseBands {
+		if score >= band.min {
+			return band.label
+		}
+	}
+	return "Very Difficult"
+}
+
+// readabilityWords splits a text into words, leaving out numbers.
+func readabilityWords(text string) []string {
+	fields := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r) && r != '\'' && r != '’'
+	})
+	words := make([]string, 0, len(fields))
+	for _, f := range fields {
+		if strings.IndexFunc(f, unicode.IsLetter) >= 0 {
+			words = append(words, strings.Trim(f, "'’"))
+		}
+	}
+	return words
+}
+
+// countSentences counts sentence endings, treating unterminated trailing
+// text as a sentence.
+func countSentences(text string) int {
+	text = strings.TrimSpace(text)
+	ends := sentenceEnd.FindAllStringIndex(text, -1)
+	n := len(ends)
+	if n == 0 || ends[n-1][1] < len(text) {
+		n++
+	}
+	return n
+}
+
+// countSyllables estimates the syllables of a word as its vowel groups.
+// English drops silent endings; Turkish has no diphthongs, so every vowel
+// is a syllable.
+func countSyllables(word, lang string) int {
+	word = strings.ToLower(word)
+	vowels, ok := languageVowels[lang]
+	if !ok {
+		vowels = languageVowels["en"]
+	}
+
+	switch lang {
+	case "tr":
+		n := 0
+		for _, r := range word {
+			if strings.ContainsRune(vowels, r) {
+				n++
+			}
+		}
+		if n == 0 {
+			return 1
+		}
+		return n
+	case "en", "":
+		if len(word) <= 3 {
+			return 1
+		}
+		word = englishSilentE.ReplaceAllStringFunc(word, func(s string) string { return string([]rune(s)[:1]) })
+		word = englishLeadingY.ReplaceAllString(word, "")
+	case "fr":
+		if len([]rune(word)) > 3 {
+			word = strings.TrimSuffix(strings.TrimSuffix(word, "s"), "e")
+		}
+	}
+
+	n := 0
+	inVowel := false
+	for _, r := range word {
+		isVowel := strings.ContainsRune(vowels, r)
+		if isVowel && !inVowel {
+			n++
+		}
+		inVowel = isVowel
+	}
+	if n == 0 {
+		return 1
+	}
+	return n
+}
@@ -0,0 +1,39 @@
+package analyzer
+
+import "testing"
+
+func TestAnalyzeReadabilityLanguages(t *testing.T) {
+	tests := []struct {
+		lang      string
+		text      string
+		wantEase  bool
+		wantGrade bool
+	}{
+		{"en", "The cat sat on the mat. It was a sunny day.", true, true},
+		{"de", "Die Katze sitzt auf der Matte. Es war ein sonniger Tag.", true, false},
+		{"tr", "Kedi paspasın üstünde oturdu. Güneşli bir gündü.", true, false},
+		{"ru", "Кошка сидела на коврике. Был солнечный день.", false, false},
+		{"pl", "Kot siedział na macie. To był słoneczny dzień.", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.lang, func(t *testing.T) {
+			r := AnalyzeReadability(tt.text, tt.lang)
+			if r == nil {
+				t.Fatal("AnalyzeReadability returned nil")
+			}
+			if r.Sentences != 2 || r.Words == 0 {
+				t.Errorf("sentences = %d, words = %d, want 2 sentences", r.Sentences, r.Words)
+			}
+			if r.HasReadingEase() != tt.wantEase {
+				t.Errorf("HasReadingEase = %v, want %v", r.HasReadingEase(), tt.wantEase)
+			}
+			if !tt.wantEase && (r.ReadingEase != 0 || r.Syllables != 0 || r.Difficulty != "") {
+				t.Errorf("scored without a formula: ease %v, syllables %d, %q", r.ReadingEase, r.Syllables, r.Difficulty)
+			}
+			if r.HasGradeLevel() != tt.wantGrade {
+				t.Errorf("HasGradeLevel = %v, want %v", r.HasGradeLevel(), tt.wantGrade)
+			}
+		})
+	}
+}
@@ -3,17 +3,25 @@ package analyzer
 import (
 	"encoding/json"
 	"fmt"
+	"math"
 
 	"github.com/spider-crawler/spider/internal/storage"
 )
 
 // ResponseCodesAnalyzer analyzes HTTP response codes.
-type ResponseCodesAnalyzer struct{}
+type ResponseCodesAnalyzer struct {
+	soft404 *Soft404Analyzer
+}
 
 func NewResponseCodesAnalyzer() *ResponseCodesAnalyzer {
 	return &ResponseCodesAnalyzer{}
 }
 
+// SetSoft404Detector enables flagging 2xx pages that look like error pages.
+func (a *ResponseCodesAnalyzer) SetSoft404Detector(d *Soft404Analyzer) {
+	a.soft404 = d
+}
+
 func (a *ResponseCodesAnalyzer) Name() string {
 	return "Response Codes"
 }
@@ -27,6 +35,7 @@ func (a *ResponseCodesAnalyzer) Columns() []ColumnDef {
 		{ID: "redirect_type", Title: "Redirect Type", Width: 100, Sortable: true, DataKey: "redirect_type"},
 		{ID: "chain_length", Title: "Chain Length", Width: 90, Sortable: true, DataKey: "chain_length"},
 		{ID: "response_time", Title: "Response Time", Width: 100, Sortable: true, DataKey: "response_time"},
+		{ID: "soft_404", Title: "Soft 404 Confidence", Width: 110, Sortable: true, DataKey: "soft_404_confidence"},
 	}
 }
 
@@ -39,6 +48,9 @@ func (a *ResponseCodesAnalyzer) Filters() []FilterDef {
 			}
 			return false
 		}},
+		{ID: "soft_404", Label: "Soft 404", Description: "2xx pages that look like error pages", FilterFunc: func(r *AnalysisResult) bool {
+			return r.Data["status_category"] == "soft_404"
+		}},
 		{ID: "redirect", Label: "Redirection (3xx)", Description: "Redirect responses", FilterFunc: func(r *AnalysisResult) bool {
 			if code, ok := r.Data["status_code"].(int); ok {
 				return code >= 300 && code < 400
@@ -103,6 +115,15 @@ func (a *ResponseCodesAnalyzer) Analyze(ctx *AnalysisContext) *AnalysisResult {
 	case statusCode >= 200 && statusCode < 300:
 		result.Data["status_category"] = "success"
 
+		if a.soft404 != nil {
+			if soft := a.soft404.Detect(ctx); soft != nil {
+				result.Data["status_category"] = "soft_404"
+				result.Data["soft_404_confidence"] = math.Round(soft.Confidence*100) / 100
+				result.Data["soft_404_signals"] = soft.Signals
+				result.Issues = append(result.Issues, soft.Issue(ctx.URL.ID))
+			}
+		}
+
 	case statusCode >= 300 && statusCode < 400:
 		result.Data["status_category"] = "redirect"
 		result.Data["redirect_type"] = a.getRedirectType(statusCode)
@@ -229,5 +250,6 @@ func (a *ResponseCodesAnalyzer) ExportRow(result *AnalysisResult) []string {
 		fmt.Sprintf("%v", result.Data["redirect_type"]),
 		fmt.Sprintf("%v", result.Data["chain_length"]),
 		fmt.Sprintf("%v", result.Data["response_time"]),
+		fmt.Sprintf("%v", result.Data["soft_404_confidence"]),
 	}
 }
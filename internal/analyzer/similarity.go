@@ -0,0 +1,217 @@
+package analyzer
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"math"
+	"math/bits"
+	"strings"
+	"unicode"
+
+	"github.com/spider-crawler/spider/internal/parser"
+)
+
+// shingleSize is the number of words hashed together as one feature.
+const shingleSize = 3
+
+// minHashSize is the number of permutations in a MinHash signature.
+const minHashSize = 128
+
+// minHashSeeds are the per-permutation seeds, fixed so that signatures are
+// comparable across runs.
+var minHashSeeds = func() [minHashSize]uint64 {
+	var seeds [minHashSize]uint64
+	state := uint64(0x5350494445520001)
+	for i := range seeds {
+		state += 0x9e3779b97f4a7c15
+		seeds[i] = mix64(state)
+	}
+	return seeds
+}()
+
+// Shingles returns the hashes of the overlapping word shingles of a text.
+// Words are lowercased and split on anything but letters and digits, then
+// segmented for scripts written without spaces (see parser.Words).
+func Shingles(text string) []uint64 {
+	words := parser.Words(strings.Map(func(r rune) rune {
+		if !unicode.IsLetter(r) && !unicode.IsNumber(r) && !unicode.IsMark(r) {
+			return ' '
+		}
+		return r
+	}, strings.ToLower(text)))
+	if len(words) == 0 {
+		return nil
+	}
+	if len(words) < shingleSize {
+		return []uint64{hashString(strings.Join(words, " "))}
+	}
+
+	seen := make(map[uint64]struct{}, len(words))
+	shingles := make([]uint64, 0, len(words))
+	for i := 0; i+shingleSize <= len(words); i++ {
+		h := hashString(strings.Join(words[i:i+shingleSize], " "))
+		if _, ok := seen[h]; !ok {
+			seen[h] = struct{}{}
+			shingles = append(shingles, h)
+		}
+	}
+	return shingles
+}
+
+// SimHash returns the 64-bit SimHash of a text's shingles. Similar texts
+// have hashes with a small Hamming distance.
+func SimHash(text string) uint64 {
+	shingles := Shingles(text)
+	if len(shingles) == 0 {
+		return 0
+	}
+
+	var weights [64]int
+	for _, sum := range shingles {
+		for i := 0; i < 64; i++ {
+			if sum&(1<<uint(i)) != 0 {
+				weights[i]++
+			} else {
+				weights[i]--
+			}
+		}
+	}
+
+	var hash uint64
+	for i, w := range weights {
+		if w > 0 {
+			hash |= 1 << uint(i)
+		}
+	}
+	return hash
+}
+
+// HammingDistance returns the number of differing bits of two hashes.
+func HammingDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// MinHash is a MinHash signature of a text's shingles.
+type MinHash []uint64
+
+// NewMinHash computes the signature of a text. It returns nil for a text
+// without words.
+func NewMinHash(text string) MinHash {
+	shingles := Shingles(text)
+	if len(shingles) == 0 {
+		return nil
+	}
+
+	sig := make(MinHash, minHashSize)
+	for i := range sig {
+		sig[i] = ^uint64(0)
+	}
+	for _, s := range shingles {
+		for i, seed := range minHashSeeds {
+			if h := mix64(s ^ seed); h < sig[i] {
+				sig[i] = h
+			}
+		}
+	}
+	return sig
+}
+
+// Similarity estimates the Jaccard similarity of the shingle sets of two
+// signatures.
+func (m MinHash) Similarity(other MinHash) float64 {
+	if len(m) == 0 || len(m) != len(other) {
+		return 0
+	}
+	same := 0
+	for i := range m {
+		if m[i] == other[i] {
+			same++
+		}
+	}
+	return float64(same) / float64(len(m))
+}
+
+// lshBands splits the signature into bands of rows for LSH. Pages sharing
+// all rows of any band become candidates, which happens half the time at
+// a similarity of about (1/bands)^(1/rows); the split whose curve is
+// closest to the threshold keeps both missed pairs and needless
+// comparisons low. For 0.7 that is 16 bands of 8 rows, for 0.9 8 of 16.
+func lshBands(threshold float64) (bands, rows int) {
+	best := math.Inf(1)
+	for r := 1; r <= minHashSize; r *= 2 {
+		b := minHashSize / r
+		if d := math.Abs(math.Pow(1/float64(b), 1/float64(r)) - threshold); d < best {
+			best, bands, rows = d, b, r
+		}
+	}
+	return bands, rows
+}
+
+// bandKeys returns the LSH bucket key of each band of the signature.
+func (m MinHash) bandKeys(bands, rows int) []uint64 {
+	keys := make([]uint64, 0, bands)
+	buf := make([]byte, 8)
+	for b := 0; b < bands; b++ {
+		h := fnv.New64a()
+		for _, v := range m[b*rows : (b+1)*rows] {
+			binary.LittleEndian.PutUint64(buf, v)
+			h.Write(buf)
+		}
+		keys = append(keys, h.Sum64())
+	}
+	return keys
+}
+
+// candidatePairs calls fn once for each pair of signatures that share a
+// band at the given threshold, with i < j. A pair is only compared in the
+// first band it shares, so no set of compared pairs is needed.
+func candidatePairs(signatures []MinHash, threshold float64, fn func(i, j int)) {
+	bands, rows := lshBands(threshold)
+	keys := make([][]uint64, len(signatures))
+	for i, sig := range signatures {
+		keys[i] = sig.bandKeys(bands, rows)
+	}
+
+	for b := 0; b < bands; b++ {
+		buckets := make(map[uint64][]int)
+		for i := range signatures {
+			buckets[keys[i][b]] = append(buckets[keys[i][b]], i)
+		}
+		for _, bucket := range buckets {
+			for x := 0; x < len(bucket); x++ {
+				for y := x + 1; y < len(bucket); y++ {
+					i, j := bucket[x], bucket[y]
+					if !sharedBefore(keys[i], keys[j], b) {
+						fn(i, j)
+					}
+				}
+			}
+		}
+	}
+}
+
+// sharedBefore reports whether two key lists match in a band before b.
+func sharedBefore(a, b []uint64, band int) bool {
+	for k := 0; k < band; k++ {
+		if a[k] == b[k] {
+			return true
+		}
+	}
+	return false
+}
+
+func hashString(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	return h.Sum64()
+}
+
+// mix64 is the SplitMix64 finalizer, used as a family of hash functions.
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
@@ -0,0 +1,105 @@
+package analyzer
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestLSHBands(t *testing.T) {
+	tests := []struct {
+		threshold float64
+		bands     int
+		rows      int
+	}{
+		{0.5, 32, 4},
+		{0.7, 16, 8},
+		{0.9, 8, 16},
+		{0.95, 4, 32},
+	}
+
+	for _, tt := range tests {
+		bands, rows := lshBands(tt.threshold)
+		if bands != tt.bands || rows != tt.rows {
+			t.Errorf("lshBands(%v) = %d, %d, want %d, %d", tt.threshold, bands, rows, tt.bands, tt.rows)
+		}
+		if bands*rows != minHashSize {
+			t.Errorf("lshBands(%v) covers %d rows, want %d", tt.threshold, bands*rows, minHashSize)
+		}
+	}
+}
+
+// testText returns n words of a text identified by seed, with the words
+// at the given positions replaced.
+func testText(seed, n int, replaced ...int) string {
+	words := make([]string, n)
+	for i := range words {
+		words[i] = fmt.Sprintf("w%d", (seed*7919+i*104729)%100003)
+	}
+	for _, i := range replaced {
+		words[i] = "changed"
+	}
+	return strings.Join(words, " ")
+}
+
+func TestMinHashSimilarity(t *testing.T) {
+	base := NewMinHash(testText(1, 300))
+	tests := []struct {
+		name string
+		text string
+		min  float64
+		max  float64
+	}{
+		{"identical", testText(1, 300), 1, 1},
+		{"one word changed", testText(1, 300, 150), 0.9, 1},
+		{"unrelated", testText(2, 300), 0, 0.1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := base.Similarity(NewMinHash(tt.text)); got < tt.min || got > tt.max {
+				t.Errorf("Similarity = %v, want %v-%v", got, tt.min, tt.max)
+			}
+		})
+	}
+
+	if NewMinHash("  ") != nil {
+		t.Error("NewMinHash of a text without words is not nil")
+	}
+}
+
+func TestCandidatePairs(t *testing.T) {
+	sigs := []MinHash{
+		NewMinHash(testText(1, 300)),
+		NewMinHash(testText(2, 300)),
+		NewMinHash(testText(1, 300)),
+		NewMinHash(testText(1, 300, 100)),
+		NewMinHash(testText(3, 300)),
+	}
+
+	for _, threshold := range []float64{0.5, 0.7, 0.9} {
+		seen := make(map[[2]int]int)
+		candidatePairs(sigs, threshold, func(i, j int) {
+			if i >= j {
+				t.Errorf("threshold %v: pair (%d, %d) is not ordered", threshold, i, j)
+			}
+			seen[[2]int{i, j}]++
+		})
+
+		for pair, n := range seen {
+			if n > 1 {
+				t.Errorf("threshold %v: pair %v reported %d times", threshold, pair, n)
+			}
+		}
+		for _, pair := range [][2]int{{0, 2}, {0, 3}, {2, 3}} {
+			if seen[pair] == 0 {
+				t.Errorf("threshold %v: near duplicate pair %v not a candidate", threshold, pair)
+			}
+		}
+		for _, pair := range [][2]int{{0, 1}, {1, 4}, {0, 4}} {
+			if seen[pair] > 0 {
+				t.Errorf("threshold %v: unrelated pair %v is a candidate", threshold, pair)
+			}
+		}
+	}
+}
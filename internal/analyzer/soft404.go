@@ -0,0 +1,291 @@
+package analyzer
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/spider-crawler/spider/internal/fetcher"
+	"github.com/spider-crawler/spider/internal/parser"
+	"github.com/spider-crawler/spider/internal/storage"
+)
+
+// Soft 404 detection settings
+const (
+	soft404Threshold   = 0.5 // minimum confidence to flag a page
+	soft404MaxDistance = 10  // SimHash bits that may differ (error pages are short and often echo the URL)
+	soft404MinWords    = 50  // pages below this word count look empty
+)
+
+// Confidence contributed by each soft 404 signal. Signals are combined as
+// independent evidence: 1 - (1-p1)(1-p2)...
+const (
+	signalErrorContent  = 0.95
+	signalErrorSimHash  = 0.85
+	signalNotFoundTitle = 0.6
+	signalRedirectHome  = 0.6
+	signalErrorTitle    = 0.4 // alone it could be a site that titles every page with its name
+	signalNotFoundText  = 0.35
+	signal404Title      = 0.3 // alone it could be a product number or "Top 404 tips"
+	signalLowWordCount  = 0.25
+)
+
+// status404Pattern matches "404" as a word in a title.
+var status404Pattern = regexp.MustCompile(`\b404\b`)
+
+// notFoundPhrases are matched against the lowercased title and text.
+var notFoundPhrases = []string{
+	"not found", "page not found", "does not exist", "doesn't exist",
+	"no longer available", "could not be found", "couldn't find", "cannot be found",
+	"nicht gefunden", "no encontrada", "introuvable", "non trovata", "bulunamadı",
+}
+
+// ErrorFingerprint describes a host's response to a URL that does not exist.
+type ErrorFingerprint struct {
+	ProbeURL      string
+	StatusCode    int
+	Title         string
+	ContentHash   string
+	SimHash       uint64
+	WordCount     int
+	RedirectsHome bool // Missing URLs are redirected to the home page
+}
+
+// Soft404 is the soft 404 verdict for a page.
+type Soft404 struct {
+	Confidence float64
+	Signals    []string
+}
+
+// Issue returns the "Soft 404" issue of a page, with the confidence and
+// signals as details.
+func (s *Soft404) Issue(urlID int64) *storage.Issue {
+	severity := storage.SeverityMedium
+	if s.Confidence >= 0.8 {
+		severity = storage.SeverityHigh
+	}
+	issue := NewIssue(
+		urlID,
+		storage.IssueSoft404,
+		storage.IssueTypeError,
+		severity,
+		"response",
+		fmt.Sprintf("Soft 404 (%.0f%% confidence): %s", s.Confidence*100, strings.Join(s.Signals, "; ")),
+	)
+	details, _ := json.Marshal(map[string]interface{}{
+		"confidence": s.Confidence,
+		"signals":    s.Signals,
+	})
+	issue.Details = string(details)
+	return issue
+}
+
+// Soft404Analyzer learns the error page of each host by requesting URLs
+// that cannot exist, and flags 200 pages resembling it or showing other
+// soft 404 signals.
+type Soft404Analyzer struct {
+	mu           sync.RWMutex
+	probes       int
+	fingerprints map[string][]*ErrorFingerprint // host -> fingerprints
+}
+
+func NewSoft404Analyzer() *Soft404Analyzer {
+	return &Soft404Analyzer{
+		probes:       3,
+		fingerprints: make(map[string][]*ErrorFingerprint),
+	}
+}
+
+// ProbeHost requests a few random non-existent URLs on the host of siteURL
+// and records their fingerprints.
+func (a *Soft404Analyzer) ProbeHost(ctx context.Context, f *fetcher.Fetcher, siteURL string) ([]*ErrorFingerprint, error) {
+	base, err := url.Parse(siteURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+
+	fingerprints := make([]*ErrorFingerprint, 0, a.probes)
+	for i := 0; i < a.probes; i++ {
+		probe := *base
+		probe.Path = randomProbePath(i)
+		probe.RawPath = ""
+		probe.RawQuery = ""
+		probe.Fragment = ""
+
+		resp := f.Fetch(ctx, probe.String())
+		if ctx.Err() != nil {
+			return fingerprints, ctx.Err()
+		}
+		if resp.Error != nil {
+			continue
+		}
+
+		fp := &ErrorFingerprint{
+			ProbeURL:      probe.String(),
+			StatusCode:    resp.StatusCode,
+			RedirectsHome: resp.HasRedirects() && isHomePage(resp.FinalURL),
+		}
+		if resp.IsHTML() {
+			if page, err := parser.ParseHTML(resp.FinalURL, resp.Body); err == nil {
+				fp.Title = strings.TrimSpace(page.Title)
+				fp.ContentHash = parser.TextHash(page.TextContent)
+				fp.SimHash = SimHash(page.TextContent)
+				fp.WordCount = page.WordCount
+			}
+		}
+		fingerprints = append(fingerprints, fp)
+	}
+
+	if len(fingerprints) == 0 {
+		return nil, fmt.Errorf("no probe of %s succeeded", base.Host)
+	}
+
+	a.mu.Lock()
+	a.fingerprints[strings.ToLower(base.Host)] = fingerprints
+	a.mu.Unlock()
+	return fingerprints, nil
+}
+
+// Fingerprints returns the recorded fingerprints of a host.
+func (a *Soft404Analyzer) Fingerprints(host string) []*ErrorFingerprint {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.fingerprints[strings.ToLower(host)]
+}
+
+// Detect returns the soft 404 verdict for a 2xx page, or nil if the page
+// does not look like an error page.
+func (a *Soft404Analyzer) Detect(ctx *AnalysisContext) *Soft404 {
+	if ctx.Fetch == nil || ctx.Fetch.StatusCode < 200 || ctx.Fetch.StatusCode >= 300 {
+		return nil
+	}
+
+	var title, text, hash string
+	var simHash uint64
+	wordCount := -1
+	if ctx.RawHTML != nil {
+		if page, err := parser.ParseHTML(ctx.URL.URL, ctx.RawHTML); err == nil {
+			title = page.Title
+			text = page.TextContent
+			hash = parser.TextHash(page.TextContent)
+			simHash = SimHash(page.TextContent)
+			wordCount = page.WordCount
+		}
+	} else if ctx.HTMLFeatures != nil {
+		title = ctx.HTMLFeatures.Title
+		wordCount = ctx.HTMLFeatures.WordCount
+	}
+	title = strings.TrimSpace(title)
+	if wordCount < 0 {
+		return nil
+	}
+
+	signals := make(map[string]float64)
+
+	// Resemblance to the host's error page
+	for _, fp := range a.Fingerprints(ctx.URL.Host) {
+		if fp.RedirectsHome {
+			continue
+		}
+		switch {
+		case hash != "" && fp.ContentHash == hash:
+			signals["matches the error page content"] = signalErrorContent
+		case hash != "" && fp.WordCount > 0 && wordCount > 0 && HammingDistance(fp.SimHash, simHash) <= soft404MaxDistance:
+			signals[fmt.Sprintf("near-identical to the error page (SimHash distance %d)", HammingDistance(fp.SimHash, simHash))] = signalErrorSimHash
+		}
+		if title != "" && strings.EqualFold(fp.Title, title) {
+			signals["same title as the error page"] = signalErrorTitle
+		}
+	}
+
+	if containsNotFound(title) {
+		signals["title says not found"] = signalNotFoundTitle
+	} else if containsNotFound(text) {
+		signals["text says not found"] = signalNotFoundText
+	}
+	if status404Pattern.MatchString(title) {
+		signals["title mentions 404"] = signal404Title
+	}
+	if wordCount < soft404MinWords {
+		signals[fmt.Sprintf("low word count (%d)", wordCount)] = signalLowWordCount
+	}
+	if finalURL := a.finalURL(ctx); finalURL != "" && isHomePage(finalURL) && !isHomePage(ctx.URL.URL) {
+		signals["redirected to the home page"] = signalRedirectHome
+	}
+
+	if len(signals) == 0 {
+		return nil
+	}
+	s := &Soft404{Confidence: 1}
+	for signal, p := range signals {
+		s.Confidence *= 1 - p
+		s.Signals = append(s.Signals, signal)
+	}
+	s.Confidence = 1 - s.Confidence
+	if s.Confidence < soft404Threshold {
+		return nil
+	}
+	sort.Slice(s.Signals, func(i, j int) bool { return signals[s.Signals[i]] > signals[s.Signals[j]] })
+	return s
+}
+
+// finalURL returns the URL a redirected page ended on, if it is known.
+func (a *Soft404Analyzer) finalURL(ctx *AnalysisContext) string {
+	if ctx.Fetch.FinalURLID == nil || *ctx.Fetch.FinalURLID == ctx.URL.ID {
+		return ""
+	}
+	for _, u := range ctx.AllURLs {
+		if u.ID == *ctx.Fetch.FinalURLID {
+			return u.URL
+		}
+	}
+	return ""
+}
+
+// Reset clears the recorded fingerprints.
+func (a *Soft404Analyzer) Reset() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.fingerprints = make(map[string][]*ErrorFingerprint)
+}
+
+func containsNotFound(text string) bool {
+	text = strings.ToLower(text)
+	for _, phrase := range notFoundPhrases {
+		if strings.Contains(text, phrase) {
+			return true
+		}
+	}
+	return false
+}
+
+func isHomePage(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	return (u.Path == "" || u.Path == "/") && u.RawQuery == ""
+}
+
+// randomProbePath returns a path that should not exist on any site, varying
+// its shape so that per-directory error handling is noticed.
+func randomProbePath(i int) string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	token := hex.EncodeToString(b)
+	switch i % 3 {
+	case 1:
+		return "/" + token + ".html"
+	case 2:
+		return "/spider-" + token[:8] + "/" + token[8:] + "/"
+	default:
+		return "/" + token
+	}
+}
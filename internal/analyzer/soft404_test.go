@@ -0,0 +1,81 @@
+package analyzer
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/spider-crawler/spider/internal/parser"
+	"github.com/spider-crawler/spider/internal/storage"
+)
+
+func TestSoft404Detect(t *testing.T) {
+	errorPage := "<html><head><title>Example Shop</title></head><body><p>Sorry, we looked everywhere.</p></body></html>"
+	longText := strings.Repeat("Our shop sells handmade furniture built from local oak and walnut. ", 20)
+
+	a := NewSoft404Analyzer()
+	a.fingerprints["example.com"] = []*ErrorFingerprint{soft404Fingerprint(t, errorPage)}
+
+	tests := []struct {
+		name    string
+		html    string
+		flagged bool
+	}{
+		{"error page content", errorPage, true},
+		{"long page with the error page title", testPage("Example Shop", longText), false},
+		{"thin page with the error page title", testPage("Example Shop", "Nothing here yet."), true},
+		{"not found title", testPage("Page Not Found", "Try the search box."), true},
+		{"404 in a long article title", testPage("Top 404 page designs", longText), false},
+		{"regular page", testPage("Oak tables", longText), false},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := &AnalysisContext{
+				URL:     &storage.URL{ID: int64(i + 1), URL: fmt.Sprintf("https://example.com/p/%d", i), Host: "example.com"},
+				Fetch:   &storage.Fetch{StatusCode: 200},
+				RawHTML: []byte(tt.html),
+			}
+			s := a.Detect(ctx)
+			if flagged := s != nil; flagged != tt.flagged {
+				t.Errorf("flagged = %v, want %v (%+v)", flagged, tt.flagged, s)
+			}
+			if s != nil && (s.Confidence < soft404Threshold || s.Confidence > 1) {
+				t.Errorf("confidence %v out of range", s.Confidence)
+			}
+		})
+	}
+}
+
+func TestSoft404SignalWeights(t *testing.T) {
+	// Signals that are common on real pages must not flag a page alone
+	for name, p := range map[string]float64{
+		"error title":    signalErrorTitle,
+		"404 title":      signal404Title,
+		"not found text": signalNotFoundText,
+		"low word count": signalLowWordCount,
+	} {
+		if p >= soft404Threshold {
+			t.Errorf("%s signal %v reaches the threshold %v alone", name, p, soft404Threshold)
+		}
+	}
+}
+
+func testPage(title, text string) string {
+	return "<html><head><title>" + title + "</title></head><body><p>" + text + "</p></body></html>"
+}
+
+func soft404Fingerprint(t *testing.T, html string) *ErrorFingerprint {
+	t.Helper()
+	p, err := parser.ParseHTML("https://example.com/missing", []byte(html))
+	if err != nil {
+		t.Fatalf("ParseHTML: %v", err)
+	}
+	return &ErrorFingerprint{
+		StatusCode:  200,
+		Title:       p.Title,
+		ContentHash: parser.TextHash(p.TextContent),
+		SimHash:     SimHash(p.TextContent),
+		WordCount:   p.WordCount,
+	}
+}
@@ -2,17 +2,19 @@
 package auth
 
 import (
+	"bytes"
 	"encoding/base64"
 	"fmt"
 	"io"
 	"net/http"
-	"net/http/cookiejar"
 	"net/url"
+	"regexp"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/spider-crawler/spider/internal/config"
+	"github.com/spider-crawler/spider/internal/urlutil"
 )
 
 // Authenticator handles authentication for HTTP requests.
@@ -20,12 +22,39 @@ type Authenticator struct {
 	mu sync.RWMutex
 
 	config     *config.CrawlConfig
-	cookieJar  http.CookieJar
+	cookieJar  *cookieStore
 	httpClient *http.Client
 
 	// Session cookies after successful login
 	sessionCookies []*http.Cookie
 
+	// Cookies the login macro requires (extract_cookie steps)
+	macroCookies []string
+
+	// Compiled Auth.LogoutURL, nil if not configured
+	logoutURL *regexp.Regexp
+
+	// Bearer token from OAuth2 or the exec provider
+	token        string
+	tokenExpiry  time.Time // zero if the token does not expire
+	tokenAttempt time.Time
+	refreshToken string // latest OAuth2 refresh token
+
+	// Serializes login macro runs, which happen outside mu
+	loginMu sync.Mutex
+
+	// Digest challenges by host
+	digestMu sync.Mutex
+	digest   map[string]*digestChallenge
+
+	// Host patterns the credentials are sent to (Auth.Hosts), or, if
+	// seedScope is set, the hosts of the crawl scope
+	authHosts []string
+	seedScope *urlutil.Scope
+
+	// Credentials bound to other hosts (HostAuth)
+	scopes []*hostScope
+
 	// Authentication status
 	isAuthenticated bool
 	lastAuthTime    time.Time
@@ -34,14 +63,12 @@ type Authenticator struct {
 
 // NewAuthenticator creates a new authenticator.
 func NewAuthenticator(cfg *config.CrawlConfig) (*Authenticator, error) {
-	jar, err := cookiejar.New(nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create cookie jar: %w", err)
-	}
+	jar := newCookieStore()
 
 	a := &Authenticator{
 		config:    cfg,
 		cookieJar: jar,
+		digest:    make(map[string]*digestChallenge),
 		httpClient: &http.Client{
 			Timeout: cfg.Timeout,
 			Jar:     jar,
@@ -54,11 +81,39 @@ func NewAuthenticator(cfg *config.CrawlConfig) (*Authenticator, error) {
 		},
 	}
 
+	if cfg.Auth != nil && cfg.Auth.LogoutURL != "" {
+		re, err := regexp.Compile(cfg.Auth.LogoutURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid logout URL pattern: %w", err)
+		}
+		a.logoutURL = re
+	}
+
+	if cfg.Auth != nil && len(cfg.Auth.Hosts) > 0 {
+		a.authHosts = cfg.Auth.Hosts
+	} else {
+		a.seedScope = seedScope(cfg)
+	}
+	for _, hc := range cfg.HostAuth {
+		scope, err := newHostScope(a, hc)
+		if err != nil {
+			return nil, fmt.Errorf("host auth for %s: %w", strings.Join(hc.Hosts, ", "), err)
+		}
+		a.scopes = append(a.scopes, scope)
+	}
+
 	// Add pre-configured cookies
 	if len(cfg.Cookies) > 0 {
 		a.addConfiguredCookies()
 	}
 
+	// Import a session exported from a browser
+	if cfg.CookieFile != "" {
+		if _, err := a.ImportCookiesFile(cfg.CookieFile); err != nil {
+			return nil, err
+		}
+	}
+
 	return a, nil
 }
 
@@ -83,8 +138,22 @@ func (a *Authenticator) addConfiguredCookies() {
 	}
 }
 
-// Authenticate performs authentication based on config.
+// Authenticate performs authentication based on config, including the
+// credentials bound to other hosts.
 func (a *Authenticator) Authenticate() error {
+	if err := a.authenticate(); err != nil {
+		return err
+	}
+	return a.authenticateScopes()
+}
+
+// authenticate performs the global authentication.
+func (a *Authenticator) authenticate() error {
+	// The macro runs a browser and takes the lock itself
+	if a.config.AuthType == config.AuthMacro {
+		return a.runLoginMacro()
+	}
+
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
@@ -111,6 +180,14 @@ func (a *Authenticator) Authenticate() error {
 	case config.AuthForm:
 		return a.performFormLogin()
 
+	case config.AuthOAuth2, config.AuthExec:
+		return a.obtainToken()
+
+	case config.AuthDigest:
+		// Digest auth answers the server's first challenge
+		a.isAuthenticated = true
+		return nil
+
 	default:
 		return fmt.Errorf("unknown auth type: %s", a.config.AuthType)
 	}
@@ -181,8 +258,32 @@ func (a *Authenticator) performFormLogin() error {
 	return nil
 }
 
-// ApplyAuth applies authentication to an HTTP request.
+// ApplyAuth applies authentication to an HTTP request. Credentials, headers
+// and cookies are only added for the hosts they are bound to.
 func (a *Authenticator) ApplyAuth(req *http.Request) {
+	if scope := a.scopeFor(req.URL.Host); scope != nil {
+		if scope.auth != nil {
+			scope.auth.applyCredentials(req)
+		}
+		for key, value := range scope.headers {
+			req.Header.Set(key, value)
+		}
+		for name, value := range scope.cookies {
+			req.AddCookie(&http.Cookie{Name: name, Value: value})
+		}
+	} else if a.isCredentialHost(req.URL.Host) {
+		a.applyCredentials(req)
+	}
+
+	a.applyCustomHeaders(req)
+}
+
+// applyCredentials adds the credentials of the auth type to a request.
+func (a *Authenticator) applyCredentials(req *http.Request) {
+	if a.usesToken() {
+		a.ensureToken()
+	}
+
 	a.mu.RLock()
 	defer a.mu.RUnlock()
 
@@ -200,6 +301,16 @@ func (a *Authenticator) ApplyAuth(req *http.Request) {
 			req.Header.Set("Authorization", "Bearer "+a.config.Auth.Token)
 		}
 
+	case config.AuthOAuth2, config.AuthExec:
+		if a.token != "" {
+			req.Header.Set("Authorization", "Bearer "+a.token)
+		}
+
+	case config.AuthDigest:
+		if header := a.digestAuthorization(req); header != "" {
+			req.Header.Set("Authorization", header)
+		}
+
 	case config.AuthCookie, config.AuthForm:
 		// Cookies are automatically added by the cookie jar
 		// But we can also manually add session cookies
@@ -207,11 +318,51 @@ func (a *Authenticator) ApplyAuth(req *http.Request) {
 			req.AddCookie(cookie)
 		}
 	}
+}
 
-	// Apply custom headers
-	for key, value := range a.config.CustomHeaders {
-		req.Header.Set(key, value)
+// HandleUnauthorized is called with a 401 response. It answers digest
+// challenges and renews rejected tokens, and reports whether the request
+// should be retried.
+func (a *Authenticator) HandleUnauthorized(resp *http.Response) bool {
+	if resp.Request == nil {
+		return false
+	}
+	owner := a.authFor(resp.Request.URL.Host)
+	if owner == nil {
+		return false
+	}
+	return owner.handleUnauthorized(resp)
+}
+
+func (a *Authenticator) handleUnauthorized(resp *http.Response) bool {
+	switch a.config.AuthType {
+	case config.AuthDigest:
+		return a.storeDigestChallenge(resp)
+	case config.AuthOAuth2, config.AuthExec:
+		return a.renewRejectedToken(resp.Request)
 	}
+	return false
+}
+
+// HTTPCredentials returns the username and password used to answer HTTP
+// authentication challenges (basic and digest) from host.
+func (a *Authenticator) HTTPCredentials(host string) (username, password string, ok bool) {
+	owner := a.authFor(host)
+	if owner == nil {
+		return "", "", false
+	}
+	return owner.httpCredentials()
+}
+
+func (a *Authenticator) httpCredentials() (username, password string, ok bool) {
+	if a.config.Auth == nil {
+		return "", "", false
+	}
+	switch a.config.AuthType {
+	case config.AuthBasic, config.AuthDigest:
+		return a.config.Auth.Username, a.config.Auth.Password, true
+	}
+	return "", "", false
 }
 
 // IsAuthenticated returns whether authentication was successful.
@@ -265,10 +416,8 @@ func (a *Authenticator) ClearCookies() {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
-	// Create new cookie jar
-	jar, _ := cookiejar.New(nil)
-	a.cookieJar = jar
-	a.httpClient.Jar = jar
+	// Clear in place: the jar is shared with the fetcher and host scopes
+	a.cookieJar.Clear()
 	a.sessionCookies = nil
 	a.isAuthenticated = false
 }
@@ -280,107 +429,132 @@ func (a *Authenticator) GetHTTPClient() *http.Client {
 
 // RefreshAuth re-authenticates if needed.
 func (a *Authenticator) RefreshAuth() error {
+	if err := a.refreshAuth(); err != nil {
+		return err
+	}
+	return a.refreshScopes()
+}
+
+func (a *Authenticator) refreshAuth() error {
+	if a.config.AuthType == config.AuthMacro {
+		// Re-run the macro when expired, required cookies are gone or after 30 minutes
+		a.mu.Lock()
+		expired := !a.isAuthenticated || !a.macroSessionValid() || time.Since(a.lastAuthTime) > 30*time.Minute
+		a.mu.Unlock()
+		if expired {
+			return a.runLoginMacro()
+		}
+		return nil
+	}
+
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
 	// Check if re-authentication is needed
 	if a.config.AuthType == config.AuthForm {
-		// Re-authenticate after 30 minutes
-		if time.Since(a.lastAuthTime) > 30*time.Minute {
+		// Re-authenticate when expired or after 30 minutes
+		if !a.isAuthenticated || time.Since(a.lastAuthTime) > 30*time.Minute {
 			a.isAuthenticated = false
 			return a.performFormLogin()
 		}
 	}
 
+	if a.usesToken() {
+		if !a.isAuthenticated || a.token == "" ||
+			!a.tokenExpiry.IsZero() && time.Until(a.tokenExpiry) < tokenRenewalMargin {
+			return a.obtainToken()
+		}
+	}
+
 	return nil
 }
 
-// ExportCookies exports cookies in Netscape format.
-func (a *Authenticator) ExportCookies(domain string) string {
+// Reauthenticate forces a new login for form and macro authentication.
+func (a *Authenticator) Reauthenticate() error {
+	switch a.config.AuthType {
+	case config.AuthForm:
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		a.isAuthenticated = false
+		return a.performFormLogin()
+	case config.AuthMacro:
+		return a.runLoginMacro()
+	}
+	return nil
+}
+
+// SessionExpired reports whether a response indicates that the login
+// session is no longer valid. A response counts as logged out when its
+// status code is a configured logout code (401 by default), its final URL
+// matches the logout pattern (or the login page, for requests that did not
+// target it), or its body contains the logout marker text. A required macro
+// cookie missing from the jar also counts.
+func (a *Authenticator) SessionExpired(requestURL string, statusCode int, finalURL string, body []byte) bool {
 	a.mu.RLock()
 	defer a.mu.RUnlock()
 
-	u, err := url.Parse(fmt.Sprintf("https://%s", domain))
-	if err != nil {
-		return ""
+	// Only login sessions can expire and be renewed
+	if a.config.AuthType != config.AuthForm && a.config.AuthType != config.AuthMacro {
+		return false
 	}
+	if a.config.Auth == nil {
+		return false
+	}
+	auth := a.config.Auth
 
-	cookies := a.cookieJar.Cookies(u)
-	var builder strings.Builder
-
-	builder.WriteString("# Netscape HTTP Cookie File\n")
-	builder.WriteString("# https://curl.se/docs/http-cookies.html\n\n")
-
-	for _, cookie := range cookies {
-		// Format: domain, flag, path, secure, expiration, name, value
-		httpOnly := "FALSE"
-		if cookie.HttpOnly {
-			httpOnly = "TRUE"
+	if len(auth.LogoutStatusCodes) > 0 {
+		for _, code := range auth.LogoutStatusCodes {
+			if statusCode == code {
+				return true
+			}
 		}
-		secure := "FALSE"
-		if cookie.Secure {
-			secure = "TRUE"
-		}
-		expires := "0"
-		if !cookie.Expires.IsZero() {
-			expires = fmt.Sprintf("%d", cookie.Expires.Unix())
+	} else if statusCode == http.StatusUnauthorized {
+		return true
+	}
+
+	if a.logoutURL != nil {
+		if a.logoutURL.MatchString(finalURL) && !a.logoutURL.MatchString(requestURL) {
+			return true
 		}
+	} else if loginURL := a.loginPageURL(); loginURL != "" &&
+		strings.HasPrefix(finalURL, loginURL) && !strings.HasPrefix(requestURL, loginURL) {
+		return true
+	}
 
-		builder.WriteString(fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
-			cookie.Domain,
-			httpOnly,
-			cookie.Path,
-			secure,
-			expires,
-			cookie.Name,
-			cookie.Value,
-		))
+	if auth.LogoutText != "" && bytes.Contains(body, []byte(auth.LogoutText)) {
+		return true
 	}
 
-	return builder.String()
+	return a.config.AuthType == config.AuthMacro && !a.macroSessionValid()
 }
 
-// ImportCookies imports cookies from Netscape format.
-func (a *Authenticator) ImportCookies(data string) error {
+// ExpireSession marks the session, and those of the host_auth entries, as
+// expired so that the next RefreshAuth logs in again.
+func (a *Authenticator) ExpireSession() {
 	a.mu.Lock()
-	defer a.mu.Unlock()
-
-	lines := strings.Split(data, "\n")
-
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" || strings.HasPrefix(line, "#") {
-			continue
-		}
+	a.isAuthenticated = false
+	a.mu.Unlock()
 
-		parts := strings.Split(line, "\t")
-		if len(parts) < 7 {
-			continue
-		}
-
-		domain := parts[0]
-		// httpOnly := parts[1] == "TRUE"
-		path := parts[2]
-		secure := parts[3] == "TRUE"
-		// expires := parts[4]
-		name := parts[5]
-		value := parts[6]
-
-		u, err := url.Parse(fmt.Sprintf("https://%s", domain))
-		if err != nil {
-			continue
+	for _, scope := range a.scopes {
+		if scope.auth != nil {
+			scope.auth.ExpireSession()
 		}
+	}
+}
 
-		cookie := &http.Cookie{
-			Name:   name,
-			Value:  value,
-			Domain: domain,
-			Path:   path,
-			Secure: secure,
+// loginPageURL returns the login form URL, or the first URL the login
+// macro navigates to.
+func (a *Authenticator) loginPageURL() string {
+	if a.config.Auth == nil {
+		return ""
+	}
+	if a.config.Auth.LoginURL != "" {
+		return a.config.Auth.LoginURL
+	}
+	for _, step := range a.config.Auth.LoginMacro {
+		if step.Action == config.LoginNavigate {
+			return step.Value
 		}
-
-		a.cookieJar.SetCookies(u, []*http.Cookie{cookie})
 	}
-
-	return nil
+	return ""
 }
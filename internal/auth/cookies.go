@@ -0,0 +1,484 @@
+package auth
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spider-crawler/spider/internal/urlutil"
+)
+
+// CookieFormat is a cookie file format.
+type CookieFormat string
+
+const (
+	CookieFormatNetscape CookieFormat = "netscape" // cookies.txt as used by curl and wget
+	CookieFormatJSON     CookieFormat = "json"     // browser cookie-export extensions
+)
+
+// CookieEntry is a cookie with the attributes needed to export it.
+type CookieEntry struct {
+	Domain   string // without leading dot
+	HostOnly bool   // sent only to Domain itself, not its subdomains
+	Path     string
+	Name     string
+	Value    string
+	Secure   bool
+	HttpOnly bool
+	SameSite string
+	Expires  time.Time // zero for session cookies
+}
+
+// cookieStore is a cookie jar that also keeps the attributes of every
+// cookie set through it, which net/http/cookiejar does not expose.
+type cookieStore struct {
+	mu      sync.Mutex
+	jar     *cookiejar.Jar
+	entries map[string]*CookieEntry // domain;path;name
+}
+
+// suffixList adapts the crawler's Public Suffix List to the cookie jar,
+// which then rejects cookies set for a whole suffix such as "co.uk" or
+// "github.io".
+type suffixList struct{}
+
+func (suffixList) PublicSuffix(domain string) string {
+	suffix, _ := urlutil.DefaultSuffixList().PublicSuffix(domain)
+	return suffix
+}
+
+func (suffixList) String() string {
+	return "urlutil.DefaultSuffixList"
+}
+
+// newJar creates an empty cookie jar that honours the Public Suffix List.
+func newJar() *cookiejar.Jar {
+	jar, _ := cookiejar.New(&cookiejar.Options{PublicSuffixList: suffixList{}})
+	return jar
+}
+
+func newCookieStore() *cookieStore {
+	return &cookieStore{
+		jar:     newJar(),
+		entries: make(map[string]*CookieEntry),
+	}
+}
+
+// SetCookies implements http.CookieJar.
+func (s *cookieStore) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for _, c := range cookies {
+		e := &CookieEntry{
+			Domain:   strings.ToLower(u.Hostname()),
+			HostOnly: true,
+			Path:     c.Path,
+			Name:     c.Name,
+			Value:    c.Value,
+			Secure:   c.Secure,
+			HttpOnly: c.HttpOnly,
+			SameSite: sameSiteName(c.SameSite),
+		}
+		if c.Domain != "" {
+			e.Domain = strings.ToLower(strings.TrimPrefix(c.Domain, "."))
+			e.HostOnly = false
+			// The jar rejects cookies for a public suffix
+			if (suffixList{}).PublicSuffix(e.Domain) == e.Domain {
+				continue
+			}
+		}
+		if e.Path == "" || !strings.HasPrefix(e.Path, "/") {
+			e.Path = defaultCookiePath(u.Path)
+		}
+
+		switch {
+		case c.MaxAge < 0:
+			e.Expires = now.Add(-time.Second)
+		case c.MaxAge > 0:
+			e.Expires = now.Add(time.Duration(c.MaxAge) * time.Second)
+		case !c.Expires.IsZero():
+			e.Expires = c.Expires
+		}
+
+		key := e.Domain + ";" + e.Path + ";" + e.Name
+		if !e.Expires.IsZero() && !e.Expires.After(now) {
+			delete(s.entries, key)
+		} else {
+			s.entries[key] = e
+		}
+	}
+	s.jar.SetCookies(u, cookies)
+}
+
+// Cookies implements http.CookieJar.
+func (s *cookieStore) Cookies(u *url.URL) []*http.Cookie {
+	s.mu.Lock()
+	jar := s.jar
+	s.mu.Unlock()
+	return jar.Cookies(u)
+}
+
+// Clear removes all cookies.
+func (s *cookieStore) Clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jar = newJar()
+	s.entries = make(map[string]*CookieEntry)
+}
+
+// Entries returns the unexpired cookies for domain and its subdomains
+// (all cookies if domain is empty), sorted by domain, path and name.
+func (s *cookieStore) Entries(domain string) []*CookieEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	domain = strings.ToLower(strings.TrimPrefix(domain, "."))
+	now := time.Now()
+	entries := make([]*CookieEntry, 0, len(s.entries))
+	for _, e := range s.entries {
+		if !e.Expires.IsZero() && !e.Expires.After(now) {
+			continue
+		}
+		if domain != "" && e.Domain != domain && !strings.HasSuffix(e.Domain, "."+domain) {
+			continue
+		}
+		entryCopy := *e
+		entries = append(entries, &entryCopy)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Domain != entries[j].Domain {
+			return entries[i].Domain < entries[j].Domain
+		}
+		if entries[i].Path != entries[j].Path {
+			return entries[i].Path < entries[j].Path
+		}
+		return entries[i].Name < entries[j].Name
+	})
+	return entries
+}
+
+// Add stores an entry with all its attributes.
+func (s *cookieStore) Add(e *CookieEntry) {
+	scheme := "http"
+	if e.Secure {
+		scheme = "https"
+	}
+	u := &url.URL{Scheme: scheme, Host: e.Domain, Path: e.Path}
+
+	cookie := &http.Cookie{
+		Name:     e.Name,
+		Value:    e.Value,
+		Path:     e.Path,
+		Secure:   e.Secure,
+		HttpOnly: e.HttpOnly,
+		Expires:  e.Expires,
+		SameSite: parseSameSite(e.SameSite),
+	}
+	if !e.HostOnly {
+		cookie.Domain = e.Domain
+	}
+	s.SetCookies(u, []*http.Cookie{cookie})
+}
+
+// defaultCookiePath returns the default cookie path (RFC 6265 5.1.4).
+func defaultCookiePath(p string) string {
+	i := strings.LastIndex(p, "/")
+	if p == "" || p[0] != '/' || i <= 0 {
+		return "/"
+	}
+	return p[:i]
+}
+
+func sameSiteName(s http.SameSite) string {
+	switch s {
+	case http.SameSiteLaxMode:
+		return "lax"
+	case http.SameSiteStrictMode:
+		return "strict"
+	case http.SameSiteNoneMode:
+		return "no_restriction"
+	}
+	return ""
+}
+
+func parseSameSite(s string) http.SameSite {
+	switch strings.ToLower(s) {
+	case "lax":
+		return http.SameSiteLaxMode
+	case "strict":
+		return http.SameSiteStrictMode
+	case "none", "no_restriction":
+		return http.SameSiteNoneMode
+	}
+	return http.SameSiteDefaultMode
+}
+
+// --- Netscape cookies.txt ---
+
+// httpOnlyPrefix marks HttpOnly cookies in cookies.txt (curl convention).
+const httpOnlyPrefix = "#HttpOnly_"
+
+// formatNetscape writes entries in cookies.txt format.
+func formatNetscape(entries []*CookieEntry) string {
+	var builder strings.Builder
+	builder.WriteString("# Netscape HTTP Cookie File\n")
+	builder.WriteString("# https://curl.se/docs/http-cookies.html\n\n")
+
+	for _, e := range entries {
+		// Format: domain, include subdomains, path, secure, expiration, name, value
+		domain := e.Domain
+		if !e.HostOnly {
+			domain = "." + domain
+		}
+		if e.HttpOnly {
+			domain = httpOnlyPrefix + domain
+		}
+		expires := "0"
+		if !e.Expires.IsZero() {
+			expires = strconv.FormatInt(e.Expires.Unix(), 10)
+		}
+
+		builder.WriteString(strings.Join([]string{
+			domain,
+			netscapeBool(!e.HostOnly),
+			e.Path,
+			netscapeBool(e.Secure),
+			expires,
+			e.Name,
+			e.Value,
+		}, "\t"))
+		builder.WriteByte('\n')
+	}
+	return builder.String()
+}
+
+func netscapeBool(b bool) string {
+	if b {
+		return "TRUE"
+	}
+	return "FALSE"
+}
+
+// parseNetscape reads cookies.txt data. Malformed lines are skipped.
+func parseNetscape(data string) []*CookieEntry {
+	entries := make([]*CookieEntry, 0)
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		httpOnly := false
+		if strings.HasPrefix(line, httpOnlyPrefix) {
+			httpOnly = true
+			line = line[len(httpOnlyPrefix):]
+		}
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		parts := strings.Split(line, "\t")
+		if len(parts) < 7 {
+			continue
+		}
+
+		domain := strings.ToLower(parts[0])
+		e := &CookieEntry{
+			Domain:   strings.TrimPrefix(domain, "."),
+			HostOnly: !strings.HasPrefix(domain, ".") && !strings.EqualFold(parts[1], "TRUE"),
+			Path:     parts[2],
+			Secure:   strings.EqualFold(parts[3], "TRUE"),
+			Name:     parts[5],
+			Value:    strings.Join(parts[6:], "\t"),
+			HttpOnly: httpOnly,
+		}
+		if expires, err := strconv.ParseInt(parts[4], 10, 64); err == nil && expires > 0 {
+			e.Expires = time.Unix(expires, 0)
+		}
+		if e.Path == "" {
+			e.Path = "/"
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// --- Browser extension JSON ---
+
+// jsonCookie is the cookie format of common browser cookie-export
+// extensions (the chrome.cookies API object).
+type jsonCookie struct {
+	Domain         string   `json:"domain"`
+	HostOnly       bool     `json:"hostOnly"`
+	Path           string   `json:"path"`
+	Name           string   `json:"name"`
+	Value          string   `json:"value"`
+	Secure         bool     `json:"secure"`
+	HttpOnly       bool     `json:"httpOnly"`
+	SameSite       string   `json:"sameSite,omitempty"`
+	Session        bool     `json:"session"`
+	ExpirationDate *float64 `json:"expirationDate,omitempty"`
+}
+
+// formatJSON writes entries as a browser-extension JSON array.
+func formatJSON(entries []*CookieEntry) (string, error) {
+	cookies := make([]*jsonCookie, 0, len(entries))
+	for _, e := range entries {
+		c := &jsonCookie{
+			Domain:   e.Domain,
+			HostOnly: e.HostOnly,
+			Path:     e.Path,
+			Name:     e.Name,
+			Value:    e.Value,
+			Secure:   e.Secure,
+			HttpOnly: e.HttpOnly,
+			SameSite: e.SameSite,
+			Session:  e.Expires.IsZero(),
+		}
+		if !e.HostOnly {
+			c.Domain = "." + e.Domain
+		}
+		if !e.Expires.IsZero() {
+			expires := float64(e.Expires.UnixNano()) / 1e9
+			c.ExpirationDate = &expires
+		}
+		cookies = append(cookies, c)
+	}
+
+	data, err := json.MarshalIndent(cookies, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// parseJSON reads a browser-extension JSON array, or an object with a
+// "cookies" array.
+func parseJSON(data string) ([]*CookieEntry, error) {
+	var cookies []*jsonCookie
+	if strings.HasPrefix(strings.TrimSpace(data), "{") {
+		var wrapper struct {
+			Cookies []*jsonCookie `json:"cookies"`
+		}
+		if err := json.Unmarshal([]byte(data), &wrapper); err != nil {
+			return nil, fmt.Errorf("invalid cookie JSON: %w", err)
+		}
+		cookies = wrapper.Cookies
+	} else if err := json.Unmarshal([]byte(data), &cookies); err != nil {
+		return nil, fmt.Errorf("invalid cookie JSON: %w", err)
+	}
+
+	entries := make([]*CookieEntry, 0, len(cookies))
+	for _, c := range cookies {
+		if c == nil || c.Name == "" || c.Domain == "" {
+			continue
+		}
+		domain := strings.ToLower(c.Domain)
+		e := &CookieEntry{
+			Domain:   strings.TrimPrefix(domain, "."),
+			HostOnly: c.HostOnly,
+			Path:     c.Path,
+			Name:     c.Name,
+			Value:    c.Value,
+			Secure:   c.Secure,
+			HttpOnly: c.HttpOnly,
+			SameSite: c.SameSite,
+		}
+		if strings.HasPrefix(domain, ".") {
+			e.HostOnly = false
+		}
+		if !c.Session && c.ExpirationDate != nil && *c.ExpirationDate > 0 {
+			sec := int64(*c.ExpirationDate)
+			e.Expires = time.Unix(sec, int64((*c.ExpirationDate-float64(sec))*1e9))
+		}
+		if e.Path == "" {
+			e.Path = "/"
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// --- Authenticator API ---
+
+// ExportCookies exports cookies for domain and its subdomains in Netscape
+// cookies.txt format. An empty domain exports all cookies.
+func (a *Authenticator) ExportCookies(domain string) string {
+	return formatNetscape(a.cookieJar.Entries(domain))
+}
+
+// ExportCookiesJSON exports cookies in the JSON format of browser
+// cookie-export extensions.
+func (a *Authenticator) ExportCookiesJSON(domain string) (string, error) {
+	return formatJSON(a.cookieJar.Entries(domain))
+}
+
+// ImportCookies imports cookies from Netscape cookies.txt or browser
+// extension JSON, detected from the content. Expired cookies are skipped.
+func (a *Authenticator) ImportCookies(data string) error {
+	_, err := a.importCookies(data)
+	return err
+}
+
+func (a *Authenticator) importCookies(data string) (int, error) {
+	var entries []*CookieEntry
+	trimmed := strings.TrimSpace(data)
+	if strings.HasPrefix(trimmed, "[") || strings.HasPrefix(trimmed, "{") {
+		var err error
+		if entries, err = parseJSON(trimmed); err != nil {
+			return 0, err
+		}
+	} else {
+		entries = parseNetscape(data)
+	}
+
+	now := time.Now()
+	imported := 0
+	for _, e := range entries {
+		if !e.Expires.IsZero() && !e.Expires.After(now) {
+			continue
+		}
+		a.cookieJar.Add(e)
+		imported++
+	}
+	return imported, nil
+}
+
+// ImportCookiesFile imports a cookies.txt or JSON cookie file and returns
+// the number of cookies imported.
+func (a *Authenticator) ImportCookiesFile(filePath string) (int, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read cookie file: %w", err)
+	}
+	return a.importCookies(string(data))
+}
+
+// ExportCookiesFile writes the cookies for domain to a file.
+func (a *Authenticator) ExportCookiesFile(filePath, domain string, format CookieFormat) error {
+	var data string
+	switch format {
+	case CookieFormatJSON:
+		var err error
+		if data, err = a.ExportCookiesJSON(domain); err != nil {
+			return err
+		}
+	case CookieFormatNetscape, "":
+		data = a.ExportCookies(domain)
+	default:
+		return fmt.Errorf("unknown cookie format: %s", format)
+	}
+
+	// Cookie files hold session credentials
+	return os.WriteFile(filePath, []byte(data), 0600)
+}
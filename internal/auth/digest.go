@@ -0,0 +1,198 @@
+package auth
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/http"
+	"strings"
+)
+
+// digestChallenge is a parsed WWW-Authenticate: Digest challenge (RFC 7616).
+type digestChallenge struct {
+	realm     string
+	nonce     string
+	opaque    string
+	algorithm string
+	qop       string // "auth" or empty
+	count     int    // nonce count sent with the next request
+}
+
+// parseDigestChallenge returns the strongest supported digest challenge
+// among the WWW-Authenticate header values, or nil.
+func parseDigestChallenge(values []string) *digestChallenge {
+	var best *digestChallenge
+	for _, value := range values {
+		if len(value) < 7 || !strings.EqualFold(value[:7], "Digest ") {
+			continue
+		}
+		params := parseAuthParams(value[7:])
+
+		c := &digestChallenge{
+			realm:     params["realm"],
+			nonce:     params["nonce"],
+			opaque:    params["opaque"],
+			algorithm: strings.ToUpper(params["algorithm"]),
+		}
+		if c.algorithm == "" {
+			c.algorithm = "MD5"
+		}
+		if digestHash(c.algorithm) == nil || c.nonce == "" {
+			continue
+		}
+		if qop, ok := params["qop"]; ok {
+			supported := false
+			for _, q := range strings.Split(qop, ",") {
+				if strings.TrimSpace(q) == "auth" {
+					supported = true
+				}
+			}
+			if !supported {
+				// auth-int needs the request body, which we never send
+				continue
+			}
+			c.qop = "auth"
+		}
+
+		if best == nil || strings.HasPrefix(c.algorithm, "SHA-256") && !strings.HasPrefix(best.algorithm, "SHA-256") {
+			best = c
+		}
+	}
+	return best
+}
+
+// parseAuthParams parses comma-separated key=value pairs with optional
+// quoted values.
+func parseAuthParams(s string) map[string]string {
+	params := make(map[string]string)
+	for len(s) > 0 {
+		s = strings.TrimLeft(s, " \t,")
+		eq := strings.IndexByte(s, '=')
+		if eq < 0 {
+			break
+		}
+		key := strings.ToLower(strings.TrimSpace(s[:eq]))
+		s = strings.TrimLeft(s[eq+1:], " \t")
+
+		var value string
+		if strings.HasPrefix(s, `"`) {
+			var b strings.Builder
+			i := 1
+			for ; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' && i+1 < len(s) {
+					i++
+				}
+				b.WriteByte(s[i])
+			}
+			value = b.String()
+			s = s[min(i+1, len(s)):]
+		} else {
+			end := strings.IndexByte(s, ',')
+			if end < 0 {
+				end = len(s)
+			}
+			value = strings.TrimSpace(s[:end])
+			s = s[end:]
+		}
+		params[key] = value
+	}
+	return params
+}
+
+func digestHash(algorithm string) func() hash.Hash {
+	switch strings.TrimSuffix(algorithm, "-SESS") {
+	case "MD5":
+		return md5.New
+	case "SHA-256":
+		return sha256.New
+	}
+	return nil
+}
+
+// authorization computes the Authorization header for a request and
+// advances the nonce count.
+func (c *digestChallenge) authorization(username, password, method, uri string) string {
+	newHash := digestHash(c.algorithm)
+	h := func(s string) string {
+		hh := newHash()
+		hh.Write([]byte(s))
+		return hex.EncodeToString(hh.Sum(nil))
+	}
+
+	cnonceBytes := make([]byte, 8)
+	rand.Read(cnonceBytes)
+	cnonce := hex.EncodeToString(cnonceBytes)
+
+	c.count++
+	nc := fmt.Sprintf("%08x", c.count)
+
+	ha1 := h(username + ":" + c.realm + ":" + password)
+	if strings.HasSuffix(c.algorithm, "-SESS") {
+		ha1 = h(ha1 + ":" + c.nonce + ":" + cnonce)
+	}
+	ha2 := h(method + ":" + uri)
+
+	var response string
+	if c.qop != "" {
+		response = h(strings.Join([]string{ha1, c.nonce, nc, cnonce, c.qop, ha2}, ":"))
+	} else {
+		response = h(ha1 + ":" + c.nonce + ":" + ha2)
+	}
+
+	parts := []string{
+		fmt.Sprintf(`username="%s"`, username),
+		fmt.Sprintf(`realm="%s"`, c.realm),
+		fmt.Sprintf(`nonce="%s"`, c.nonce),
+		fmt.Sprintf(`uri="%s"`, uri),
+		fmt.Sprintf(`algorithm=%s`, c.algorithm),
+		fmt.Sprintf(`response="%s"`, response),
+	}
+	if c.opaque != "" {
+		parts = append(parts, fmt.Sprintf(`opaque="%s"`, c.opaque))
+	}
+	if c.qop != "" {
+		parts = append(parts, "qop="+c.qop, "nc="+nc, fmt.Sprintf(`cnonce="%s"`, cnonce))
+	}
+	return "Digest " + strings.Join(parts, ", ")
+}
+
+// digestAuthorization returns the Authorization header for req if a
+// challenge from its host is known.
+func (a *Authenticator) digestAuthorization(req *http.Request) string {
+	if a.config.Auth == nil {
+		return ""
+	}
+
+	a.digestMu.Lock()
+	defer a.digestMu.Unlock()
+
+	c, ok := a.digest[req.URL.Host]
+	if !ok {
+		return ""
+	}
+	return c.authorization(a.config.Auth.Username, a.config.Auth.Password, req.Method, req.URL.RequestURI())
+}
+
+// storeDigestChallenge records the challenge of a 401 response. Returns
+// false if the response carries no usable challenge, or if the same nonce
+// was already rejected (wrong credentials).
+func (a *Authenticator) storeDigestChallenge(resp *http.Response) bool {
+	c := parseDigestChallenge(resp.Header.Values("WWW-Authenticate"))
+	if c == nil || resp.Request == nil {
+		return false
+	}
+
+	a.digestMu.Lock()
+	defer a.digestMu.Unlock()
+
+	host := resp.Request.URL.Host
+	if prev, ok := a.digest[host]; ok && prev.nonce == c.nonce &&
+		resp.Request.Header.Get("Authorization") != "" {
+		return false
+	}
+	a.digest[host] = c
+	return true
+}
@@ -0,0 +1,240 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/storage"
+	"github.com/chromedp/chromedp"
+
+	"github.com/spider-crawler/spider/internal/config"
+)
+
+// runLoginMacro executes the configured login steps in a headless browser
+// and copies the resulting browser cookies into the cookie jar. Caller must
+// not hold a.mu: it is taken only to store the new session, so requests
+// keep using the old one while the browser runs.
+func (a *Authenticator) runLoginMacro() error {
+	if a.config.Auth == nil || len(a.config.Auth.LoginMacro) == 0 {
+		return fmt.Errorf("login macro is not configured")
+	}
+
+	// One browser login at a time
+	a.loginMu.Lock()
+	defer a.loginMu.Unlock()
+
+	opts := append(chromedp.DefaultExecAllocatorOptions[:],
+		chromedp.Flag("headless", true),
+		chromedp.Flag("disable-gpu", true),
+		chromedp.Flag("no-sandbox", true),
+		chromedp.Flag("disable-dev-shm-usage", true),
+		chromedp.UserAgent(a.config.UserAgent),
+	)
+	if a.config.ChromiumPath != "" {
+		opts = append(opts, chromedp.ExecPath(a.config.ChromiumPath))
+	}
+
+	allocCtx, cancelAlloc := chromedp.NewExecAllocator(context.Background(), opts...)
+	defer cancelAlloc()
+	ctx, cancel := chromedp.NewContext(allocCtx)
+	defer cancel()
+
+	required := make([]string, 0)
+	for i, step := range a.config.Auth.LoginMacro {
+		if step.Action == config.LoginExtractCookie {
+			required = append(required, step.Value)
+		}
+		if err := a.runLoginStep(ctx, step); err != nil {
+			return a.loginFailed(fmt.Errorf("login step %d (%s) failed: %w", i+1, step.Action, err))
+		}
+	}
+
+	var cookies []*network.Cookie
+	err := chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		var err error
+		cookies, err = storage.GetCookies().Do(ctx)
+		return err
+	}))
+	if err != nil {
+		return a.loginFailed(fmt.Errorf("failed to read browser cookies: %w", err))
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	transferCookies(a.cookieJar, cookies)
+
+	a.macroCookies = required
+	a.isAuthenticated = true
+	a.lastAuthTime = time.Now()
+	a.authError = nil
+
+	return nil
+}
+
+// loginFailed records a failed macro login and returns its error.
+func (a *Authenticator) loginFailed(err error) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.isAuthenticated = false
+	a.authError = err
+	return err
+}
+
+// runLoginStep executes a single macro step.
+func (a *Authenticator) runLoginStep(ctx context.Context, step *config.LoginStep) error {
+	timeout := step.Timeout
+	if timeout <= 0 {
+		timeout = a.config.RenderTimeout
+	}
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	stepCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	switch step.Action {
+	case config.LoginNavigate:
+		return chromedp.Run(stepCtx, chromedp.Navigate(step.Value))
+
+	case config.LoginType:
+		return chromedp.Run(stepCtx,
+			chromedp.WaitVisible(step.Selector, chromedp.ByQuery),
+			chromedp.Clear(step.Selector, chromedp.ByQuery),
+			chromedp.SendKeys(step.Selector, step.Value, chromedp.ByQuery),
+		)
+
+	case config.LoginClick:
+		return chromedp.Run(stepCtx,
+			chromedp.WaitVisible(step.Selector, chromedp.ByQuery),
+			chromedp.Click(step.Selector, chromedp.ByQuery),
+		)
+
+	case config.LoginWaitSelector:
+		return chromedp.Run(stepCtx, chromedp.WaitVisible(step.Selector, chromedp.ByQuery))
+
+	case config.LoginWaitURL:
+		return waitForURL(stepCtx, step.Value)
+
+	case config.LoginExtractCookie:
+		return waitForCookie(stepCtx, step.Value)
+
+	default:
+		return fmt.Errorf("unknown login action: %s", step.Action)
+	}
+}
+
+// waitForURL polls the page location until it contains want.
+func waitForURL(ctx context.Context, want string) error {
+	for {
+		var location string
+		if err := chromedp.Run(ctx, chromedp.Location(&location)); err != nil {
+			return err
+		}
+		if strings.Contains(location, want) {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("URL %q does not contain %q", location, want)
+		case <-time.After(200 * time.Millisecond):
+		}
+	}
+}
+
+// waitForCookie polls the browser until a cookie with the given name exists.
+func waitForCookie(ctx context.Context, name string) error {
+	for {
+		var cookies []*network.Cookie
+		err := chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+			var err error
+			cookies, err = storage.GetCookies().Do(ctx)
+			return err
+		}))
+		if err != nil {
+			return err
+		}
+		for _, c := range cookies {
+			if c.Name == name {
+				return nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("cookie %q was not set", name)
+		case <-time.After(200 * time.Millisecond):
+		}
+	}
+}
+
+// transferCookies copies browser cookies into an HTTP cookie jar.
+func transferCookies(jar http.CookieJar, cookies []*network.Cookie) {
+	for _, c := range cookies {
+		scheme := "http"
+		if c.Secure {
+			scheme = "https"
+		}
+		host := strings.TrimPrefix(c.Domain, ".")
+		u := &url.URL{Scheme: scheme, Host: host, Path: c.Path}
+
+		cookie := &http.Cookie{
+			Name:     c.Name,
+			Value:    c.Value,
+			Path:     c.Path,
+			Secure:   c.Secure,
+			HttpOnly: c.HTTPOnly,
+		}
+		// Host-only cookies must not carry a Domain attribute
+		if strings.HasPrefix(c.Domain, ".") {
+			cookie.Domain = c.Domain
+		}
+		if !c.Session && c.Expires > 0 {
+			cookie.Expires = time.Unix(int64(c.Expires), 0)
+		}
+
+		jar.SetCookies(u, []*http.Cookie{cookie})
+	}
+}
+
+// macroSessionValid reports whether every cookie required by the macro is
+// still present in the jar. Expired cookies are dropped by the jar.
+// Caller must hold a.mu.
+func (a *Authenticator) macroSessionValid() bool {
+	if len(a.macroCookies) == 0 {
+		return true
+	}
+
+	present := make(map[string]bool)
+	for _, u := range a.macroURLs() {
+		for _, c := range a.cookieJar.Cookies(u) {
+			present[c.Name] = true
+		}
+	}
+	for _, name := range a.macroCookies {
+		if !present[name] {
+			return false
+		}
+	}
+	return true
+}
+
+// macroURLs returns the URLs visited by navigate steps.
+func (a *Authenticator) macroURLs() []*url.URL {
+	urls := make([]*url.URL, 0)
+	for _, step := range a.config.Auth.LoginMacro {
+		if step.Action != config.LoginNavigate {
+			continue
+		}
+		if u, err := url.Parse(step.Value); err == nil {
+			urls = append(urls, u)
+		}
+	}
+	return urls
+}
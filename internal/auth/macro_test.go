@@ -0,0 +1,94 @@
+package auth
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spider-crawler/spider/internal/config"
+	fixtures "github.com/spider-crawler/spider/internal/testing"
+)
+
+// chromiumPath returns a Chromium executable, or "" if none is installed.
+func chromiumPath() string {
+	for _, name := range []string{"chromium", "chromium-browser", "google-chrome", "google-chrome-stable", "headless-shell"} {
+		if path, err := exec.LookPath(name); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+func TestLoginMacro(t *testing.T) {
+	chromium := chromiumPath()
+	if chromium == "" {
+		t.Skip("Chromium is not installed")
+	}
+
+	ls := fixtures.NewLoginServer("crawler", "secret")
+	defer ls.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.ChromiumPath = chromium
+	cfg.RenderTimeout = 30 * time.Second
+	cfg.Seeds = []string{ls.URL()}
+	cfg.AuthType = config.AuthMacro
+	cfg.Auth = &config.AuthConfig{LoginMacro: ls.LoginMacro()}
+
+	a, err := NewAuthenticator(cfg)
+	if err != nil {
+		t.Fatalf("NewAuthenticator: %v", err)
+	}
+	if err := a.Authenticate(); err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if ls.Logins() != 1 {
+		t.Fatalf("logins = %d, want 1", ls.Logins())
+	}
+
+	account := ls.URL() + "/account"
+	get := func() (*http.Response, []byte) {
+		t.Helper()
+		resp, err := a.GetHTTPClient().Get(account)
+		if err != nil {
+			t.Fatalf("GET %s: %v", account, err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp, body
+	}
+
+	// The browser session cookie is used by the crawler's client
+	resp, body := get()
+	if resp.Request.URL.Path != "/account" || !strings.Contains(string(body), "Welcome, crawler") {
+		t.Fatalf("account page not reached, final URL %s", resp.Request.URL)
+	}
+
+	// A server-side logout is detected and the macro runs again
+	ls.Expire()
+	resp, body = get()
+	if !a.SessionExpired(account, resp.StatusCode, resp.Request.URL.String(), body) {
+		t.Fatalf("expired session not detected, final URL %s", resp.Request.URL)
+	}
+	a.ExpireSession()
+	if err := a.RefreshAuth(); err != nil {
+		t.Fatalf("RefreshAuth: %v", err)
+	}
+	if ls.Logins() != 2 {
+		t.Fatalf("logins = %d, want 2", ls.Logins())
+	}
+
+	resp, _ = get()
+	if resp.Request.URL.Path != "/account" {
+		t.Fatalf("account page not reached after re-login, final URL %s", resp.Request.URL)
+	}
+
+	u, _ := url.Parse(ls.URL())
+	if len(a.cookieJar.Cookies(u)) == 0 {
+		t.Fatal("no cookies transferred into the jar")
+	}
+}
@@ -0,0 +1,158 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/spider-crawler/spider/internal/config"
+	"github.com/spider-crawler/spider/internal/urlutil"
+)
+
+// credentialHeaders are custom headers that carry credentials. Unless
+// CustomHeaderHosts is set they are only sent to the credential hosts.
+var credentialHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"X-Api-Key":           true,
+	"X-Auth-Token":        true,
+}
+
+// hostScope is a set of credentials, headers and cookies bound to hosts.
+type hostScope struct {
+	hosts   []string
+	auth    *Authenticator // nil if the entry only sets headers or cookies
+	headers map[string]string
+	cookies map[string]string
+}
+
+// newHostScope creates the scope for a host_auth entry. Its authenticator
+// shares the parent's cookie jar so that logins are visible to the fetcher.
+func newHostScope(parent *Authenticator, hc *config.HostCredentials) (*hostScope, error) {
+	scope := &hostScope{
+		hosts:   hc.Hosts,
+		headers: hc.Headers,
+		cookies: hc.Cookies,
+	}
+	if hc.AuthType == "" || hc.AuthType == config.AuthNone {
+		return scope, nil
+	}
+
+	cfg := parent.config.Clone()
+	cfg.AuthType = hc.AuthType
+	cfg.Auth = hc.Auth
+	cfg.CustomHeaders = nil
+	cfg.CustomHeaderHosts = nil
+	cfg.Cookies = nil
+	cfg.CookieFile = ""
+	cfg.HostAuth = nil
+
+	child, err := NewAuthenticator(cfg)
+	if err != nil {
+		return nil, err
+	}
+	child.cookieJar = parent.cookieJar
+	child.httpClient.Jar = parent.cookieJar
+	child.authHosts = hc.Hosts
+	child.seedScope = nil
+
+	scope.auth = child
+	return scope, nil
+}
+
+// seedScope returns the scope of the configured seeds, which receive the
+// global credentials when Auth.Hosts is not set.
+func seedScope(cfg *config.CrawlConfig) *urlutil.Scope {
+	scope := urlutil.NewScope(cfg.IncludeSubdomains)
+	for _, seed := range cfg.Seeds {
+		scope.AddSeed(seed)
+	}
+	return scope
+}
+
+// SetScope sends the global credentials to the hosts of the crawl scope,
+// including seeds added to the scheduler after the authenticator was
+// created. It has no effect when Auth.Hosts is set. Call it before the
+// crawl starts.
+func (a *Authenticator) SetScope(scope *urlutil.Scope) {
+	if a.seedScope != nil {
+		a.seedScope = scope
+	}
+}
+
+// isCredentialHost reports whether the global credentials may be sent to
+// host: it matches Auth.Hosts, or, without Auth.Hosts, is a seed host (or
+// shares a seed's registrable domain when subdomains are included). With
+// neither, credentials are sent nowhere.
+func (a *Authenticator) isCredentialHost(host string) bool {
+	if a.seedScope != nil {
+		return a.seedScope.IsInternalHost(host)
+	}
+	return urlutil.MatchAnyHost(host, a.authHosts)
+}
+
+// scopeFor returns the first host_auth entry matching host, or nil.
+func (a *Authenticator) scopeFor(host string) *hostScope {
+	for _, scope := range a.scopes {
+		if urlutil.MatchAnyHost(host, scope.hosts) {
+			return scope
+		}
+	}
+	return nil
+}
+
+// authFor returns the authenticator responsible for host, or nil if no
+// credentials may be sent to it.
+func (a *Authenticator) authFor(host string) *Authenticator {
+	if scope := a.scopeFor(host); scope != nil {
+		return scope.auth
+	}
+	if a.isCredentialHost(host) {
+		return a
+	}
+	return nil
+}
+
+// applyCustomHeaders sets CustomHeaders honouring CustomHeaderHosts.
+func (a *Authenticator) applyCustomHeaders(req *http.Request) {
+	host := req.URL.Host
+	scoped := len(a.config.CustomHeaderHosts) > 0
+	if scoped && !urlutil.MatchAnyHost(host, a.config.CustomHeaderHosts) {
+		return
+	}
+
+	credentialHost := a.isCredentialHost(host)
+	for key, value := range a.config.CustomHeaders {
+		if !scoped && !credentialHost && credentialHeaders[http.CanonicalHeaderKey(key)] {
+			continue
+		}
+		req.Header.Set(key, value)
+	}
+}
+
+// authenticateScopes authenticates every host_auth entry with credentials.
+func (a *Authenticator) authenticateScopes() error {
+	for _, scope := range a.scopes {
+		if scope.auth == nil {
+			continue
+		}
+		if err := scope.auth.Authenticate(); err != nil {
+			return fmt.Errorf("authentication for %s failed: %w", strings.Join(scope.hosts, ", "), err)
+		}
+	}
+	return nil
+}
+
+// refreshScopes refreshes every host_auth entry with credentials.
+func (a *Authenticator) refreshScopes() error {
+	for _, scope := range a.scopes {
+		if scope.auth == nil {
+			continue
+		}
+		if err := scope.auth.RefreshAuth(); err != nil {
+			return fmt.Errorf("refresh for %s failed: %w", strings.Join(scope.hosts, ", "), err)
+		}
+	}
+	return nil
+}
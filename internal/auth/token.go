@@ -0,0 +1,196 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/spider-crawler/spider/internal/config"
+)
+
+// tokenRenewalMargin renews OAuth2 tokens this long before they expire.
+const tokenRenewalMargin = time.Minute
+
+// tokenRetryInterval limits how often a failed token request is retried.
+const tokenRetryInterval = 5 * time.Second
+
+// oauth2TokenResponse is the token endpoint response (RFC 6749 section 5).
+type oauth2TokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int64  `json:"expires_in"`
+	RefreshToken     string `json:"refresh_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// usesToken reports whether the auth type sends a renewable bearer token.
+func (a *Authenticator) usesToken() bool {
+	return a.config.AuthType == config.AuthOAuth2 || a.config.AuthType == config.AuthExec
+}
+
+// obtainToken requests a new token from the configured provider.
+// Caller must hold a.mu.
+func (a *Authenticator) obtainToken() error {
+	a.tokenAttempt = time.Now()
+
+	var err error
+	switch a.config.AuthType {
+	case config.AuthOAuth2:
+		err = a.fetchOAuth2Token()
+	case config.AuthExec:
+		err = a.runTokenCommand()
+	default:
+		err = fmt.Errorf("auth type %s does not use tokens", a.config.AuthType)
+	}
+
+	if err != nil {
+		a.authError = err
+		a.isAuthenticated = false
+		return err
+	}
+
+	a.isAuthenticated = true
+	a.lastAuthTime = time.Now()
+	a.authError = nil
+	return nil
+}
+
+// ensureToken renews the token if it is missing or about to expire.
+func (a *Authenticator) ensureToken() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.token != "" && (a.tokenExpiry.IsZero() || time.Until(a.tokenExpiry) > tokenRenewalMargin) {
+		return
+	}
+	// Do not hammer the provider while it is failing
+	if a.authError != nil && time.Since(a.tokenAttempt) < tokenRetryInterval {
+		return
+	}
+	a.obtainToken()
+}
+
+// fetchOAuth2Token runs the refresh token grant if a refresh token is
+// available, otherwise the client credentials grant.
+// Caller must hold a.mu.
+func (a *Authenticator) fetchOAuth2Token() error {
+	auth := a.config.Auth
+	if auth == nil || auth.TokenURL == "" {
+		return fmt.Errorf("OAuth2 token URL is not configured")
+	}
+
+	form := url.Values{}
+	refreshToken := a.refreshToken
+	if refreshToken == "" {
+		refreshToken = auth.RefreshToken
+	}
+	if refreshToken != "" {
+		form.Set("grant_type", "refresh_token")
+		form.Set("refresh_token", refreshToken)
+	} else {
+		form.Set("grant_type", "client_credentials")
+	}
+	if len(auth.Scopes) > 0 {
+		form.Set("scope", strings.Join(auth.Scopes, " "))
+	}
+
+	req, err := http.NewRequest("POST", auth.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", a.config.UserAgent)
+	if auth.ClientID != "" {
+		// Client credentials are form-encoded before basic auth (RFC 6749 2.3.1)
+		req.SetBasicAuth(url.QueryEscape(auth.ClientID), url.QueryEscape(auth.ClientSecret))
+	}
+
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var tok oauth2TokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tok); err != nil {
+		return fmt.Errorf("invalid token response (status %d): %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK || tok.Error != "" {
+		if tok.Error == "" {
+			return fmt.Errorf("token request failed with status %d", resp.StatusCode)
+		}
+		return fmt.Errorf("token request failed: %s %s", tok.Error, tok.ErrorDescription)
+	}
+	if tok.AccessToken == "" {
+		return fmt.Errorf("token response contains no access token")
+	}
+
+	a.token = tok.AccessToken
+	a.tokenExpiry = time.Time{}
+	if tok.ExpiresIn > 0 {
+		a.tokenExpiry = time.Now().Add(time.Duration(tok.ExpiresIn) * time.Second)
+	}
+	// Servers may rotate refresh tokens
+	if tok.RefreshToken != "" {
+		a.refreshToken = tok.RefreshToken
+	}
+
+	return nil
+}
+
+// runTokenCommand runs the configured command and uses its trimmed stdout
+// as the bearer token.
+// Caller must hold a.mu.
+func (a *Authenticator) runTokenCommand() error {
+	if a.config.Auth == nil || len(a.config.Auth.TokenCommand) == 0 {
+		return fmt.Errorf("token command is not configured")
+	}
+	args := a.config.Auth.TokenCommand
+
+	timeout := a.config.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("token command failed: %w: %s", err, msg)
+		}
+		return fmt.Errorf("token command failed: %w", err)
+	}
+
+	token := strings.TrimSpace(stdout.String())
+	if token == "" {
+		return fmt.Errorf("token command printed no token")
+	}
+
+	a.token = token
+	a.tokenExpiry = time.Time{}
+	return nil
+}
+
+// renewRejectedToken obtains a new token after the server rejected the one
+// sent with req. If another request already renewed it, nothing is done.
+func (a *Authenticator) renewRejectedToken(req *http.Request) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if req != nil && req.Header.Get("Authorization") != "Bearer "+a.token {
+		return a.token != ""
+	}
+	return a.obtainToken() == nil
+}
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"os"
 	"regexp"
+	"sort"
+	"strings"
 	"time"
 )
 
@@ -45,6 +47,25 @@ const (
 	WaitSelector         WaitCondition = "selector"         // Wait for specific selector
 )
 
+// NetworkThrottling defines the network profile emulated while rendering.
+type NetworkThrottling string
+
+const (
+	ThrottleNone   NetworkThrottling = ""       // No network throttling
+	ThrottleSlow4G NetworkThrottling = "slow4g" // Lighthouse mobile profile (150ms RTT, 1.6 Mbps)
+	ThrottleFast3G NetworkThrottling = "fast3g" // DevTools Fast 3G (562.5ms RTT, 1.44 Mbps)
+	ThrottleSlow3G NetworkThrottling = "slow3g" // DevTools Slow 3G (2s RTT, 400 Kbps)
+)
+
+// ScreenshotMode defines which part of a rendered page is captured.
+type ScreenshotMode string
+
+const (
+	ScreenshotOff      ScreenshotMode = ""         // No screenshots
+	ScreenshotViewport ScreenshotMode = "viewport" // Above the fold only
+	ScreenshotFullPage ScreenshotMode = "full"     // Entire scrollable page
+)
+
 // AuthType defines authentication method.
 type AuthType string
 
@@ -54,6 +75,22 @@ const (
 	AuthBearer AuthType = "bearer" // Bearer token
 	AuthCookie AuthType = "cookie" // Cookie-based
 	AuthForm   AuthType = "form"   // Form login
+	AuthMacro  AuthType = "macro"  // Scripted browser login (LoginMacro)
+	AuthOAuth2 AuthType = "oauth2" // OAuth2 client credentials or refresh token
+	AuthDigest AuthType = "digest" // HTTP digest authentication
+	AuthExec   AuthType = "exec"   // Bearer token printed by a local command
+)
+
+// LoginAction defines a login macro step.
+type LoginAction string
+
+const (
+	LoginNavigate      LoginAction = "navigate"       // Open Value as URL
+	LoginType          LoginAction = "type"           // Type Value into Selector
+	LoginClick         LoginAction = "click"          // Click Selector
+	LoginWaitSelector  LoginAction = "wait_selector"  // Wait until Selector is visible
+	LoginWaitURL       LoginAction = "wait_url"       // Wait until the URL contains Value
+	LoginExtractCookie LoginAction = "extract_cookie" // Require cookie named Value
 )
 
 // CrawlConfig holds all configuration for a crawl session.
@@ -100,6 +137,23 @@ type CrawlConfig struct {
 	// Crawl duration limit (0 = unlimited)
 	CrawlDuration time.Duration `json:"crawl_duration"`
 
+	// Per-folder or per-template limits; the first matching rule applies
+	QuotaRules []*QuotaRule `json:"quota_rules,omitempty"`
+
+	// === Crawl Traps ===
+
+	// Detect URL patterns that look like crawl traps (calendars, facets, loops)
+	DetectTraps bool `json:"detect_traps"`
+
+	// Minimum interval between crawls of URLs of one detected trap pattern
+	TrapDelay time.Duration `json:"trap_delay"`
+
+	// Stop queueing URLs of a trap pattern once TrapQuota is reached
+	TrapAutoExclude bool `json:"trap_auto_exclude"`
+
+	// URLs queued per trap pattern after detection when auto-excluding
+	TrapQuota int `json:"trap_quota"`
+
 	// === Speed & Concurrency ===
 
 	// Maximum requests per second (0 = unlimited)
@@ -148,6 +202,58 @@ type CrawlConfig struct {
 	// Chromium executable path (empty = bundled)
 	ChromiumPath string `json:"chromium_path"`
 
+	// Record network activity during rendering for HAR export
+	CaptureHAR bool `json:"capture_har"`
+
+	// Keep Authorization and cookie headers in HAR exports (redacted by default)
+	HARIncludeCredentials bool `json:"har_include_credentials"`
+
+	// CPU slowdown multiplier for lab Web Vitals (0 or 1 = no throttling)
+	CPUThrottlingRate float64 `json:"cpu_throttling_rate"`
+
+	// Network profile for lab Web Vitals: "", slow4g, fast3g, slow3g
+	NetworkThrottling NetworkThrottling `json:"network_throttling"`
+
+	// Scroll to the bottom after load to trigger lazy loading and infinite scroll
+	ScrollPage bool `json:"scroll_page"`
+
+	// Pixels per scroll step (0 = one viewport height)
+	ScrollStep int `json:"scroll_step"`
+
+	// Maximum number of scroll steps
+	MaxScrolls int `json:"max_scrolls"`
+
+	// Time to wait after each step for new content
+	ScrollIdleWait time.Duration `json:"scroll_idle_wait"`
+
+	// Selector of a "load more" button clicked after each step (optional)
+	LoadMoreSelector string `json:"load_more_selector"`
+
+	// Discover SPA routes from pushState/replaceState and router attributes
+	DiscoverJSRoutes bool `json:"discover_js_routes"`
+
+	// Screenshot capture while rendering: "", viewport, full
+	Screenshots ScreenshotMode `json:"screenshots"`
+
+	// JPEG quality for screenshots (1-100)
+	ScreenshotQuality int `json:"screenshot_quality"`
+
+	// Directory where screenshots are stored, in a subdirectory per crawl
+	ScreenshotDir string `json:"screenshot_dir"`
+
+	// === Device Emulation ===
+
+	// Device profile to crawl as (empty = no emulation, see DeviceProfiles)
+	Device string `json:"device"`
+
+	// Second device profile for dual crawls (empty = disabled). Each URL is
+	// also fetched with this profile and compared for content parity.
+	CompareDevice string `json:"compare_device"`
+
+	// Device profiles by name. Defaults to DefaultDeviceProfiles; profiles
+	// in a config file are added to them, replacing any with the same name.
+	DeviceProfiles map[string]*DeviceProfile `json:"device_profiles"`
+
 	// === Authentication (5.4) ===
 
 	// Authentication type
@@ -159,9 +265,20 @@ type CrawlConfig struct {
 	// Custom headers to inject
 	CustomHeaders map[string]string `json:"custom_headers,omitempty"`
 
+	// Host patterns CustomHeaders are sent to (empty = all hosts, but
+	// credential headers such as Authorization only go to the seed hosts)
+	CustomHeaderHosts []string `json:"custom_header_hosts,omitempty"`
+
 	// Cookies to use
 	Cookies []*CookieConfig `json:"cookies,omitempty"`
 
+	// Netscape cookies.txt or browser-extension JSON file to import
+	CookieFile string `json:"cookie_file,omitempty"`
+
+	// Credentials, headers and cookies bound to other hosts. The first
+	// matching entry wins over Auth.
+	HostAuth []*HostCredentials `json:"host_auth,omitempty"`
+
 	// === Robots & Nofollow (5.5) ===
 
 	// Respect robots.txt
@@ -178,6 +295,9 @@ type CrawlConfig struct {
 
 	// === URL Normalization ===
 
+	// Regex find/replace rules applied in order before normalization
+	RewriteRules []*RewriteRule `json:"rewrite_rules,omitempty"`
+
 	// Query parameters to ignore (utm_*, gclid, etc.)
 	IgnoreQueryParams []string `json:"ignore_query_params"`
 
@@ -198,6 +318,24 @@ type CrawlConfig struct {
 	// File extensions to exclude
 	ExcludeExtensions []string `json:"exclude_extensions,omitempty"`
 
+	// === Content Analysis ===
+
+	// Minimum similarity (0-1) for pages to be near duplicates
+	NearDuplicateThreshold float64 `json:"near_duplicate_threshold"`
+
+	// Flesch Reading Ease (0-100) below which content is difficult to read
+	DifficultReadingEase float64 `json:"difficult_reading_ease"`
+
+	// Flesch-Kincaid grade level above which English content is difficult to read
+	DifficultGradeLevel float64 `json:"difficult_grade_level"`
+
+	// CSS selectors of the main content area (empty = detect automatically).
+	// They are validated when the analyzers are configured.
+	ContentAreaSelectors []string `json:"content_area_selectors,omitempty"`
+
+	// CSS selectors of elements to leave out of the main content
+	ContentExcludeSelectors []string `json:"content_exclude_selectors,omitempty"`
+
 	// === Storage ===
 
 	// Store raw HTML in database
@@ -209,6 +347,14 @@ type CrawlConfig struct {
 	// === Compiled patterns (not serialized) ===
 	compiledIncludes []*regexp.Regexp
 	compiledExcludes []*regexp.Regexp
+	compiledRewrites []compiledRewrite
+}
+
+// compiledRewrite pairs a rewrite rule with its compiled pattern.
+type compiledRewrite struct {
+	re      *regexp.Regexp
+	pattern string
+	replace string
 }
 
 // AuthConfig holds authentication credentials.
@@ -223,6 +369,75 @@ type AuthConfig struct {
 	FormFields  map[string]string `json:"form_fields,omitempty"`
 	SuccessURL  string            `json:"success_url,omitempty"`
 	SuccessText string            `json:"success_text,omitempty"`
+
+	// Host patterns the credentials are sent to (see urlutil.MatchHost).
+	// Empty means the seed hosts; without seeds credentials are not sent.
+	Hosts []string `json:"hosts,omitempty"`
+
+	// Macro login: steps run in a headless browser
+	LoginMacro []*LoginStep `json:"login_macro,omitempty"`
+
+	// OAuth2: client credentials grant, or refresh token grant if RefreshToken is set
+	TokenURL     string   `json:"token_url,omitempty"`
+	ClientID     string   `json:"client_id,omitempty"`
+	ClientSecret string   `json:"client_secret,omitempty"`
+	Scopes       []string `json:"scopes,omitempty"`
+	RefreshToken string   `json:"refresh_token,omitempty"`
+
+	// Exec token provider: command and arguments; stdout is the bearer token
+	TokenCommand []string `json:"token_command,omitempty"`
+
+	// Logout detection: any match means the session expired
+	LogoutURL         string `json:"logout_url,omitempty"`          // Regex on the final URL (default: login URL prefix)
+	LogoutText        string `json:"logout_text,omitempty"`         // Marker text in the response body
+	LogoutStatusCodes []int  `json:"logout_status_codes,omitempty"` // Default: 401
+}
+
+// HostCredentials binds authentication, headers and cookies to host patterns.
+// They are never sent to other hosts.
+type HostCredentials struct {
+	Hosts    []string          `json:"hosts"`
+	AuthType AuthType          `json:"auth_type,omitempty"`
+	Auth     *AuthConfig       `json:"auth,omitempty"`
+	Headers  map[string]string `json:"headers,omitempty"`
+	Cookies  map[string]string `json:"cookies,omitempty"` // name -> value
+}
+
+// LoginStep is a single step of a login macro.
+type LoginStep struct {
+	Action   LoginAction   `json:"action"`
+	Selector string        `json:"selector,omitempty"`
+	Value    string        `json:"value,omitempty"`
+	Timeout  time.Duration `json:"timeout,omitempty"` // 0 = RenderTimeout
+}
+
+// QuotaRule limits the URLs queued from a section of the site. URLs are
+// matched by path prefix or by a regex on the full URL.
+type QuotaRule struct {
+	Name     string `json:"name,omitempty"`
+	Prefix   string `json:"prefix,omitempty"`  // e.g. "/products/"
+	Pattern  string `json:"pattern,omitempty"` // e.g. "/p/[0-9]+$"
+	MaxURLs  int    `json:"max_urls"`          // 0 = unlimited
+	MaxDepth int    `json:"max_depth"`         // 0 = MaxDepth
+}
+
+// Label returns the rule name, or its prefix or pattern.
+func (q *QuotaRule) Label() string {
+	switch {
+	case q.Name != "":
+		return q.Name
+	case q.Prefix != "":
+		return q.Prefix
+	default:
+		return q.Pattern
+	}
+}
+
+// RewriteRule replaces matches of Pattern (regex) in a URL with Replace,
+// which may reference capture groups as $1 or ${name}.
+type RewriteRule struct {
+	Pattern string `json:"pattern"`
+	Replace string `json:"replace"`
 }
 
 // CookieConfig holds cookie information.
@@ -235,6 +450,17 @@ type CookieConfig struct {
 	HttpOnly bool   `json:"http_only"`
 }
 
+// DeviceProfile describes an emulated device.
+type DeviceProfile struct {
+	Name              string  `json:"name"`
+	Width             int64   `json:"width"`
+	Height            int64   `json:"height"`
+	DeviceScaleFactor float64 `json:"device_scale_factor"`
+	Mobile            bool    `json:"mobile"`
+	Touch             bool    `json:"touch"`
+	UserAgent         string  `json:"user_agent"`
+}
+
 // DefaultConfig returns a CrawlConfig with sensible defaults.
 func DefaultConfig() *CrawlConfig {
 	return &CrawlConfig{
@@ -253,6 +479,12 @@ func DefaultConfig() *CrawlConfig {
 		MaxResponseSize: 10 * 1024 * 1024, // 10MB
 		CrawlDuration:   0, // unlimited
 
+		// Crawl Traps
+		DetectTraps:     true,
+		TrapDelay:       2 * time.Second,
+		TrapAutoExclude: false,
+		TrapQuota:       100,
+
 		// Speed & Concurrency
 		RequestsPerSecond: 10,
 		Concurrency:       5,
@@ -267,9 +499,16 @@ func DefaultConfig() *CrawlConfig {
 		RedirectPolicy: RedirectFollow,
 
 		// Rendering
-		RenderMode:    RenderHTML,
-		RenderTimeout: 30 * time.Second,
-		WaitCondition: WaitDOMContentLoaded,
+		RenderMode:        RenderHTML,
+		RenderTimeout:     30 * time.Second,
+		WaitCondition:     WaitDOMContentLoaded,
+		MaxScrolls:        20,
+		ScrollIdleWait:    500 * time.Millisecond,
+		ScreenshotQuality: 80,
+		ScreenshotDir:     "screenshots",
+
+		// Device Emulation
+		DeviceProfiles: defaultDeviceProfiles(),
 
 		// Authentication
 		AuthType: AuthNone,
@@ -298,6 +537,11 @@ func DefaultConfig() *CrawlConfig {
 			".css", ".js", ".woff", ".woff2", ".ttf", ".eot",
 		},
 
+		// Content Analysis
+		NearDuplicateThreshold: 0.9,
+		DifficultReadingEase:   50,
+		DifficultGradeLevel:    12,
+
 		// Storage
 		StoreHTML:    true,
 		StoreHeaders: true,
@@ -318,9 +562,78 @@ func (c *CrawlConfig) Validate() error {
 	if c.MaxRedirects < 0 {
 		c.MaxRedirects = 0
 	}
+	if c.TrapQuota < 0 {
+		c.TrapQuota = 0
+	}
+	for i, rule := range c.QuotaRules {
+		if rule.Prefix == "" && rule.Pattern == "" {
+			return fmt.Errorf("quota rule %d: prefix or pattern is required", i+1)
+		}
+		if rule.Prefix != "" && rule.Pattern != "" {
+			return fmt.Errorf("quota rule %d: set either prefix or pattern", i+1)
+		}
+		if rule.Pattern != "" {
+			if _, err := regexp.Compile(rule.Pattern); err != nil {
+				return fmt.Errorf("invalid quota pattern '%s': %w", rule.Pattern, err)
+			}
+		}
+		if rule.MaxURLs < 0 || rule.MaxDepth < 0 {
+			return fmt.Errorf("quota rule %d: limits must not be negative", i+1)
+		}
+	}
 	if c.RenderTimeout < time.Second {
 		c.RenderTimeout = time.Second
 	}
+	switch c.NetworkThrottling {
+	case ThrottleNone, ThrottleSlow4G, ThrottleFast3G, ThrottleSlow3G:
+	default:
+		return fmt.Errorf("unknown network throttling profile: %s", c.NetworkThrottling)
+	}
+	if c.CPUThrottlingRate < 1 {
+		c.CPUThrottlingRate = 1
+	}
+	if c.MaxScrolls < 1 {
+		c.MaxScrolls = 20
+	}
+	if c.NearDuplicateThreshold <= 0 || c.NearDuplicateThreshold > 1 {
+		c.NearDuplicateThreshold = 0.9
+	}
+	if c.DifficultReadingEase <= 0 || c.DifficultReadingEase > 100 {
+		c.DifficultReadingEase = 50
+	}
+	if c.DifficultGradeLevel <= 0 {
+		c.DifficultGradeLevel = 12
+	}
+	if c.ScreenshotQuality < 1 || c.ScreenshotQuality > 100 {
+		c.ScreenshotQuality = 80
+	}
+	for name, profile := range c.DeviceProfiles {
+		if profile == nil || profile.Width <= 0 || profile.Height <= 0 {
+			return fmt.Errorf("device profile %s: width and height are required", name)
+		}
+		profile.Name = name
+		if profile.DeviceScaleFactor <= 0 {
+			profile.DeviceScaleFactor = 1
+		}
+	}
+	for _, name := range []string{c.Device, c.CompareDevice} {
+		if name == "" {
+			continue
+		}
+		if _, ok := c.LookupDevice(name); !ok {
+			return fmt.Errorf("unknown device profile: %s", name)
+		}
+	}
+	for i, hc := range c.HostAuth {
+		if len(hc.Hosts) == 0 {
+			return fmt.Errorf("host_auth entry %d has no hosts", i+1)
+		}
+	}
+	if c.Auth != nil && c.Auth.LogoutURL != "" {
+		if _, err := regexp.Compile(c.Auth.LogoutURL); err != nil {
+			return fmt.Errorf("invalid logout URL pattern '%s': %w", c.Auth.LogoutURL, err)
+		}
+	}
 	return nil
 }
 
@@ -345,9 +658,85 @@ func (c *CrawlConfig) CompilePatterns() error {
 		c.compiledExcludes = append(c.compiledExcludes, re)
 	}
 
+	c.compiledRewrites = make([]compiledRewrite, 0, len(c.RewriteRules))
+	for _, rule := range c.RewriteRules {
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid rewrite pattern '%s': %w", rule.Pattern, err)
+		}
+		c.compiledRewrites = append(c.compiledRewrites, compiledRewrite{re, rule.Pattern, rule.Replace})
+	}
+
 	return nil
 }
 
+// RewriteURL applies the rewrite rules to a URL in order. It returns the
+// rewritten URL and the patterns of the rules that changed it.
+func (c *CrawlConfig) RewriteURL(urlStr string) (string, []string) {
+	var applied []string
+	for _, rule := range c.compiledRewrites {
+		rewritten := rule.re.ReplaceAllString(urlStr, rule.replace)
+		if rewritten != urlStr {
+			applied = append(applied, rule.pattern)
+			urlStr = rewritten
+		}
+	}
+	return urlStr, applied
+}
+
+// Clone creates a deep copy of the auth configuration.
+func (a *AuthConfig) Clone() *AuthConfig {
+	authCopy := *a
+	if a.FormFields != nil {
+		authCopy.FormFields = make(map[string]string)
+		for k, v := range a.FormFields {
+			authCopy.FormFields[k] = v
+		}
+	}
+	if a.LoginMacro != nil {
+		authCopy.LoginMacro = make([]*LoginStep, len(a.LoginMacro))
+		for i, step := range a.LoginMacro {
+			stepCopy := *step
+			authCopy.LoginMacro[i] = &stepCopy
+		}
+	}
+	if a.Hosts != nil {
+		authCopy.Hosts = append([]string(nil), a.Hosts...)
+	}
+	if a.Scopes != nil {
+		authCopy.Scopes = append([]string(nil), a.Scopes...)
+	}
+	if a.TokenCommand != nil {
+		authCopy.TokenCommand = append([]string(nil), a.TokenCommand...)
+	}
+	if a.LogoutStatusCodes != nil {
+		authCopy.LogoutStatusCodes = append([]int(nil), a.LogoutStatusCodes...)
+	}
+	return &authCopy
+}
+
+// Clone creates a deep copy of the host credentials.
+func (h *HostCredentials) Clone() *HostCredentials {
+	hcCopy := *h
+	hcCopy.Hosts = append([]string(nil), h.Hosts...)
+	if h.Auth != nil {
+		hcCopy.Auth = h.Auth.Clone()
+	}
+	if h.Headers != nil {
+		hcCopy.Headers = make(map[string]string)
+		for k, v := range h.Headers {
+			hcCopy.Headers[k] = v
+		}
+	}
+	if h.Cookies != nil {
+		hcCopy.Cookies = make(map[string]string)
+		for k, v := range h.Cookies {
+			hcCopy.Cookies[k] = v
+		}
+	}
+	return &hcCopy
+}
+
 // ShouldCrawl checks if a URL should be crawled based on include/exclude patterns.
 func (c *CrawlConfig) ShouldCrawl(urlStr string) bool {
 	// Check exclude patterns first
@@ -396,6 +785,92 @@ func (c *CrawlConfig) Save(filePath string) error {
 	return nil
 }
 
+// SecretRefPrefix marks config values stored in the secrets vault.
+const SecretRefPrefix = "secret://"
+
+// secretFields calls fn for every credential-bearing string in the config.
+func (c *CrawlConfig) secretFields(fn func(value *string) error) error {
+	authFields := func(a *AuthConfig) error {
+		if a == nil {
+			return nil
+		}
+		for _, f := range []*string{&a.Username, &a.Password, &a.Token, &a.ClientID, &a.ClientSecret, &a.RefreshToken} {
+			if err := fn(f); err != nil {
+				return err
+			}
+		}
+		for _, step := range a.LoginMacro {
+			if err := fn(&step.Value); err != nil {
+				return err
+			}
+		}
+		return mapFields(a.FormFields, fn)
+	}
+
+	if err := authFields(c.Auth); err != nil {
+		return err
+	}
+	if err := mapFields(c.CustomHeaders, fn); err != nil {
+		return err
+	}
+	for _, cookie := range c.Cookies {
+		if err := fn(&cookie.Value); err != nil {
+			return err
+		}
+	}
+	for _, hc := range c.HostAuth {
+		if err := authFields(hc.Auth); err != nil {
+			return err
+		}
+		if err := mapFields(hc.Headers, fn); err != nil {
+			return err
+		}
+		if err := mapFields(hc.Cookies, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func mapFields(m map[string]string, fn func(value *string) error) error {
+	for k, v := range m {
+		value := v
+		if err := fn(&value); err != nil {
+			return err
+		}
+		m[k] = value
+	}
+	return nil
+}
+
+// HasSecretRefs reports whether any credential is a secret:// reference.
+func (c *CrawlConfig) HasSecretRefs() bool {
+	found := false
+	c.secretFields(func(value *string) error {
+		if strings.HasPrefix(*value, SecretRefPrefix) {
+			found = true
+		}
+		return nil
+	})
+	return found
+}
+
+// ResolveSecrets replaces secret:// references with the values returned by
+// resolve. Call it on a loaded config at crawl start; never Save the result.
+func (c *CrawlConfig) ResolveSecrets(resolve func(ref string) (string, error)) error {
+	return c.secretFields(func(value *string) error {
+		if !strings.HasPrefix(*value, SecretRefPrefix) {
+			return nil
+		}
+		resolved, err := resolve(*value)
+		if err != nil {
+			return fmt.Errorf("failed to resolve %s: %w", *value, err)
+		}
+		*value = resolved
+		return nil
+	})
+}
+
 // Load loads configuration from a JSON file.
 func Load(filePath string) (*CrawlConfig, error) {
 	data, err := os.ReadFile(filePath)
@@ -439,6 +914,12 @@ func (c *CrawlConfig) Clone() *CrawlConfig {
 	clone.ExcludeExtensions = make([]string, len(c.ExcludeExtensions))
 	copy(clone.ExcludeExtensions, c.ExcludeExtensions)
 
+	clone.ContentAreaSelectors = make([]string, len(c.ContentAreaSelectors))
+	copy(clone.ContentAreaSelectors, c.ContentAreaSelectors)
+
+	clone.ContentExcludeSelectors = make([]string, len(c.ContentExcludeSelectors))
+	copy(clone.ContentExcludeSelectors, c.ContentExcludeSelectors)
+
 	// Deep copy maps
 	if c.CustomHeaders != nil {
 		clone.CustomHeaders = make(map[string]string)
@@ -458,14 +939,40 @@ func (c *CrawlConfig) Clone() *CrawlConfig {
 
 	// Deep copy auth
 	if c.Auth != nil {
-		authCopy := *c.Auth
-		if c.Auth.FormFields != nil {
-			authCopy.FormFields = make(map[string]string)
-			for k, v := range c.Auth.FormFields {
-				authCopy.FormFields[k] = v
-			}
+		clone.Auth = c.Auth.Clone()
+	}
+
+	if c.QuotaRules != nil {
+		clone.QuotaRules = make([]*QuotaRule, len(c.QuotaRules))
+		for i, rule := range c.QuotaRules {
+			ruleCopy := *rule
+			clone.QuotaRules[i] = &ruleCopy
+		}
+	}
+
+	if c.RewriteRules != nil {
+		clone.RewriteRules = make([]*RewriteRule, len(c.RewriteRules))
+		for i, rule := range c.RewriteRules {
+			ruleCopy := *rule
+			clone.RewriteRules[i] = &ruleCopy
+		}
+	}
+
+	if c.CustomHeaderHosts != nil {
+		clone.CustomHeaderHosts = append([]string(nil), c.CustomHeaderHosts...)
+	}
+	if c.HostAuth != nil {
+		clone.HostAuth = make([]*HostCredentials, len(c.HostAuth))
+		for i, hc := range c.HostAuth {
+			clone.HostAuth[i] = hc.Clone()
+		}
+	}
+	if c.DeviceProfiles != nil {
+		clone.DeviceProfiles = make(map[string]*DeviceProfile, len(c.DeviceProfiles))
+		for name, profile := range c.DeviceProfiles {
+			profileCopy := *profile
+			clone.DeviceProfiles[name] = &profileCopy
 		}
-		clone.Auth = &authCopy
 	}
 
 	return &clone
@@ -529,3 +1036,104 @@ var (
 		StoreHeaders:      true,
 	}
 )
+
+// Device profile names
+const (
+	DeviceDesktop             = "desktop"
+	DeviceGooglebotSmartphone = "googlebot-smartphone"
+	DeviceGooglebotDesktop    = "googlebot-desktop"
+	DeviceIPhone              = "iphone"
+)
+
+// DefaultDeviceProfiles holds the built-in device profiles by name.
+var DefaultDeviceProfiles = map[string]*DeviceProfile{
+	DeviceDesktop: {
+		Name:              DeviceDesktop,
+		Width:             1920,
+		Height:            1080,
+		DeviceScaleFactor: 1,
+		UserAgent:         "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+	},
+	DeviceGooglebotSmartphone: {
+		Name:              DeviceGooglebotSmartphone,
+		Width:             412,
+		Height:            732,
+		DeviceScaleFactor: 2.625,
+		Mobile:            true,
+		Touch:             true,
+		UserAgent:         "Mozilla/5.0 (Linux; Android 6.0.1; Nexus 5X Build/MMB29P) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Mobile Safari/537.36 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+	},
+	DeviceGooglebotDesktop: {
+		Name:              DeviceGooglebotDesktop,
+		Width:             1920,
+		Height:            1080,
+		DeviceScaleFactor: 1,
+		UserAgent:         "Mozilla/5.0 AppleWebKit/537.36 (KHTML, like Gecko; compatible; Googlebot/2.1; +http://www.google.com/bot.html) Chrome/120.0.0.0 Safari/537.36",
+	},
+	DeviceIPhone: {
+		Name:              DeviceIPhone,
+		Width:             390,
+		Height:            844,
+		DeviceScaleFactor: 3,
+		Mobile:            true,
+		Touch:             true,
+		UserAgent:         "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Mobile/15E148 Safari/604.1",
+	},
+}
+
+// defaultDeviceProfiles returns a copy of the built-in device profiles.
+func defaultDeviceProfiles() map[string]*DeviceProfile {
+	profiles := make(map[string]*DeviceProfile, len(DefaultDeviceProfiles))
+	for name, profile := range DefaultDeviceProfiles {
+		profileCopy := *profile
+		profiles[name] = &profileCopy
+	}
+	return profiles
+}
+
+// LookupDevice returns the named device profile. Configs not created by
+// DefaultConfig fall back to the built-in profiles.
+func (c *CrawlConfig) LookupDevice(name string) (*DeviceProfile, bool) {
+	if profile, ok := c.DeviceProfiles[name]; ok {
+		return profile, true
+	}
+	if c.DeviceProfiles == nil {
+		profile, ok := DefaultDeviceProfiles[name]
+		return profile, ok
+	}
+	return nil, false
+}
+
+// DeviceNames returns the sorted names of the available device profiles.
+func (c *CrawlConfig) DeviceNames() []string {
+	profiles := c.DeviceProfiles
+	if profiles == nil {
+		profiles = DefaultDeviceProfiles
+	}
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DeviceProfile returns the configured device profile, or nil if none.
+func (c *CrawlConfig) DeviceProfile() *DeviceProfile {
+	profile, _ := c.LookupDevice(c.Device)
+	return profile
+}
+
+// ForDevice returns a copy of the config that crawls as the named device.
+// The device user agent replaces the configured one.
+func (c *CrawlConfig) ForDevice(name string) (*CrawlConfig, error) {
+	profile, ok := c.LookupDevice(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown device profile: %s", name)
+	}
+	clone := c.Clone()
+	clone.Device = name
+	clone.CompareDevice = ""
+	clone.UserAgent = profile.UserAgent
+	return clone, nil
+}
@@ -0,0 +1,75 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Patch is a partial configuration generated from crawl analysis, such as
+// the query parameter recommendations. Its fields use the CrawlConfig JSON
+// names, and applying it adds to the existing lists.
+type Patch struct {
+	IgnoreQueryParams []string `json:"ignore_query_params,omitempty"`
+	ExcludePatterns   []string `json:"exclude_patterns,omitempty"`
+}
+
+// LoadPatch reads a patch from a JSON file.
+func LoadPatch(filePath string) (*Patch, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read patch file: %w", err)
+	}
+
+	patch := &Patch{}
+	if err := json.Unmarshal(data, patch); err != nil {
+		return nil, fmt.Errorf("failed to parse patch file: %w", err)
+	}
+	return patch, nil
+}
+
+// Save writes the patch to a JSON file. Regex characters such as "&" are
+// written unescaped so that the file can be reviewed by hand.
+func (p *Patch) Save(filePath string) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(p); err != nil {
+		return fmt.Errorf("failed to marshal patch: %w", err)
+	}
+
+	if err := os.WriteFile(filePath, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write patch file: %w", err)
+	}
+	return nil
+}
+
+// ApplyPatch adds the patch entries missing from the configuration and
+// recompiles its patterns. It returns the number of entries added.
+func (c *CrawlConfig) ApplyPatch(p *Patch) (int, error) {
+	added := 0
+	c.IgnoreQueryParams, added = appendMissing(c.IgnoreQueryParams, p.IgnoreQueryParams, added)
+	c.ExcludePatterns, added = appendMissing(c.ExcludePatterns, p.ExcludePatterns, added)
+
+	if err := c.CompilePatterns(); err != nil {
+		return added, err
+	}
+	return added, nil
+}
+
+func appendMissing(list, values []string, added int) ([]string, int) {
+	seen := make(map[string]bool, len(list))
+	for _, v := range list {
+		seen[v] = true
+	}
+	for _, v := range values {
+		if !seen[v] {
+			list = append(list, v)
+			seen[v] = true
+			added++
+		}
+	}
+	return list, added
+}
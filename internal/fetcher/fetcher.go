@@ -21,6 +21,23 @@ type Fetcher struct {
 	config       *config.CrawlConfig
 	maxBodySize  int64
 	transport    *http.Transport
+	session      SessionHandler
+	authorizer   Authorizer
+}
+
+// Authorizer adds credentials to requests and reacts to 401 responses.
+// It is implemented by auth.Authenticator.
+type Authorizer interface {
+	ApplyAuth(req *http.Request)
+	// HandleUnauthorized reports whether the request should be retried
+	// with renewed credentials.
+	HandleUnauthorized(resp *http.Response) bool
+}
+
+// SessionHandler detects expired login sessions.
+// It is implemented by auth.Authenticator.
+type SessionHandler interface {
+	SessionExpired(requestURL string, statusCode int, finalURL string, body []byte) bool
 }
 
 // NewFetcher creates a new HTTP fetcher.
@@ -61,8 +78,19 @@ func NewFetcher(cfg *config.CrawlConfig) *Fetcher {
 	return f
 }
 
-// Fetch fetches a URL and returns the response.
+// Fetch fetches a URL and returns the response. If a session handler is
+// set, responses showing an expired login are flagged SessionExpired; the
+// scheduler logs in again and requeues the URL.
 func (f *Fetcher) Fetch(ctx context.Context, rawURL string) *Response {
+	response := f.fetch(ctx, rawURL)
+	if f.session != nil && response.Error == nil {
+		response.SessionExpired = f.session.SessionExpired(rawURL, response.StatusCode, response.FinalURL, response.Body)
+	}
+	return response
+}
+
+// fetch performs a single fetch, following redirects.
+func (f *Fetcher) fetch(ctx context.Context, rawURL string) *Response {
 	startTime := time.Now()
 	response := &Response{
 		RequestURL:    rawURL,
@@ -71,6 +99,7 @@ func (f *Fetcher) Fetch(ctx context.Context, rawURL string) *Response {
 
 	currentURL := rawURL
 	var ttfbRecorded bool
+	authRetried := make(map[string]bool)
 
 	// Follow redirects manually to track the chain
 	for i := 0; i <= f.config.MaxRedirects; i++ {
@@ -83,6 +112,9 @@ func (f *Fetcher) Fetch(ctx context.Context, rawURL string) *Response {
 
 		// Set headers
 		f.setRequestHeaders(req)
+		if f.authorizer != nil {
+			f.authorizer.ApplyAuth(req)
+		}
 
 		// Make request
 		reqStart := time.Now()
@@ -100,6 +132,17 @@ func (f *Fetcher) Fetch(ctx context.Context, rawURL string) *Response {
 			ttfbRecorded = true
 		}
 
+		// Answer an authentication challenge once per URL
+		if resp.StatusCode == http.StatusUnauthorized && f.authorizer != nil && !authRetried[currentURL] {
+			authRetried[currentURL] = true
+			if f.authorizer.HandleUnauthorized(resp) {
+				io.Copy(io.Discard, resp.Body)
+				resp.Body.Close()
+				i--
+				continue
+			}
+		}
+
 		// Check if redirect
 		if resp.StatusCode >= 300 && resp.StatusCode < 400 {
 			location := resp.Header.Get("Location")
@@ -290,6 +333,22 @@ func (f *Fetcher) SetMaxBodySize(size int64) {
 	f.maxBodySize = size
 }
 
+// SetCookieJar sets the cookie jar used for requests, e.g. the jar of an
+// authenticator holding login session cookies.
+func (f *Fetcher) SetCookieJar(jar http.CookieJar) {
+	f.client.Jar = jar
+}
+
+// SetAuthorizer sets the credentials applied to every request.
+func (f *Fetcher) SetAuthorizer(a Authorizer) {
+	f.authorizer = a
+}
+
+// SetSessionHandler enables detection of expired login sessions.
+func (f *Fetcher) SetSessionHandler(h SessionHandler) {
+	f.session = h
+}
+
 // SetInsecureSkipVerify enables/disables TLS certificate verification.
 func (f *Fetcher) SetInsecureSkipVerify(skip bool) {
 	f.transport.TLSClientConfig.InsecureSkipVerify = skip
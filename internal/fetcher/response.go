@@ -52,6 +52,9 @@ type Response struct {
 
 	// Whether this response should be retried
 	Retryable bool
+
+	// The response was a logged-out page (see SessionHandler)
+	SessionExpired bool
 }
 
 // RedirectHop represents a single redirect in the chain.
@@ -0,0 +1,51 @@
+package frontier
+
+import (
+	"container/heap"
+	"time"
+
+	"github.com/spider-crawler/spider/internal/config"
+)
+
+// delayQueue holds URLs scheduled for later (retry backoff, throttled
+// crawl trap patterns), ordered by ScheduledAt. Keeping them out of the
+// main queue lets workers crawl other URLs in the meantime.
+type delayQueue []*URLItem
+
+func (q delayQueue) Len() int           { return len(q) }
+func (q delayQueue) Less(i, j int) bool { return q[i].ScheduledAt.Before(q[j].ScheduledAt) }
+func (q delayQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+
+func (q *delayQueue) Push(x interface{}) { *q = append(*q, x.(*URLItem)) }
+
+func (q *delayQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	old[len(old)-1] = nil
+	*q = old[:len(old)-1]
+	return item
+}
+
+// delayLocked holds back an item scheduled in the future and reports
+// whether it did.
+func (f *MemoryFrontier) delayLocked(item *URLItem) bool {
+	if !item.ScheduledAt.After(time.Now()) {
+		return false
+	}
+	heap.Push(&f.delayed, item)
+	return true
+}
+
+// releaseLocked moves the delayed items that are due to the front of the
+// queue.
+func (f *MemoryFrontier) releaseLocked() {
+	now := time.Now()
+	for len(f.delayed) > 0 && !f.delayed[0].ScheduledAt.After(now) {
+		item := heap.Pop(&f.delayed).(*URLItem)
+		if f.mode == config.DFS {
+			f.stack = append(f.stack, item)
+		} else {
+			f.queue.PushFront(item)
+		}
+	}
+}
@@ -44,6 +44,7 @@ type FrontierStats struct {
 	TotalAdded  int
 	Duplicates  int
 	DepthCounts map[int]int
+	Quotas      []QuotaUsage
 }
 
 // MemoryFrontier is an in-memory implementation of Frontier.
@@ -53,12 +54,15 @@ type MemoryFrontier struct {
 	stack         []*URLItem            // For DFS (LIFO)
 	visited       map[string]struct{}   // Set of visited normalized URLs
 	queued        map[string]struct{}   // Set of URLs currently in queue
+	delayed       delayQueue            // URLs scheduled for later
 	mode          config.TraversalMode
 	maxDepth      int
 	maxURLs       int
 	totalAdded    int
 	duplicates    int
 	depthCounts   map[int]int
+	quotas        []*quota
+	admit         func(*URLItem) bool
 }
 
 // NewMemoryFrontier creates a new in-memory frontier.
@@ -100,11 +104,26 @@ func (f *MemoryFrontier) Push(item *URLItem) bool {
 		return false
 	}
 
-	// Add to queue based on traversal mode
-	if f.mode == config.DFS {
-		f.stack = append(f.stack, item)
-	} else {
-		f.queue.PushBack(item)
+	// Check folder and template quotas, then the admission check
+	q, ok := f.quotaLocked(item)
+	if !ok {
+		return false
+	}
+	if f.admit != nil && !f.admit(item) {
+		return false
+	}
+	if q != nil {
+		q.usage.Queued++
+	}
+
+	// Add to queue based on traversal mode. URLs scheduled for later wait
+	// in the delay queue.
+	if !f.delayLocked(item) {
+		if f.mode == config.DFS {
+			f.stack = append(f.stack, item)
+		} else {
+			f.queue.PushBack(item)
+		}
 	}
 
 	f.queued[item.NormalizedURL] = struct{}{}
@@ -121,6 +140,7 @@ func (f *MemoryFrontier) Pop() *URLItem {
 
 	var item *URLItem
 
+	f.releaseLocked()
 	if f.mode == config.DFS {
 		if len(f.stack) == 0 {
 			return nil
@@ -166,9 +186,9 @@ func (f *MemoryFrontier) Size() int {
 	defer f.mu.RUnlock()
 
 	if f.mode == config.DFS {
-		return len(f.stack)
+		return len(f.stack) + len(f.delayed)
 	}
-	return f.queue.Len()
+	return f.queue.Len() + len(f.delayed)
 }
 
 // IsEmpty returns true if the frontier is empty.
@@ -219,6 +239,7 @@ func (f *MemoryFrontier) Stats() FrontierStats {
 	if f.mode == config.BFS {
 		size = f.queue.Len()
 	}
+	size += len(f.delayed)
 
 	return FrontierStats{
 		Queued:      size,
@@ -226,6 +247,7 @@ func (f *MemoryFrontier) Stats() FrontierStats {
 		TotalAdded:  f.totalAdded,
 		Duplicates:  f.duplicates,
 		DepthCounts: depthCounts,
+		Quotas:      f.quotaUsageLocked(),
 	}
 }
 
@@ -234,11 +256,23 @@ func (f *MemoryFrontier) Requeue(item *URLItem) {
 	f.mu.Lock()
 	defer f.mu.Unlock()
 
-	// For retries, always add to front/top for immediate retry
-	if f.mode == config.DFS {
-		f.stack = append(f.stack, item)
-	} else {
-		f.queue.PushFront(item)
+	// For retries, add to front/top for immediate retry, or to the delay
+	// queue until the backoff has passed
+	if !f.delayLocked(item) {
+		if f.mode == config.DFS {
+			f.stack = append(f.stack, item)
+		} else {
+			f.queue.PushFront(item)
+		}
 	}
 	f.queued[item.NormalizedURL] = struct{}{}
 }
+
+// SetAdmitFunc sets a final check for URLs that passed the depth, URL
+// limit, duplicate and quota checks of Push; URLs it rejects are not
+// queued. It runs with the frontier locked and must not call back into it.
+func (f *MemoryFrontier) SetAdmitFunc(fn func(*URLItem) bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.admit = fn
+}
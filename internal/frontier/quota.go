@@ -0,0 +1,114 @@
+package frontier
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/spider-crawler/spider/internal/config"
+)
+
+// QuotaUsage reports how a quota rule limited the crawl.
+type QuotaUsage struct {
+	Rule         string
+	MaxURLs      int
+	MaxDepth     int
+	Queued       int // URLs matching the rule that were queued
+	SkippedURLs  int // URLs dropped because MaxURLs was reached
+	SkippedDepth int // URLs dropped because they were deeper than MaxDepth
+}
+
+// Hit reports whether the rule caused any URL to be skipped.
+func (q QuotaUsage) Hit() bool {
+	return q.SkippedURLs > 0 || q.SkippedDepth > 0
+}
+
+// Skipped returns the number of URLs the rule dropped.
+func (q QuotaUsage) Skipped() int {
+	return q.SkippedURLs + q.SkippedDepth
+}
+
+// quota is a compiled quota rule with its counters.
+type quota struct {
+	rule    *config.QuotaRule
+	pattern *regexp.Regexp
+	usage   QuotaUsage
+}
+
+func (q *quota) matches(item *URLItem) bool {
+	if q.pattern != nil {
+		return q.pattern.MatchString(item.NormalizedURL)
+	}
+	u, err := url.Parse(item.NormalizedURL)
+	if err != nil {
+		return false
+	}
+	path := u.Path
+	if path == "" {
+		path = "/"
+	}
+	return strings.HasPrefix(path, q.rule.Prefix)
+}
+
+// SetQuotas installs the quota rules enforced by Push, replacing any
+// previous rules and their counters.
+func (f *MemoryFrontier) SetQuotas(rules []*config.QuotaRule) error {
+	quotas := make([]*quota, 0, len(rules))
+	for _, rule := range rules {
+		q := &quota{
+			rule: rule,
+			usage: QuotaUsage{
+				Rule:     rule.Label(),
+				MaxURLs:  rule.MaxURLs,
+				MaxDepth: rule.MaxDepth,
+			},
+		}
+		if rule.Pattern != "" {
+			re, err := regexp.Compile(rule.Pattern)
+			if err != nil {
+				return fmt.Errorf("invalid quota pattern '%s': %w", rule.Pattern, err)
+			}
+			q.pattern = re
+		}
+		quotas = append(quotas, q)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.quotas = quotas
+	return nil
+}
+
+// quotaLocked applies the first quota rule matching the item and reports
+// whether it may be queued. The caller counts the item against the
+// returned quota (nil if no rule matches) once it is queued.
+func (f *MemoryFrontier) quotaLocked(item *URLItem) (*quota, bool) {
+	for _, q := range f.quotas {
+		if !q.matches(item) {
+			continue
+		}
+		if q.rule.MaxDepth > 0 && item.Depth > q.rule.MaxDepth {
+			q.usage.SkippedDepth++
+			return nil, false
+		}
+		if q.rule.MaxURLs > 0 && q.usage.Queued >= q.rule.MaxURLs {
+			q.usage.SkippedURLs++
+			return nil, false
+		}
+		return q, true
+	}
+	return nil, true
+}
+
+// quotaUsageLocked returns a snapshot of the quota counters.
+func (f *MemoryFrontier) quotaUsageLocked() []QuotaUsage {
+	if len(f.quotas) == 0 {
+		return nil
+	}
+	usage := make([]QuotaUsage, len(f.quotas))
+	for i, q := range f.quotas {
+		usage[i] = q.usage
+	}
+	return usage
+}
@@ -20,6 +20,9 @@ type URLItem struct {
 	// Number of retry attempts
 	RetryCount int
 
+	// Number of times re-queued because the login session expired
+	SessionRetries int
+
 	// When this URL was added to the queue
 	AddedAt time.Time
 
@@ -31,6 +34,19 @@ type URLItem struct {
 
 	// Priority (lower = higher priority)
 	Priority int
+
+	// URL as discovered, if a rewrite rule changed it (empty otherwise)
+	OriginalURL string
+
+	// How the URL was discovered (e.g. "js_route"; empty for seeds and links)
+	LinkType string
+
+	// Whether the URL is inside the crawl scope (see urlutil.Scope)
+	IsInternal bool
+
+	// Device profile to fetch the URL as (empty = the configured device).
+	// Set on the second fetch of dual-device crawls.
+	Device string
 }
 
 // NewURLItem creates a new URLItem with the given URL.
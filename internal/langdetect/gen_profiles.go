@@ -0,0 +1,197 @@
+//go:build ignore
+
+// gen_profiles builds profiles.dat from the gettext catalogs of a system
+// locale directory, such as /usr/share/locale. The translations of each
+// language form its corpus; the English one is made of the source
+// strings.
+//
+//	go run gen_profiles.go -locale /usr/share/locale -o profiles.dat
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Profile settings, matching langdetect.go
+const (
+	profileSize = 2000 // Trigrams kept per profile
+	minWords    = 6    // Shorter strings are mostly labels and menu items
+)
+
+// Locale directories read for each profile.
+var languages = map[string][]string{
+	"cs": {"cs"}, "da": {"da"}, "de": {"de"}, "es": {"es"}, "fi": {"fi"},
+	"fr": {"fr"}, "hu": {"hu"}, "id": {"id"}, "it": {"it"}, "nl": {"nl"},
+	"no": {"nb", "nn", "no"}, "pl": {"pl"}, "pt": {"pt", "pt_BR"}, "ro": {"ro"},
+	"ru": {"ru"}, "sv": {"sv"}, "tr": {"tr"}, "uk": {"uk"},
+}
+
+// Markup left out of the corpus: format directives, tags, entities,
+// keyboard accelerators and command line options.
+var markup = regexp.MustCompile(`%[-+#0-9.$*lhqjzt]*[a-zA-Z%]|\$\{?\w+\}?|\{\w*\}|<[^>]*>|&\w+;|[_&](\pL)|--?[a-z][-a-z]*`)
+
+func main() {
+	locale := flag.String("locale", "/usr/share/locale", "Locale directory with gettext catalogs")
+	out := flag.String("o", "", "Output file (default standard output)")
+	flag.Parse()
+
+	w := new(bytes.Buffer)
+
+	langs := make([]string, 0, len(languages)+1)
+	for lang := range languages {
+		langs = append(langs, lang)
+	}
+	langs = append(langs, "en")
+	sort.Strings(langs)
+
+	fmt.Fprintf(w, "# Language profiles for langdetect: the %d most frequent character\n", profileSize)
+	fmt.Fprintln(w, "# trigrams of each language, most frequent first. Words are lowercased")
+	fmt.Fprintln(w, "# and padded with one space, written here as \"_\". Built by")
+	fmt.Fprintln(w, "# gen_profiles.go from the translations of the gettext catalogs of a")
+	fmt.Fprintln(w, "# Linux system (English from their source strings).")
+	fmt.Fprintln(w, "#")
+	fmt.Fprintln(w, "# Format: <language>\\t<trigram> <trigram> ...")
+
+	for _, lang := range langs {
+		counts := make(map[string]int)
+		if lang == "en" {
+			// Source strings are the same in every catalog; read one set
+			err := readCatalogs(filepath.Join(*locale, "de"), true, counts)
+			check(err)
+		} else {
+			for _, dir := range languages[lang] {
+				err := readCatalogs(filepath.Join(*locale, dir), false, counts)
+				check(err)
+			}
+		}
+		if len(counts) == 0 {
+			check(fmt.Errorf("no catalogs for %s in %s", lang, *locale))
+		}
+		fmt.Fprintf(w, "%s\t%s\n", lang, strings.Join(top(counts), " "))
+	}
+
+	if *out == "" {
+		_, err := os.Stdout.Write(w.Bytes())
+		check(err)
+		return
+	}
+	check(os.WriteFile(*out, w.Bytes(), 0644))
+}
+
+// readCatalogs adds the trigrams of the .mo catalogs in dir/LC_MESSAGES,
+// reading the source strings or the translations.
+func readCatalogs(dir string, sources bool, counts map[string]int) error {
+	files, err := filepath.Glob(filepath.Join(dir, "LC_MESSAGES", "*.mo"))
+	if err != nil {
+		return err
+	}
+	seen := make(map[string]bool)
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		for _, pair := range readMO(data) {
+			text := pair[1]
+			if sources {
+				text = pair[0]
+			}
+			// Untranslated strings are English
+			if pair[0] == "" || (!sources && pair[0] == pair[1]) || seen[text] {
+				continue
+			}
+			seen[text] = true
+			if len(strings.Fields(text)) < minWords {
+				continue
+			}
+			addTrigrams(markup.ReplaceAllString(text, "$1"), counts)
+		}
+	}
+	return nil
+}
+
+// readMO returns the source and translated strings of a gettext catalog,
+// with contexts removed and plural forms joined by spaces.
+func readMO(data []byte) [][2]string {
+	if len(data) < 28 {
+		return nil
+	}
+	var order binary.ByteOrder = binary.LittleEndian
+	if binary.BigEndian.Uint32(data) == 0x950412de {
+		order = binary.BigEndian
+	} else if order.Uint32(data) != 0x950412de {
+		return nil
+	}
+
+	n := int(order.Uint32(data[8:]))
+	sources, translations := int(order.Uint32(data[12:])), int(order.Uint32(data[16:]))
+	str := func(table, i int) string {
+		at := table + i*8
+		if at+8 > len(data) {
+			return ""
+		}
+		length, offset := int(order.Uint32(data[at:])), int(order.Uint32(data[at+4:]))
+		if offset+length > len(data) {
+			return ""
+		}
+		s := data[offset : offset+length]
+		if i := bytes.IndexByte(s, 4); i >= 0 {
+			s = s[i+1:]
+		}
+		return string(bytes.ReplaceAll(s, []byte{0}, []byte{' '}))
+	}
+
+	pairs := make([][2]string, 0, n)
+	for i := 0; i < n; i++ {
+		pairs = append(pairs, [2]string{str(sources, i), str(translations, i)})
+	}
+	return pairs
+}
+
+// addTrigrams counts the trigrams of the words of a text, as textProfile
+// does.
+func addTrigrams(text string, counts map[string]int) {
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool { return !unicode.IsLetter(r) }) {
+		padded := []rune(" " + word + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			counts[string(padded[i:i+3])]++
+		}
+	}
+}
+
+// top returns the most frequent trigrams, with spaces written as "_".
+func top(counts map[string]int) []string {
+	grams := make([]string, 0, len(counts))
+	for g := range counts {
+		grams = append(grams, g)
+	}
+	sort.Slice(grams, func(i, j int) bool {
+		if counts[grams[i]] != counts[grams[j]] {
+			return counts[grams[i]] > counts[grams[j]]
+		}
+		return grams[i] < grams[j]
+	})
+	if len(grams) > profileSize {
+		grams = grams[:profileSize]
+	}
+	for i, g := range grams {
+		grams[i] = strings.ReplaceAll(g, " ", "_")
+	}
+	return grams
+}
+
+func check(err error) {
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
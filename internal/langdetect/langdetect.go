@@ -0,0 +1,224 @@
+// Package langdetect detects the language of a text offline, by comparing
+// its character trigrams with profiles embedded in the binary.
+package langdetect
+
+import (
+	"bufio"
+	"bytes"
+	_ "embed"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// embeddedProfiles holds the ranked trigrams of each language, most
+// frequent first, one language per line. It is built by gen_profiles.go.
+//
+//go:generate go run gen_profiles.go -locale /usr/share/locale -o profiles.dat
+//go:embed profiles.dat
+var embeddedProfiles []byte
+
+// Detection settings
+const (
+	profileSize = 2000 // Trigrams kept per profile
+	maxText     = 4000 // Letters of the text considered
+	minLetters  = 40   // Shorter texts are not detected
+	minMargin   = 0.02 // Relative distance margin below which no language is chosen
+)
+
+// Result is the detected language of a text.
+type Result struct {
+	Language   string  // ISO 639-1 code, "" if undetermined
+	Confidence float64 // 0-1
+}
+
+var (
+	profilesOnce sync.Once
+	profiles     map[string]map[string]int // language -> trigram -> rank
+)
+
+func loadProfiles() {
+	profiles = make(map[string]map[string]int)
+	scanner := bufio.NewScanner(bytes.NewReader(embeddedProfiles))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lang, grams, ok := strings.Cut(line, "\t")
+		if !ok {
+			continue
+		}
+		ranks := make(map[string]int)
+		for i, gram := range strings.Fields(grams) {
+			ranks[strings.ReplaceAll(gram, "_", " ")] = i
+		}
+		profiles[lang] = ranks
+	}
+}
+
+// Languages returns the languages that can be detected.
+func Languages() []string {
+	profilesOnce.Do(loadProfiles)
+	langs := make([]string, 0, len(profiles)+len(scriptLanguages))
+	for lang := range profiles {
+		langs = append(langs, lang)
+	}
+	for _, s := range scriptLanguages {
+		langs = append(langs, s.lang)
+		langs = append(langs, s.others...)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// Detect returns the language of a text. Texts written in a script used by
+// a single language are detected by their script; others are compared with
+// the trigram profiles of the languages written in that script.
+func Detect(text string) Result {
+	profilesOnce.Do(loadProfiles)
+
+	letters, scripts := scanScripts(text)
+	if letters < minLetters {
+		return Result{}
+	}
+
+	// Dominant script
+	for _, s := range scriptLanguages {
+		if share := float64(scripts[s.name]) / float64(letters); share >= 0.4 {
+			lang := s.lang
+			if s.refine != nil {
+				lang = s.refine(text, scripts)
+			}
+			return Result{Language: lang, Confidence: share}
+		}
+	}
+
+	doc := textProfile(text)
+	if len(doc) == 0 {
+		return Result{}
+	}
+
+	type score struct {
+		lang     string
+		distance int
+	}
+	scores := make([]score, 0, len(profiles))
+	cyrillic := float64(scripts["cyrillic"])/float64(letters) >= 0.4
+	for lang, profile := range profiles {
+		if cyrillicLanguages[lang] != cyrillic {
+			continue
+		}
+		scores = append(scores, score{lang, outOfPlace(doc, profile)})
+	}
+	if len(scores) == 0 {
+		return Result{}
+	}
+	sort.Slice(scores, func(i, j int) bool {
+		if scores[i].distance != scores[j].distance {
+			return scores[i].distance < scores[j].distance
+		}
+		return scores[i].lang < scores[j].lang
+	})
+
+	best := scores[0]
+	if len(scores) == 1 {
+		worst := len(doc) * profileSize
+		return Result{Language: best.lang, Confidence: 1 - float64(best.distance)/float64(worst)}
+	}
+	margin := float64(scores[1].distance-best.distance) / float64(scores[1].distance)
+	if margin < minMargin {
+		return Result{}
+	}
+	// Confidence grows with the margin over the runner-up, reaching 1 at a
+	// 20% margin
+	confidence := margin * 5
+	if confidence > 1 {
+		confidence = 1
+	}
+	return Result{Language: best.lang, Confidence: confidence}
+}
+
+// Base returns the primary subtag of a language tag, e.g. "en" for "en-GB".
+// Norwegian variants are folded into "no".
+func Base(tag string) string {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if i := strings.IndexAny(tag, "-_"); i >= 0 {
+		tag = tag[:i]
+	}
+	switch tag {
+	case "nb", "nn":
+		return "no"
+	}
+	return tag
+}
+
+// Matches reports whether a language tag, such as an hreflang or lang
+// attribute, names the detected language.
+func Matches(tag, detected string) bool {
+	return Base(tag) == Base(detected)
+}
+
+// Known reports whether a language tag names a detectable language.
+func Known(tag string) bool {
+	base := Base(tag)
+	for _, lang := range Languages() {
+		if lang == base {
+			return true
+		}
+	}
+	return false
+}
+
+// textProfile returns the ranked trigrams of a text.
+func textProfile(text string) []string {
+	counts := make(map[string]int)
+	letters := 0
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool { return !unicode.IsLetter(r) }) {
+		if letters >= maxText {
+			break
+		}
+		letters += len(word)
+		padded := []rune(" " + word + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			counts[string(padded[i:i+3])]++
+		}
+	}
+
+	grams := make([]string, 0, len(counts))
+	for g := range counts {
+		grams = append(grams, g)
+	}
+	sort.Slice(grams, func(i, j int) bool {
+		if counts[grams[i]] != counts[grams[j]] {
+			return counts[grams[i]] > counts[grams[j]]
+		}
+		return grams[i] < grams[j]
+	})
+	if len(grams) > profileSize {
+		grams = grams[:profileSize]
+	}
+	return grams
+}
+
+// outOfPlace is the Cavnar-Trenkle distance between a text profile and a
+// language profile: the sum of rank differences, with trigrams missing
+// from the language costing the maximum.
+func outOfPlace(doc []string, profile map[string]int) int {
+	distance := 0
+	for i, gram := range doc {
+		rank, ok := profile[gram]
+		if !ok {
+			distance += profileSize
+			continue
+		}
+		if d := rank - i; d < 0 {
+			distance -= d
+		} else {
+			distance += d
+		}
+	}
+	return distance
+}
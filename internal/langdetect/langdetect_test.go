@@ -0,0 +1,76 @@
+package langdetect
+
+import "testing"
+
+// minConfidence is the confidence expected for a paragraph of ordinary
+// text, the floor at which the analyzer flags language mismatches.
+const minConfidence = 0.5
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		want string
+		text string
+	}{
+		{"cs", "Praha je hlavní a největší město České republiky. Leží v centru Čech na řece Vltavě a každý rok ji navštíví miliony turistů, kteří obdivují její historické památky."},
+		{"da", "København er Danmarks hovedstad og landets største by. Byen ligger på øerne Sjælland og Amager, og den er kendt for sine kanaler, cykelstier og gamle bygninger."},
+		{"de", "Berlin ist die Hauptstadt der Bundesrepublik Deutschland und zugleich ein eigenes Land. Die Stadt ist mit rund vier Millionen Einwohnern die bevölkerungsreichste Gemeinde des Landes."},
+		{"en", "London is the capital and largest city of England and the United Kingdom. It stands on the River Thames and has been a major settlement for nearly two thousand years."},
+		{"es", "Madrid es la capital de España y la ciudad más poblada del país. Se encuentra en el centro de la península y es conocida por sus museos, sus parques y su vida nocturna."},
+		{"fi", "Helsinki on Suomen pääkaupunki ja maan suurin kaupunki. Se sijaitsee Suomenlahden rannalla, ja sen keskustassa on paljon puistoja, museoita ja vanhoja rakennuksia."},
+		{"fr", "Paris est la capitale de la France et la ville la plus peuplée du pays. Elle est traversée par la Seine et elle est connue dans le monde entier pour ses musées et ses monuments."},
+		{"hu", "Budapest Magyarország fővárosa és egyben legnagyobb városa. A Duna két partján fekszik, és híres a fürdőiről, a hídjairól és a gyönyörű történelmi épületeiről."},
+		{"id", "Jakarta adalah ibu kota Indonesia dan kota terbesar di negara ini. Kota ini terletak di pantai utara pulau Jawa dan menjadi pusat pemerintahan serta perdagangan."},
+		{"it", "Roma è la capitale d'Italia e il comune più popoloso del paese. La città è famosa in tutto il mondo per la sua storia, per le sue chiese e per i suoi monumenti antichi."},
+		{"nl", "Amsterdam is de hoofdstad van Nederland en de grootste stad van het land. De stad staat bekend om haar grachten, musea en de vele fietsen die je overal ziet."},
+		{"no", "Vi har ikke mottatt betalingen din ennå. Vennligst sjekk at kortet ditt er gyldig og prøv igjen. Hvis du fortsatt har problemer, kan du kontakte kundeservice på telefon eller e-post."},
+		{"pl", "Warszawa jest stolicą Polski i największym miastem w kraju. Leży nad Wisłą w centralnej części kraju i jest ważnym ośrodkiem politycznym, gospodarczym i kulturalnym."},
+		{"pt", "Lisboa é a capital de Portugal e a cidade mais populosa do país. Fica na margem direita do rio Tejo e é conhecida pelos seus bairros antigos, elétricos e miradouros."},
+		{"ro", "București este capitala României și cel mai mare oraș din țară. Orașul se află în sud-estul țării, pe malurile râului Dâmbovița, și are numeroase parcuri și muzee."},
+		{"ru", "Москва является столицей России и самым крупным городом страны. Город расположен на реке Москве и известен своими музеями, театрами и историческими зданиями."},
+		{"sv", "Stockholm är Sveriges huvudstad och landets största stad. Staden ligger där Mälaren möter Östersjön och är känd för sina vackra öar, museer och gamla stadsdelar."},
+		{"tr", "Ankara Türkiye'nin başkenti ve en kalabalık ikinci şehridir. Şehir, İç Anadolu Bölgesi'nde yer alır ve birçok üniversiteye, müzeye ve devlet kurumuna ev sahipliği yapar."},
+		{"uk", "Київ є столицею України та найбільшим містом країни. Місто розташоване на річці Дніпро і відоме своїми соборами, парками та старовинними вулицями."},
+
+		// Detected by script
+		{"el", "Η Αθήνα είναι η πρωτεύουσα και η μεγαλύτερη πόλη της Ελλάδας, με μακρά ιστορία."},
+		{"ja", "東京は日本の首都であり、世界でも有数の大都市です。多くの人が毎日電車で通勤しています。"},
+		{"zh", "北京是中华人民共和国的首都，也是全国的政治和文化中心。这座城市有许多历史悠久的名胜古迹，每年吸引大量游客前来参观。"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			got := Detect(tt.text)
+			if got.Language != tt.want {
+				t.Errorf("Detect = %q (%.2f), want %q", got.Language, got.Confidence, tt.want)
+			}
+			if got.Confidence < minConfidence {
+				t.Errorf("confidence %.2f below %.2f", got.Confidence, minConfidence)
+			}
+		})
+	}
+}
+
+func TestDetectShortText(t *testing.T) {
+	if got := Detect("Hello world"); got.Language != "" {
+		t.Errorf("Detect of a short text = %q, want none", got.Language)
+	}
+}
+
+func TestBase(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want string
+	}{
+		{"en-GB", "en"},
+		{"pt_BR", "pt"},
+		{" FR ", "fr"},
+		{"nb", "no"},
+		{"nn-NO", "no"},
+	}
+
+	for _, tt := range tests {
+		if got := Base(tt.tag); got != tt.want {
+			t.Errorf("Base(%q) = %q, want %q", tt.tag, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package langdetect
+
+import (
+	"strings"
+	"unicode"
+)
+
+// scriptLanguage maps a script to the language written in it. refine
+// picks between the languages sharing the script, listed in others.
+type scriptLanguage struct {
+	name   string
+	lang   string
+	others []string
+	refine func(text string, scripts map[string]int) string
+}
+
+// Scripts detected without profiles, in order of precedence.
+var scriptLanguages = []scriptLanguage{
+	{name: "cjk", lang: "zh", others: []string{"ja", "ko"}, refine: refineCJK},
+	{name: "thai", lang: "th"},
+	{name: "greek", lang: "el"},
+	{name: "hebrew", lang: "he"},
+	{name: "devanagari", lang: "hi"},
+	{name: "arabic", lang: "ar", others: []string{"fa", "ur"}, refine: refineArabic},
+	{name: "georgian", lang: "ka"},
+	{name: "armenian", lang: "hy"},
+}
+
+// Languages written in Cyrillic, told apart by their profiles.
+var cyrillicLanguages = map[string]bool{
+	"ru": true,
+	"uk": true,
+}
+
+// scanScripts counts the letters of a text and the letters of each script.
+// Han, kana and Hangul count both on their own and together as "cjk".
+func scanScripts(text string) (int, map[string]int) {
+	scripts := make(map[string]int)
+	letters := 0
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		switch {
+		case unicode.Is(unicode.Han, r):
+			scripts["han"]++
+			scripts["cjk"]++
+		case unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r):
+			scripts["kana"]++
+			scripts["cjk"]++
+		case unicode.Is(unicode.Hangul, r):
+			scripts["hangul"]++
+			scripts["cjk"]++
+		case unicode.Is(unicode.Thai, r):
+			scripts["thai"]++
+		case unicode.Is(unicode.Greek, r):
+			scripts["greek"]++
+		case unicode.Is(unicode.Hebrew, r):
+			scripts["hebrew"]++
+		case unicode.Is(unicode.Devanagari, r):
+			scripts["devanagari"]++
+		case unicode.Is(unicode.Arabic, r):
+			scripts["arabic"]++
+		case unicode.Is(unicode.Georgian, r):
+			scripts["georgian"]++
+		case unicode.Is(unicode.Armenian, r):
+			scripts["armenian"]++
+		case unicode.Is(unicode.Cyrillic, r):
+			scripts["cyrillic"]++
+		}
+	}
+	return letters, scripts
+}
+
+// refineCJK tells Korean, Japanese and Chinese apart: Korean is written in
+// Hangul, and Japanese mixes kana into Han characters.
+func refineCJK(text string, scripts map[string]int) string {
+	switch {
+	case scripts["hangul"] > scripts["han"]+scripts["kana"]:
+		return "ko"
+	case scripts["kana"]*10 >= scripts["han"]+scripts["kana"]:
+		return "ja"
+	}
+	return "zh"
+}
+
+// refineArabic tells Persian and Urdu apart from Arabic by the letters
+// Arabic lacks.
+func refineArabic(text string, scripts map[string]int) string {
+	switch {
+	case strings.ContainsAny(text, "ٹڈڑںھے"):
+		return "ur"
+	case strings.ContainsAny(text, "پچژگ"):
+		return "fa"
+	}
+	return "ar"
+}
@@ -0,0 +1,316 @@
+package parser
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Main content extraction follows the scoring used by Readability: text
+// blocks score their parent and grandparent, class and id names push
+// candidates up or down, and link-heavy candidates are penalised.
+var (
+	unlikelyCandidate = regexp.MustCompile(`(?i)ad-break|agegate|banner|breadcrumb|combx|comment|community|consent|cookie|cover-wrap|disqus|extra|footer|gdpr|header|legends|menu|modal|newsletter|pager|pagination|popup|related|remark|replies|rss|share|shoutbox|sidebar|skyscraper|social|sponsor|supplemental`)
+	maybeCandidate    = regexp.MustCompile(`(?i)and|article|body|column|content|main|shadow`)
+	positiveName      = regexp.MustCompile(`(?i)article|body|content|entry|hentry|h-entry|main|page|post|text|blog|story`)
+	negativeName      = regexp.MustCompile(`(?i)-ad-|hidden|^hid$|banner|combx|comment|com-|consent|contact|cookie|footer|gdpr|masthead|media|menu|meta|nav|outbrain|promo|related|scroll|share|shopping|shoutbox|sidebar|skyscraper|sponsor|tags|widget`)
+)
+
+// Tags never part of the main content.
+var boilerplateTags = map[string]bool{
+	"script": true, "style": true, "noscript": true, "template": true, "svg": true,
+	"nav": true, "header": true, "footer": true, "aside": true, "iframe": true,
+	"button": true, "select": true, "dialog": true, "object": true, "embed": true,
+}
+
+// Landmark roles never part of the main content.
+var boilerplateRoles = map[string]bool{
+	"navigation": true, "banner": true, "contentinfo": true, "complementary": true,
+	"dialog": true, "alertdialog": true, "search": true, "menu": true, "menubar": true,
+}
+
+const (
+	minBlockLength    = 25 // characters for a text block to be scored
+	siblingScoreRatio = 0.2
+)
+
+// ContentSelectors are user-defined selectors for main content
+// extraction. When Include matches, its text is the main content;
+// otherwise the content is detected automatically. Exclude always removes
+// matching elements.
+type ContentSelectors struct {
+	Include []*Selector
+	Exclude []*Selector
+}
+
+// TextHash returns the hash of a text with its whitespace collapsed, used
+// for exact duplicate detection. It returns "" for a text without words.
+func TextHash(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if text == "" {
+		return ""
+	}
+	sum := md5.Sum([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
+
+// mainContent returns the visible text of the main content of a document.
+func (p *Parser) mainContent(doc *html.Node) string {
+	sel := p.content
+	if sel == nil {
+		sel = &ContentSelectors{}
+	}
+	removed := func(n *html.Node) bool { return sel.excluded(n) || isBoilerplate(n) }
+
+	// User-defined content areas
+	if len(sel.Include) > 0 {
+		var b strings.Builder
+		var walk func(n *html.Node)
+		walk = func(n *html.Node) {
+			if removed(n) {
+				return
+			}
+			if n.Type == html.ElementNode && sel.included(n) {
+				visibleText(n, &b, removed)
+				return
+			}
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				walk(c)
+			}
+		}
+		walk(doc)
+		if text := strings.TrimSpace(b.String()); text != "" {
+			return text
+		}
+	}
+
+	// Skip boilerplate and unlikely candidates while scoring
+	skip := func(n *html.Node) bool {
+		if removed(n) {
+			return true
+		}
+		if n.Type != html.ElementNode || n.Data == "body" || n.Data == "html" || n.Data == "article" || n.Data == "main" {
+			return false
+		}
+		names := getAttr(n, "class") + " " + getAttr(n, "id")
+		return unlikelyCandidate.MatchString(names) && !maybeCandidate.MatchString(names)
+	}
+
+	scores := make(map[*html.Node]float64)
+	candidates := make([]*html.Node, 0)
+	addScore := func(n *html.Node, score float64) {
+		if n == nil || n.Type != html.ElementNode {
+			return
+		}
+		if _, ok := scores[n]; !ok {
+			scores[n] = initialScore(n)
+			candidates = append(candidates, n)
+		}
+		scores[n] += score
+	}
+
+	var score func(n *html.Node)
+	score = func(n *html.Node) {
+		if skip(n) {
+			return
+		}
+		if n.Type == html.ElementNode && isTextBlock(n) {
+			var b strings.Builder
+			visibleText(n, &b, skip)
+			text := strings.TrimSpace(b.String())
+			if len(text) >= minBlockLength {
+				s := 1 + float64(strings.Count(text, ",")) + math.Min(float64(len(text)/100), 3)
+				addScore(n.Parent, s)
+				if n.Parent != nil {
+					addScore(n.Parent.Parent, s/2)
+				}
+			}
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			score(c)
+		}
+	}
+	score(doc)
+
+	if len(candidates) == 0 {
+		var b strings.Builder
+		visibleText(doc, &b, skip)
+		return strings.TrimSpace(b.String())
+	}
+
+	// Penalise link-heavy candidates
+	for _, n := range candidates {
+		scores[n] *= 1 - linkDensity(n, skip)
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return scores[candidates[i]] > scores[candidates[j]] })
+	top := candidates[0]
+
+	// Add siblings scoring close to the top candidate, such as the other
+	// sections of a long article
+	threshold := math.Max(10, scores[top]*siblingScoreRatio)
+	var b strings.Builder
+	if top.Parent == nil {
+		visibleText(top, &b, skip)
+		return strings.TrimSpace(b.String())
+	}
+	for s := top.Parent.FirstChild; s != nil; s = s.NextSibling {
+		include := s == top
+		if !include && s.Type == html.ElementNode && !skip(s) {
+			if score, ok := scores[s]; ok && score >= threshold {
+				include = true
+			} else if s.Data == "p" {
+				var pb strings.Builder
+				visibleText(s, &pb, skip)
+				text := strings.TrimSpace(pb.String())
+				density := linkDensity(s, skip)
+				include = (len(text) > 80 && density < 0.25) ||
+					(len(text) > 0 && density == 0 && strings.Contains(text, ". "))
+			}
+		}
+		if include {
+			visibleText(s, &b, skip)
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// initialScore weights a new candidate by its tag and class/id names.
+func initialScore(n *html.Node) float64 {
+	score := 0.0
+	switch n.Data {
+	case "article", "main":
+		score += 10
+	case "div":
+		score += 5
+	case "pre", "td", "blockquote", "section":
+		score += 3
+	case "address", "ol", "ul", "dl", "dd", "dt", "li", "form":
+		score -= 3
+	case "h1", "h2", "h3", "h4", "h5", "h6", "th":
+		score -= 5
+	}
+
+	for _, name := range []string{getAttr(n, "class"), getAttr(n, "id")} {
+		if name == "" {
+			continue
+		}
+		if negativeName.MatchString(name) {
+			score -= 25
+		}
+		if positiveName.MatchString(name) {
+			score += 25
+		}
+	}
+	return score
+}
+
+// isTextBlock reports whether an element is a paragraph-like block scored
+// on its own: a paragraph, or a div holding only inline content.
+func isTextBlock(n *html.Node) bool {
+	switch n.Data {
+	case "p", "pre", "td", "blockquote":
+		return true
+	case "div":
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type == html.ElementNode && isBlockElement(c.Data) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func isBlockElement(tag string) bool {
+	switch tag {
+	case "address", "article", "aside", "blockquote", "dd", "div", "dl", "dt", "fieldset",
+		"figure", "footer", "form", "h1", "h2", "h3", "h4", "h5", "h6", "header", "hr",
+		"li", "main", "nav", "ol", "p", "pre", "section", "table", "ul":
+		return true
+	}
+	return false
+}
+
+// isBoilerplate reports whether an element is never main content.
+func isBoilerplate(n *html.Node) bool {
+	if n.Type != html.ElementNode {
+		return false
+	}
+	if boilerplateTags[n.Data] || boilerplateRoles[getAttr(n, "role")] {
+		return true
+	}
+	return hasAttr(n, "hidden") || getAttr(n, "aria-hidden") == "true"
+}
+
+func (s *ContentSelectors) included(n *html.Node) bool {
+	for _, sel := range s.Include {
+		if sel.Match(n) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *ContentSelectors) excluded(n *html.Node) bool {
+	for _, sel := range s.Exclude {
+		if sel.Match(n) {
+			return true
+		}
+	}
+	return false
+}
+
+// visibleText appends the text of n, leaving out skipped elements.
+func visibleText(n *html.Node, b *strings.Builder, skip func(*html.Node) bool) {
+	if skip(n) {
+		return
+	}
+	if n.Type == html.TextNode {
+		if text := strings.TrimSpace(n.Data); text != "" {
+			b.WriteString(text)
+			b.WriteString(" ")
+		}
+		return
+	}
+	if n.Type == html.ElementNode && (n.Data == "script" || n.Data == "style") {
+		return
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		visibleText(c, b, skip)
+	}
+}
+
+// linkDensity returns the share of an element's text inside links.
+func linkDensity(n *html.Node, skip func(*html.Node) bool) float64 {
+	var all strings.Builder
+	visibleText(n, &all, skip)
+	total := len(all.String())
+	if total == 0 {
+		return 0
+	}
+
+	linked := 0
+	var walk func(*html.Node)
+	walk = func(c *html.Node) {
+		if skip(c) {
+			return
+		}
+		if c.Type == html.ElementNode && c.Data == "a" {
+			var b strings.Builder
+			visibleText(c, &b, skip)
+			linked += len(b.String())
+			return
+		}
+		for child := c.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(n)
+	return float64(linked) / float64(total)
+}
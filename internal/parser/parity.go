@@ -0,0 +1,110 @@
+package parser
+
+import "sort"
+
+// DefaultMinWordCountRatio is the mobile/desktop word ratio below which the
+// mobile page is missing content.
+const DefaultMinWordCountRatio = 0.9
+
+// DeviceParity compares the same page crawled as desktop and as mobile.
+type DeviceParity struct {
+	DesktopWordCount int
+	MobileWordCount  int
+
+	// Mobile word count relative to desktop (1 = equal)
+	WordCountRatio float64
+
+	TitleMatch           bool
+	MetaDescriptionMatch bool
+	CanonicalMatch       bool
+	H1Match              bool
+
+	DesktopLinks int
+	MobileLinks  int
+
+	// Links present on desktop but not on mobile, and the reverse
+	MissingLinks []string
+	ExtraLinks   []string
+
+	// Structured data types present on desktop but not on mobile, and the reverse
+	MissingStructuredData []string
+	ExtraStructuredData   []string
+}
+
+// CompareDevices compares desktop and mobile versions of a page.
+func CompareDevices(desktop, mobile *PageData) *DeviceParity {
+	p := &DeviceParity{
+		DesktopWordCount:     desktop.WordCount,
+		MobileWordCount:      mobile.WordCount,
+		WordCountRatio:       1,
+		TitleMatch:           desktop.Title == mobile.Title,
+		MetaDescriptionMatch: desktop.MetaDescription == mobile.MetaDescription,
+		CanonicalMatch:       desktop.Canonical == mobile.Canonical,
+		H1Match:              equalStrings(desktop.H1, mobile.H1),
+	}
+
+	if desktop.WordCount > 0 {
+		p.WordCountRatio = float64(mobile.WordCount) / float64(desktop.WordCount)
+	}
+
+	desktopLinks := linkSet(desktop.Links)
+	mobileLinks := linkSet(mobile.Links)
+	p.DesktopLinks = len(desktopLinks)
+	p.MobileLinks = len(mobileLinks)
+	p.MissingLinks = setDifference(desktopLinks, mobileLinks)
+	p.ExtraLinks = setDifference(mobileLinks, desktopLinks)
+
+	desktopTypes := stringSet(desktop.StructuredDataTypes)
+	mobileTypes := stringSet(mobile.StructuredDataTypes)
+	p.MissingStructuredData = setDifference(desktopTypes, mobileTypes)
+	p.ExtraStructuredData = setDifference(mobileTypes, desktopTypes)
+
+	return p
+}
+
+// MissingMobileContent reports whether the mobile page lacks content found on
+// desktop: fewer words than minRatio allows, dropped links or dropped
+// structured data.
+func (p *DeviceParity) MissingMobileContent(minRatio float64) bool {
+	return p.WordCountRatio < minRatio || len(p.MissingLinks) > 0 || len(p.MissingStructuredData) > 0
+}
+
+func linkSet(links []Link) map[string]bool {
+	set := make(map[string]bool, len(links))
+	for _, l := range links {
+		set[l.URL] = true
+	}
+	return set
+}
+
+func stringSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[v] = true
+	}
+	return set
+}
+
+// setDifference returns the sorted members of a that are not in b.
+func setDifference(a, b map[string]bool) []string {
+	diff := make([]string, 0)
+	for k := range a {
+		if !b[k] {
+			diff = append(diff, k)
+		}
+	}
+	sort.Strings(diff)
+	return diff
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -3,6 +3,7 @@ package parser
 
 import (
 	"bytes"
+	"encoding/json"
 	"io"
 	"net/url"
 	"strings"
@@ -47,6 +48,9 @@ type PageData struct {
 	// Hreflang tags
 	Hreflangs []Hreflang
 
+	// Structured data types (JSON-LD @type and microdata itemtype)
+	StructuredDataTypes []string
+
 	// Open Graph data
 	OpenGraph map[string]string
 
@@ -62,20 +66,38 @@ type PageData struct {
 	// Language from html lang attribute
 	Language string
 
-	// Word count (visible text)
+	// Word count (visible text), see Words
 	WordCount int
 
+	// Letters and digits of the visible text, the length measure for
+	// languages written without spaces
+	CharCount int
+
 	// Text content (for content hash)
 	TextContent string
+
+	// Hash of TextContent (see TextHash)
+	ContentHash string
+
+	// Text of the main content, without navigation, footers and banners
+	MainContent string
+
+	// Word count, character count and hash of MainContent
+	MainWordCount   int
+	MainCharCount   int
+	MainContentHash string
 }
 
+// LinkTypeJSRoute marks client-side routes discovered while rendering.
+const LinkTypeJSRoute = "js_route"
+
 // Link represents a link found on the page.
 type Link struct {
 	URL        string
 	Text       string // Anchor text
 	Rel        string // rel attribute (nofollow, sponsored, ugc, etc.)
-	Type       string // link type: a, area, link
-	IsInternal bool   // Will be set by crawler based on domain
+	Type       string // link type: a, area, link, js_route
+	IsInternal bool   // Inside the crawl scope; set by the scheduler
 	NoFollow   bool   // Has rel="nofollow"
 }
 
@@ -107,6 +129,7 @@ type Hreflang struct {
 // Parser parses HTML content.
 type Parser struct {
 	baseURL *url.URL
+	content *ContentSelectors
 }
 
 // NewParser creates a new HTML parser.
@@ -118,6 +141,21 @@ func NewParser(baseURL string) (*Parser, error) {
 	return &Parser{baseURL: u}, nil
 }
 
+// SetContentSelectors sets the selectors of the content area and of the
+// elements excluded from it, used for main content extraction.
+func (p *Parser) SetContentSelectors(include, exclude []string) error {
+	inc, err := ParseSelectors(include)
+	if err != nil {
+		return err
+	}
+	exc, err := ParseSelectors(exclude)
+	if err != nil {
+		return err
+	}
+	p.content = &ContentSelectors{Include: inc, Exclude: exc}
+	return nil
+}
+
 // Parse parses HTML content and extracts page data.
 func (p *Parser) Parse(htmlContent []byte) (*PageData, error) {
 	doc, err := html.Parse(bytes.NewReader(htmlContent))
@@ -145,6 +183,14 @@ func (p *Parser) Parse(htmlContent []byte) (*PageData, error) {
 	// Calculate word count
 	data.TextContent = textBuilder.String()
 	data.WordCount = countWords(data.TextContent)
+	data.CharCount = CountChars(data.TextContent)
+	data.ContentHash = TextHash(data.TextContent)
+
+	// Main content metrics
+	data.MainContent = p.mainContent(doc)
+	data.MainWordCount = countWords(data.MainContent)
+	data.MainCharCount = CountChars(data.MainContent)
+	data.MainContentHash = TextHash(data.MainContent)
 
 	return data, nil
 }
@@ -185,6 +231,9 @@ func (p *Parser) traverse(n *html.Node, data *PageData, textBuilder *strings.Bui
 			data.Images = append(data.Images, img)
 
 		case "script":
+			if strings.EqualFold(getAttr(n, "type"), "application/ld+json") {
+				data.StructuredDataTypes = append(data.StructuredDataTypes, jsonLDTypes(getTextContent(n))...)
+			}
 			if src := getAttr(n, "src"); src != "" {
 				data.Scripts = append(data.Scripts, Resource{
 					URL:   p.resolveURL(src),
@@ -214,6 +263,15 @@ func (p *Parser) traverse(n *html.Node, data *PageData, textBuilder *strings.Bui
 		}
 	}
 
+	// Microdata item types
+	if n.Type == html.ElementNode {
+		if itemType := getAttr(n, "itemtype"); itemType != "" {
+			for _, t := range strings.Fields(itemType) {
+				data.StructuredDataTypes = append(data.StructuredDataTypes, t[strings.LastIndex(t, "/")+1:])
+			}
+		}
+	}
+
 	// Collect text content (skip script/style)
 	if n.Type == html.TextNode {
 		parent := n.Parent
@@ -384,9 +442,42 @@ func collectText(n *html.Node, buf *bytes.Buffer) {
 	}
 }
 
-func countWords(text string) int {
-	words := strings.Fields(text)
-	return len(words)
+// jsonLDTypes returns the @type values in a JSON-LD block, including
+// nested objects and @graph entries.
+func jsonLDTypes(content string) []string {
+	var v interface{}
+	if err := json.Unmarshal([]byte(content), &v); err != nil {
+		return nil
+	}
+
+	var types []string
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch t := v.(type) {
+		case []interface{}:
+			for _, item := range t {
+				walk(item)
+			}
+		case map[string]interface{}:
+			switch typ := t["@type"].(type) {
+			case string:
+				types = append(types, typ)
+			case []interface{}:
+				for _, item := range typ {
+					if s, ok := item.(string); ok {
+						types = append(types, s)
+					}
+				}
+			}
+			for k, item := range t {
+				if k != "@type" {
+					walk(item)
+				}
+			}
+		}
+	}
+	walk(v)
+	return types
 }
 
 // ParseHTML is a convenience function to parse HTML from bytes.
@@ -0,0 +1,278 @@
+package parser
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Character classes used for segmentation.
+type charClass int
+
+const (
+	classOther    charClass = iota // Scripts written with spaces between words
+	classHan                       // Chinese characters, also used in Japanese
+	classHiragana                  // Japanese particles and inflections
+	classKatakana                  // Japanese loanwords
+	classThai
+)
+
+// Characters shared by several scripts but segmented with one of them.
+const (
+	prolongedSoundMark = 'ー' // Lengthens the preceding kana
+	iterationMark      = '々' // Repeats the preceding Han character
+)
+
+// Words splits a text into words. Scripts written with spaces are split on
+// whitespace, with punctuation trimmed from each word. Chinese and Japanese
+// are written without spaces: runs of Han characters are split into
+// overlapping character bigrams, so that a word's bigrams are the same
+// wherever the run around it starts, katakana runs are one word each and
+// hiragana runs one word per three characters. Thai is split into
+// syllables by rule, most Thai words being monosyllabic.
+func Words(text string) []string {
+	words := make([]string, 0)
+	segment(text, func(class charClass, runes []rune) {
+		words = appendSegment(words, class, runes)
+	})
+	return words
+}
+
+// countWords returns the number of words of a text. Han runs count one
+// word per two characters, the most common word length, rather than one
+// per overlapping bigram.
+func countWords(text string) int {
+	n := 0
+	segment(text, func(class charClass, runes []rune) {
+		if class == classHan {
+			n += (len(runes) + 1) / 2
+			return
+		}
+		n += len(appendSegment(nil, class, runes))
+	})
+	return n
+}
+
+// segment calls fn for each run of characters of one class in a text.
+func segment(text string, fn func(class charClass, runes []rune)) {
+	for _, field := range strings.Fields(text) {
+		runes := []rune(field)
+		for start := 0; start < len(runes); {
+			class := classify(runes[start])
+			end := start + 1
+			for end < len(runes) && continues(class, runes[end]) {
+				end++
+			}
+			fn(class, runes[start:end])
+			start = end
+		}
+	}
+}
+
+// CountChars returns the number of letters and digits of a text. It
+// measures the length of texts in languages written without spaces.
+func CountChars(text string) int {
+	n := 0
+	for _, r := range text {
+		if unicode.IsLetter(r) || unicode.IsNumber(r) {
+			n++
+		}
+	}
+	return n
+}
+
+func classify(r rune) charClass {
+	switch {
+	case unicode.Is(unicode.Han, r) || r == iterationMark:
+		return classHan
+	case unicode.Is(unicode.Hiragana, r):
+		return classHiragana
+	case unicode.Is(unicode.Katakana, r) || r == prolongedSoundMark:
+		return classKatakana
+	case unicode.Is(unicode.Thai, r):
+		return classThai
+	}
+	return classOther
+}
+
+// continues reports whether r belongs to a segment of the given class.
+// The prolonged sound mark also follows hiragana.
+func continues(class charClass, r rune) bool {
+	if r == prolongedSoundMark && class == classHiragana {
+		return true
+	}
+	return classify(r) == class
+}
+
+func appendSegment(words []string, class charClass, runes []rune) []string {
+	switch class {
+	case classHan:
+		return appendBigrams(words, runes)
+	case classHiragana:
+		return appendChunks(words, runes, 3)
+	case classKatakana:
+		return append(words, string(runes))
+	case classThai:
+		return append(words, thaiClusters(runes)...)
+	}
+
+	word := strings.TrimFunc(string(runes), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	if word == "" {
+		return words
+	}
+	return append(words, word)
+}
+
+// appendChunks splits runes into words of up to size characters.
+func appendChunks(words []string, runes []rune, size int) []string {
+	for i := 0; i < len(runes); i += size {
+		end := i + size
+		if end > len(runes) {
+			end = len(runes)
+		}
+		words = append(words, string(runes[i:end]))
+	}
+	return words
+}
+
+// appendBigrams splits runes into overlapping pairs of characters. A
+// single character is one word.
+func appendBigrams(words []string, runes []rune) []string {
+	if len(runes) == 1 {
+		return append(words, string(runes))
+	}
+	for i := 0; i+1 < len(runes); i++ {
+		words = append(words, string(runes[i:i+2]))
+	}
+	return words
+}
+
+// Thai character classes.
+func isThaiConsonant(r rune) bool { return r >= 'ก' && r <= 'ฮ' }
+
+func isThaiLeadingVowel(r rune) bool { return r >= 'เ' && r <= 'ไ' }
+
+// isThaiMark reports whether r is a vowel, tone mark or sign written
+// after, above or below the preceding character. Syllables never break
+// before one.
+func isThaiMark(r rune) bool {
+	return r == 'ะ' || r == 'ั' || r == 'า' || r == 'ำ' || (r >= 'ิ' && r <= 'ฺ') || (r >= '็' && r <= '๎')
+}
+
+// isThaiClosingVowel reports whether r is a vowel that ends its syllable.
+func isThaiClosingVowel(r rune) bool { return r == 'ะ' || r == 'ำ' }
+
+func isThaiDigit(r rune) bool { return r >= '๐' && r <= '๙' }
+
+// Consonants that also write vowels, as in "ขอ", "ตัว" or "เรียน".
+func isThaiVowelConsonant(r rune) bool { return r == 'อ' || r == 'ว' || r == 'ย' }
+
+// isThaiCluster reports whether two consonants form one initial: a true
+// cluster such as "ปร" or "คว", or a low consonant led by ห or อ, as in
+// "หม" or "อย".
+func isThaiCluster(first, second rune) bool {
+	switch first {
+	case 'ห':
+		return strings.ContainsRune("งญนมยรลว", second)
+	case 'อ':
+		return second == 'ย'
+	}
+	return strings.ContainsRune("กขคตปพผบดท", first) && strings.ContainsRune("รลว", second)
+}
+
+// thaiClusters splits a run of Thai text into syllables: an optional
+// leading vowel, one or two initial consonants, the vowels and tone marks
+// written on them, and an optional final consonant with any silenced
+// letters after it. A lone consonant with an implicit vowel, as in the
+// "ส" of "สบาย", is joined to the syllable after it.
+func thaiClusters(runes []rune) []string {
+	clusters := make([]string, 0)
+	bare := false
+	for i := 0; i < len(runes); {
+		// Repetition and abbreviation marks end the word before them
+		if (runes[i] == 'ๆ' || runes[i] == 'ฯ') && len(clusters) > 0 {
+			clusters[len(clusters)-1] += string(runes[i])
+			bare = false
+			i++
+			continue
+		}
+
+		end := thaiSyllableEnd(runes, i)
+		if bare && !isThaiDigit(runes[i]) {
+			clusters[len(clusters)-1] += string(runes[i:end])
+			bare = false
+		} else {
+			clusters = append(clusters, string(runes[i:end]))
+			bare = end == i+1 && isThaiConsonant(runes[i])
+		}
+		i = end
+	}
+	return clusters
+}
+
+// thaiSyllableEnd returns the end of the syllable starting at runes[i].
+func thaiSyllableEnd(runes []rune, i int) int {
+	n := len(runes)
+	at := func(j int, is func(rune) bool) bool { return j < n && is(runes[j]) }
+
+	j := i
+	if at(j, isThaiDigit) {
+		for at(j, isThaiDigit) {
+			j++
+		}
+		return j
+	}
+
+	leading := at(j, isThaiLeadingVowel)
+	if leading {
+		j++
+	}
+	if !at(j, isThaiConsonant) {
+		// A stray mark, or a leading vowel without its consonant
+		if j == i {
+			j++
+		}
+		for at(j, isThaiMark) {
+			j++
+		}
+		return j
+	}
+
+	// Initial consonants. The second one of a cluster carries the vowel,
+	// unless the vowel was written before them.
+	j++
+	if at(j, isThaiConsonant) && isThaiCluster(runes[j-1], runes[j]) && (leading || at(j+1, isThaiMark)) {
+		j++
+	}
+
+	// Vowels and tone marks
+	for at(j, isThaiMark) {
+		j++
+		if isThaiClosingVowel(runes[j-1]) {
+			return j
+		}
+	}
+
+	// A consonant takes marks of its own when it starts the next
+	// syllable; otherwise it writes the vowel or closes this one
+	closes := func(j int) bool {
+		return at(j, isThaiConsonant) && !at(j+1, isThaiMark) &&
+			!(at(j+1, isThaiConsonant) && isThaiCluster(runes[j], runes[j+1]) && at(j+2, isThaiMark))
+	}
+	if at(j, isThaiVowelConsonant) && closes(j) {
+		j++
+	}
+	if closes(j) {
+		j++
+	}
+
+	// Silenced letters, as in "จันทร์"
+	for k := j; k < j+2 && at(k, isThaiConsonant); k++ {
+		if at(k+1, func(r rune) bool { return r == '์' }) {
+			j = k + 2
+			break
+		}
+	}
+	return j
+}
@@ -0,0 +1,79 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWords(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		// Scripts written with spaces
+		{"english", "Hello, world! It's fine.", []string{"Hello", "world", "It's", "fine"}},
+		{"punctuation only", "-- ...", []string{}},
+
+		// Chinese: overlapping bigrams, the same wherever a run starts
+		{"chinese", "我们是学生", []string{"我们", "们是", "是学", "学生"}},
+		{"chinese suffix", "是学生", []string{"是学", "学生"}},
+		{"single han", "中", []string{"中"}},
+		{"chinese with latin", "使用Go语言", []string{"使用", "Go", "语言"}},
+
+		// Japanese
+		{"japanese", "東京へ行きます", []string{"東京", "へ", "行", "きます"}},
+		{"katakana word", "コンピューターを使う", []string{"コンピューター", "を", "使", "う"}},
+		{"prolonged hiragana", "すごーい", []string{"すごー", "い"}},
+
+		// Thai syllables
+		{"thai greeting", "สวัสดี", []string{"สวัส", "ดี"}},
+		{"thai leading ho", "ใหม่", []string{"ใหม่"}},
+		{"thai leading ho without vowel", "หน้าต่าง", []string{"หน้า", "ต่าง"}},
+		{"thai leading o", "อยู่บ้าน", []string{"อยู่", "บ้าน"}},
+		{"thai vowel consonant", "ขอบคุณ", []string{"ขอบ", "คุณ"}},
+		{"thai cluster", "ความรัก", []string{"ความ", "รัก"}},
+		{"thai leading vowel cluster", "เปลี่ยน", []string{"เปลี่ยน"}},
+		{"thai closing vowel", "ประเทศไทย", []string{"ประ", "เทศ", "ไทย"}},
+		{"thai implicit vowel", "คนดี", []string{"คน", "ดี"}},
+		{"thai bare consonant", "สบาย", []string{"สบาย"}},
+		{"thai silenced letter", "จันทร์นี้", []string{"จันทร์", "นี้"}},
+		{"thai repetition mark", "ต่างๆ", []string{"ต่างๆ"}},
+		{"thai digits", "ปี๒๕๖๗", []string{"ปี", "๒๕๖๗"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Words(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Words(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestThaiMarksStayAttached(t *testing.T) {
+	text := "ภาษาไทยเป็นภาษาที่สวยงามและน่าสนใจมากสำหรับผู้เรียนใหม่ๆ"
+	for _, word := range Words(text) {
+		if r := []rune(word)[0]; isThaiMark(r) {
+			t.Errorf("word %q starts with the combining character %q", word, r)
+		}
+	}
+}
+
+func TestCountWords(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"one two three", 3},
+		{"我们是学生", 3},
+		{"中", 1},
+		{"สวัสดี ครับ", 3},
+	}
+
+	for _, tt := range tests {
+		if got := countWords(tt.text); got != tt.want {
+			t.Errorf("countWords(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
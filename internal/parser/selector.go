@@ -0,0 +1,196 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Selector is a parsed list of simple CSS selectors. It supports type,
+// universal, #id, .class, [attr] and [attr=value] selectors, combined with
+// the descendant and child (>) combinators, e.g. "main, div#content > .post".
+type Selector struct {
+	text   string
+	chains [][]selectorStep
+}
+
+type selectorStep struct {
+	tag     string // "" or "*" for any
+	id      string
+	classes []string
+	attrs   []attrMatch
+	child   bool // must be a direct child of the previous step
+}
+
+type attrMatch struct {
+	name     string
+	value    string
+	hasValue bool
+}
+
+// ParseSelector parses a comma-separated list of selectors.
+func ParseSelector(text string) (*Selector, error) {
+	sel := &Selector{text: text}
+	for _, part := range strings.Split(text, ",") {
+		tokens := strings.Fields(strings.ReplaceAll(part, ">", " > "))
+		if len(tokens) == 0 {
+			return nil, fmt.Errorf("invalid selector %q: empty selector", text)
+		}
+
+		chain := make([]selectorStep, 0, len(tokens))
+		child := false
+		for _, token := range tokens {
+			if token == ">" {
+				if len(chain) == 0 || child {
+					return nil, fmt.Errorf("invalid selector %q: misplaced '>'", text)
+				}
+				child = true
+				continue
+			}
+			step, err := parseCompound(token)
+			if err != nil {
+				return nil, fmt.Errorf("invalid selector %q: %w", text, err)
+			}
+			step.child = child
+			child = false
+			chain = append(chain, step)
+		}
+		if child {
+			return nil, fmt.Errorf("invalid selector %q: misplaced '>'", text)
+		}
+		sel.chains = append(sel.chains, chain)
+	}
+	return sel, nil
+}
+
+// ParseSelectors parses several selector lists.
+func ParseSelectors(texts []string) ([]*Selector, error) {
+	selectors := make([]*Selector, 0, len(texts))
+	for _, text := range texts {
+		sel, err := ParseSelector(text)
+		if err != nil {
+			return nil, err
+		}
+		selectors = append(selectors, sel)
+	}
+	return selectors, nil
+}
+
+// String returns the selector text.
+func (s *Selector) String() string {
+	return s.text
+}
+
+// Match reports whether an element matches the selector.
+func (s *Selector) Match(n *html.Node) bool {
+	if n == nil || n.Type != html.ElementNode {
+		return false
+	}
+	for _, chain := range s.chains {
+		if matchChain(n, chain, len(chain)-1) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchChain(n *html.Node, chain []selectorStep, i int) bool {
+	if !chain[i].match(n) {
+		return false
+	}
+	if i == 0 {
+		return true
+	}
+	if chain[i].child {
+		return n.Parent != nil && matchChain(n.Parent, chain, i-1)
+	}
+	for a := n.Parent; a != nil; a = a.Parent {
+		if matchChain(a, chain, i-1) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *selectorStep) match(n *html.Node) bool {
+	if n.Type != html.ElementNode {
+		return false
+	}
+	if s.tag != "" && s.tag != "*" && !strings.EqualFold(s.tag, n.Data) {
+		return false
+	}
+	if s.id != "" && getAttr(n, "id") != s.id {
+		return false
+	}
+	if len(s.classes) > 0 {
+		classes := strings.Fields(getAttr(n, "class"))
+		for _, want := range s.classes {
+			found := false
+			for _, c := range classes {
+				if c == want {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		}
+	}
+	for _, a := range s.attrs {
+		if !hasAttr(n, a.name) || (a.hasValue && getAttr(n, a.name) != a.value) {
+			return false
+		}
+	}
+	return true
+}
+
+// parseCompound parses a selector without combinators, e.g. div.post#main.
+func parseCompound(token string) (selectorStep, error) {
+	var step selectorStep
+	i := 0
+	readIdent := func() string {
+		start := i
+		for i < len(token) && !strings.ContainsRune("#.[", rune(token[i])) {
+			i++
+		}
+		return token[start:i]
+	}
+
+	step.tag = strings.ToLower(readIdent())
+	for i < len(token) {
+		switch token[i] {
+		case '#':
+			i++
+			if step.id = readIdent(); step.id == "" {
+				return step, fmt.Errorf("empty id in %q", token)
+			}
+		case '.':
+			i++
+			class := readIdent()
+			if class == "" {
+				return step, fmt.Errorf("empty class in %q", token)
+			}
+			step.classes = append(step.classes, class)
+		case '[':
+			end := strings.IndexByte(token[i:], ']')
+			if end < 0 {
+				return step, fmt.Errorf("unterminated attribute in %q", token)
+			}
+			attr := token[i+1 : i+end]
+			i += end + 1
+			name, value, hasValue := strings.Cut(attr, "=")
+			name = strings.ToLower(strings.TrimSpace(name))
+			if name == "" {
+				return step, fmt.Errorf("empty attribute in %q", token)
+			}
+			step.attrs = append(step.attrs, attrMatch{
+				name:     name,
+				value:    strings.Trim(strings.TrimSpace(value), `"'`),
+				hasValue: hasValue,
+			})
+		}
+	}
+	return step, nil
+}
@@ -0,0 +1,111 @@
+package renderer
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/chromedp"
+)
+
+// Authorizer supplies credentials for rendered pages.
+// It is implemented by auth.Authenticator.
+type Authorizer interface {
+	// ApplyAuth adds the credentials bound to the request's host
+	ApplyAuth(req *http.Request)
+	// HTTPCredentials returns credentials for basic and digest challenges
+	HTTPCredentials(host string) (username, password string, ok bool)
+}
+
+// SetAuthorizer sets the credentials applied to rendered pages.
+func (r *Renderer) SetAuthorizer(a Authorizer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.authorizer = a
+}
+
+// applyAuth intercepts the tab's requests to add the credentials bound to
+// each request's host, so that subresources and third-party requests never
+// receive credentials of another host. HTTP authentication challenges are
+// answered the same way. The returned function must be called with the
+// pooled tab context once rendering is done.
+func (r *Renderer) applyAuth(ctx context.Context) (func(ctx context.Context), error) {
+	r.mu.Lock()
+	authorizer := r.authorizer
+	r.mu.Unlock()
+
+	if authorizer == nil {
+		return func(context.Context) {}, nil
+	}
+
+	// A request challenged twice has wrong credentials and is cancelled
+	attempts := make(map[fetch.RequestID]int)
+	chromedp.ListenTarget(ctx, func(ev interface{}) {
+		switch e := ev.(type) {
+		case *fetch.EventRequestPaused:
+			continueReq := fetch.ContinueRequest(e.RequestID)
+			if headers, changed := authHeaders(authorizer, e); changed {
+				continueReq = continueReq.WithHeaders(headers)
+			}
+			go chromedp.Run(ctx, continueReq)
+
+		case *fetch.EventAuthRequired:
+			attempts[e.RequestID]++
+			response := &fetch.AuthChallengeResponse{Response: fetch.AuthChallengeResponseResponseCancelAuth}
+			if u, err := url.Parse(e.AuthChallenge.Origin); err == nil && attempts[e.RequestID] == 1 {
+				if username, password, ok := authorizer.HTTPCredentials(u.Host); ok {
+					response = &fetch.AuthChallengeResponse{
+						Response: fetch.AuthChallengeResponseResponseProvideCredentials,
+						Username: username,
+						Password: password,
+					}
+				}
+			}
+			go chromedp.Run(ctx, fetch.ContinueWithAuth(e.RequestID, response))
+		}
+	})
+
+	if err := chromedp.Run(ctx, fetch.Enable().WithHandleAuthRequests(true)); err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context) {
+		chromedp.Run(ctx, fetch.Disable())
+	}, nil
+}
+
+// authHeaders returns the request headers with the authorizer's additions
+// for the request's host, and whether anything was added.
+func authHeaders(authorizer Authorizer, e *fetch.EventRequestPaused) ([]*fetch.HeaderEntry, bool) {
+	req, err := http.NewRequest(e.Request.Method, e.Request.URL, nil)
+	if err != nil {
+		return nil, false
+	}
+	for key, value := range e.Request.Headers {
+		req.Header.Set(key, fmt.Sprint(value))
+	}
+	original := req.Header.Clone()
+
+	authorizer.ApplyAuth(req)
+
+	// Challenge-based credentials are answered on demand instead
+	if _, _, ok := authorizer.HTTPCredentials(req.URL.Host); ok {
+		req.Header.Del("Authorization")
+		if auth, exists := original["Authorization"]; exists {
+			req.Header["Authorization"] = auth
+		}
+	}
+
+	if reflect.DeepEqual(original, req.Header) {
+		return nil, false
+	}
+
+	headers := make([]*fetch.HeaderEntry, 0, len(req.Header))
+	for key, values := range req.Header {
+		headers = append(headers, &fetch.HeaderEntry{Name: key, Value: strings.Join(values, ", ")})
+	}
+	return headers, true
+}
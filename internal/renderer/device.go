@@ -0,0 +1,34 @@
+package renderer
+
+import (
+	"context"
+
+	"github.com/chromedp/cdproto/emulation"
+	"github.com/chromedp/chromedp"
+
+	"github.com/spider-crawler/spider/internal/config"
+)
+
+// RenderDevice renders a page while emulating the given device profile.
+// A nil profile renders without emulation.
+func (r *Renderer) RenderDevice(urlStr string, device *config.DeviceProfile) *RenderResult {
+	return r.render(urlStr, device)
+}
+
+// applyDevice configures viewport, touch and user agent emulation for the
+// tab. Pooled tabs keep their emulation state, so a nil profile clears it.
+func (r *Renderer) applyDevice(ctx context.Context, device *config.DeviceProfile) error {
+	if device == nil {
+		return chromedp.Run(ctx,
+			emulation.ClearDeviceMetricsOverride(),
+			emulation.SetTouchEmulationEnabled(false),
+			emulation.SetUserAgentOverride(r.config.UserAgent),
+		)
+	}
+
+	return chromedp.Run(ctx,
+		emulation.SetDeviceMetricsOverride(device.Width, device.Height, device.DeviceScaleFactor, device.Mobile),
+		emulation.SetTouchEmulationEnabled(device.Touch),
+		emulation.SetUserAgentOverride(device.UserAgent),
+	)
+}
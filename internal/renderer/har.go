@@ -0,0 +1,725 @@
+package renderer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+)
+
+// NetworkLog holds the network activity recorded while rendering a page.
+type NetworkLog struct {
+	// Wall-clock time the first request was sent
+	StartedAt time.Time
+
+	// Page timings relative to StartedAt (-1 if not fired)
+	OnContentLoad time.Duration
+	OnLoad        time.Duration
+
+	// Requests in the order they were sent
+	Entries []*NetworkEntry
+}
+
+// NetworkEntry holds a single request/response pair captured from CDP events.
+type NetworkEntry struct {
+	RequestID    string
+	ResourceType string
+
+	// Request
+	URL            string
+	Method         string
+	RequestHeaders map[string]string
+	PostData       string
+	StartedAt      time.Time
+
+	// Response
+	Status          int
+	StatusText      string
+	Protocol        string
+	ResponseHeaders map[string]string
+	MimeType        string
+	RemoteIPAddress string
+	ConnectionID    string
+	FromCache       bool
+	RedirectURL     string
+
+	// Sizes in bytes
+	TransferSize int64 // Encoded bytes received, including headers
+	ContentSize  int64 // Decoded body size
+
+	// Timing breakdown as reported by Chromium
+	Timing *network.ResourceTiming
+
+	// Monotonic timestamps used to derive durations
+	requestTime  time.Time
+	finishedTime time.Time
+
+	// Failure information
+	Failed    bool
+	ErrorText string
+}
+
+// Duration returns the total time spent on the request.
+func (e *NetworkEntry) Duration() time.Duration {
+	if e.finishedTime.IsZero() || e.requestTime.IsZero() {
+		return 0
+	}
+	return e.finishedTime.Sub(e.requestTime)
+}
+
+// networkRecorder collects CDP network events into a NetworkLog.
+type networkRecorder struct {
+	mu sync.Mutex
+
+	entries  []*NetworkEntry
+	inFlight map[network.RequestID]*NetworkEntry
+
+	firstRequest  time.Time
+	startedAt     time.Time
+	contentLoaded time.Time
+	loaded        time.Time
+}
+
+func newNetworkRecorder() *networkRecorder {
+	return &networkRecorder{
+		entries:  make([]*NetworkEntry, 0),
+		inFlight: make(map[network.RequestID]*NetworkEntry),
+	}
+}
+
+// handleEvent records a CDP event if it is relevant to the network log.
+func (n *networkRecorder) handleEvent(ev interface{}) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	switch e := ev.(type) {
+	case *network.EventRequestWillBeSent:
+		// A redirect reuses the request ID: finish the previous hop first
+		if prev, ok := n.inFlight[e.RequestID]; ok && e.RedirectResponse != nil {
+			n.applyResponse(prev, e.RedirectResponse)
+			prev.RedirectURL = e.Request.URL
+			prev.finishedTime = monotonicTime(e.Timestamp)
+			delete(n.inFlight, e.RequestID)
+		}
+
+		entry := &NetworkEntry{
+			RequestID:      e.RequestID.String(),
+			ResourceType:   string(e.Type),
+			URL:            e.Request.URL + e.Request.URLFragment,
+			Method:         e.Request.Method,
+			RequestHeaders: headersToMap(e.Request.Headers),
+			PostData:       e.Request.PostData,
+			requestTime:    monotonicTime(e.Timestamp),
+		}
+		if e.WallTime != nil {
+			entry.StartedAt = e.WallTime.Time()
+		}
+
+		if n.firstRequest.IsZero() {
+			n.firstRequest = entry.requestTime
+			n.startedAt = entry.StartedAt
+		}
+
+		n.entries = append(n.entries, entry)
+		n.inFlight[e.RequestID] = entry
+
+	case *network.EventResponseReceived:
+		if entry, ok := n.inFlight[e.RequestID]; ok {
+			n.applyResponse(entry, e.Response)
+		}
+
+	case *network.EventDataReceived:
+		if entry, ok := n.inFlight[e.RequestID]; ok {
+			entry.ContentSize += e.DataLength
+		}
+
+	case *network.EventLoadingFinished:
+		if entry, ok := n.inFlight[e.RequestID]; ok {
+			entry.TransferSize = int64(e.EncodedDataLength)
+			entry.finishedTime = monotonicTime(e.Timestamp)
+			delete(n.inFlight, e.RequestID)
+		}
+
+	case *network.EventLoadingFailed:
+		if entry, ok := n.inFlight[e.RequestID]; ok {
+			entry.Failed = true
+			entry.ErrorText = e.ErrorText
+			entry.finishedTime = monotonicTime(e.Timestamp)
+			delete(n.inFlight, e.RequestID)
+		}
+	}
+}
+
+// markContentLoaded records the DOMContentLoaded timestamp.
+func (n *networkRecorder) markContentLoaded(ts *cdp.MonotonicTime) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.contentLoaded = monotonicTime(ts)
+}
+
+// markLoaded records the load event timestamp.
+func (n *networkRecorder) markLoaded(ts *cdp.MonotonicTime) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.loaded = monotonicTime(ts)
+}
+
+// applyResponse copies response data onto an entry.
+func (n *networkRecorder) applyResponse(entry *NetworkEntry, resp *network.Response) {
+	entry.Status = int(resp.Status)
+	entry.StatusText = resp.StatusText
+	entry.Protocol = resp.Protocol
+	entry.ResponseHeaders = headersToMap(resp.Headers)
+	entry.MimeType = resp.MimeType
+	entry.RemoteIPAddress = resp.RemoteIPAddress
+	entry.ConnectionID = fmt.Sprintf("%.0f", resp.ConnectionID)
+	entry.FromCache = resp.FromDiskCache || resp.FromPrefetchCache
+	entry.TransferSize = int64(resp.EncodedDataLength)
+	entry.Timing = resp.Timing
+
+	// Prefer the headers actually sent on the wire
+	if len(resp.RequestHeaders) > 0 {
+		entry.RequestHeaders = headersToMap(resp.RequestHeaders)
+	}
+}
+
+// log returns a snapshot of the recorded network activity.
+func (n *networkRecorder) log() *NetworkLog {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	log := &NetworkLog{
+		StartedAt:     n.startedAt,
+		OnContentLoad: -1,
+		OnLoad:        -1,
+		Entries:       make([]*NetworkEntry, len(n.entries)),
+	}
+	copy(log.Entries, n.entries)
+
+	if !n.contentLoaded.IsZero() {
+		log.OnContentLoad = n.contentLoaded.Sub(n.firstRequest)
+	}
+	if !n.loaded.IsZero() {
+		log.OnLoad = n.loaded.Sub(n.firstRequest)
+	}
+
+	return log
+}
+
+// HAR is an HTTP Archive 1.2 document.
+type HAR struct {
+	Log *HARLog `json:"log"`
+
+	// Keep credential headers in entries added from now on. They are
+	// redacted by default, as HAR files are commonly shared.
+	IncludeCredentials bool `json:"-"`
+}
+
+// redactedValue replaces the values of credential headers, cookies and
+// posted form data.
+const redactedValue = "[redacted]"
+
+// credentialHeaders are the headers redacted from HAR exports (lowercase).
+var credentialHeaders = map[string]bool{
+	"authorization":       true,
+	"proxy-authorization": true,
+	"cookie":              true,
+	"set-cookie":          true,
+}
+
+// HARLog is the root object of a HAR document.
+type HARLog struct {
+	Version string      `json:"version"`
+	Creator *HARCreator `json:"creator"`
+	Pages   []*HARPage  `json:"pages"`
+	Entries []*HAREntry `json:"entries"`
+}
+
+// HARCreator identifies the application that produced the HAR.
+type HARCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// HARPage represents a rendered page.
+type HARPage struct {
+	StartedDateTime string          `json:"startedDateTime"`
+	ID              string          `json:"id"`
+	Title           string          `json:"title"`
+	PageTimings     *HARPageTimings `json:"pageTimings"`
+}
+
+// HARPageTimings holds page load timings in milliseconds.
+type HARPageTimings struct {
+	OnContentLoad float64 `json:"onContentLoad"`
+	OnLoad        float64 `json:"onLoad"`
+}
+
+// HAREntry represents a single request/response pair.
+type HAREntry struct {
+	Pageref         string       `json:"pageref,omitempty"`
+	StartedDateTime string       `json:"startedDateTime"`
+	Time            float64      `json:"time"`
+	Request         *HARRequest  `json:"request"`
+	Response        *HARResponse `json:"response"`
+	Cache           struct{}     `json:"cache"`
+	Timings         *HARTimings  `json:"timings"`
+	ServerIPAddress string       `json:"serverIPAddress,omitempty"`
+	Connection      string       `json:"connection,omitempty"`
+	ResourceType    string       `json:"_resourceType,omitempty"`
+	Error           string       `json:"_error,omitempty"`
+}
+
+// HARRequest holds request details.
+type HARRequest struct {
+	Method      string          `json:"method"`
+	URL         string          `json:"url"`
+	HTTPVersion string          `json:"httpVersion"`
+	Cookies     []*HARCookie    `json:"cookies"`
+	Headers     []*HARNameValue `json:"headers"`
+	QueryString []*HARNameValue `json:"queryString"`
+	PostData    *HARPostData    `json:"postData,omitempty"`
+	HeadersSize int64           `json:"headersSize"`
+	BodySize    int64           `json:"bodySize"`
+}
+
+// HARResponse holds response details.
+type HARResponse struct {
+	Status       int             `json:"status"`
+	StatusText   string          `json:"statusText"`
+	HTTPVersion  string          `json:"httpVersion"`
+	Cookies      []*HARCookie    `json:"cookies"`
+	Headers      []*HARNameValue `json:"headers"`
+	Content      *HARContent     `json:"content"`
+	RedirectURL  string          `json:"redirectURL"`
+	HeadersSize  int64           `json:"headersSize"`
+	BodySize     int64           `json:"bodySize"`
+	TransferSize int64           `json:"_transferSize"`
+}
+
+// HARNameValue is a generic name/value pair (headers, cookies, query params).
+type HARNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// HARCookie is a cookie sent with a request or set by a response.
+type HARCookie struct {
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	Path     string `json:"path,omitempty"`
+	Domain   string `json:"domain,omitempty"`
+	Expires  string `json:"expires,omitempty"`
+	HTTPOnly bool   `json:"httpOnly,omitempty"`
+	Secure   bool   `json:"secure,omitempty"`
+}
+
+// HARPostData holds posted request data.
+type HARPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+// HARContent describes the response body.
+type HARContent struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+}
+
+// HARTimings holds the request phase durations in milliseconds (-1 = not applicable).
+type HARTimings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	SSL     float64 `json:"ssl"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// NewHAR creates an empty HAR document.
+func NewHAR() *HAR {
+	return &HAR{
+		Log: &HARLog{
+			Version: "1.2",
+			Creator: &HARCreator{Name: "SpiderCrawler", Version: "1.0"},
+			Pages:   make([]*HARPage, 0),
+			Entries: make([]*HAREntry, 0),
+		},
+	}
+}
+
+// BuildHAR builds a single HAR document from one or more render results.
+func BuildHAR(results ...*RenderResult) *HAR {
+	har := NewHAR()
+	for _, result := range results {
+		har.AddPage(result)
+	}
+	return har
+}
+
+// AddPage adds a rendered page and its network entries to the HAR.
+// Results rendered without CaptureHAR are skipped.
+func (h *HAR) AddPage(result *RenderResult) {
+	if result == nil || result.NetworkLog == nil {
+		return
+	}
+	log := result.NetworkLog
+
+	pageID := fmt.Sprintf("page_%d", len(h.Log.Pages)+1)
+	title := result.Title
+	if title == "" {
+		title = result.FinalURL
+	}
+
+	h.Log.Pages = append(h.Log.Pages, &HARPage{
+		StartedDateTime: formatHARTime(log.StartedAt),
+		ID:              pageID,
+		Title:           title,
+		PageTimings: &HARPageTimings{
+			OnContentLoad: durationMillis(log.OnContentLoad),
+			OnLoad:        durationMillis(log.OnLoad),
+		},
+	})
+
+	for _, entry := range log.Entries {
+		h.Log.Entries = append(h.Log.Entries, buildHAREntry(pageID, entry, h.IncludeCredentials))
+	}
+}
+
+// Write encodes the HAR document as indented JSON.
+func (h *HAR) Write(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(h)
+}
+
+// Save writes the HAR document to a file.
+func (h *HAR) Save(filePath string) error {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to create HAR file: %w", err)
+	}
+	defer file.Close()
+
+	if err := h.Write(file); err != nil {
+		return fmt.Errorf("failed to write HAR: %w", err)
+	}
+	return nil
+}
+
+// ExportHARFiles writes one HAR file per render result into dir, with
+// credential headers redacted unless includeCredentials is set. It returns
+// the paths of the files written.
+func ExportHARFiles(dir string, results []*RenderResult, includeCredentials bool) ([]string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create HAR directory: %w", err)
+	}
+
+	paths := make([]string, 0, len(results))
+	used := make(map[string]int)
+	for _, result := range results {
+		if result == nil || result.NetworkLog == nil {
+			continue
+		}
+
+		name := harFilename(result.FinalURL)
+		used[name]++
+		if n := used[name]; n > 1 {
+			name = fmt.Sprintf("%s_%d", name, n)
+		}
+
+		filePath := filepath.Join(dir, name+".har")
+		har := NewHAR()
+		har.IncludeCredentials = includeCredentials
+		har.AddPage(result)
+		if err := har.Save(filePath); err != nil {
+			return paths, err
+		}
+		paths = append(paths, filePath)
+	}
+
+	return paths, nil
+}
+
+// buildHAREntry converts a network entry into a HAR entry.
+func buildHAREntry(pageID string, entry *NetworkEntry, includeCredentials bool) *HAREntry {
+	httpVersion := harHTTPVersion(entry.Protocol)
+	timings := buildHARTimings(entry)
+
+	harEntry := &HAREntry{
+		Pageref:         pageID,
+		StartedDateTime: formatHARTime(entry.StartedAt),
+		Time:            durationMillis(entry.Duration()),
+		Request: &HARRequest{
+			Method:      entry.Method,
+			URL:         entry.URL,
+			HTTPVersion: httpVersion,
+			Cookies:     requestCookies(entry.RequestHeaders, includeCredentials),
+			Headers:     mapToNameValues(entry.RequestHeaders, includeCredentials),
+			QueryString: queryToNameValues(entry.URL),
+			HeadersSize: -1,
+			BodySize:    int64(len(entry.PostData)),
+		},
+		Response: &HARResponse{
+			Status:      entry.Status,
+			StatusText:  entry.StatusText,
+			HTTPVersion: httpVersion,
+			Cookies:     responseCookies(entry.ResponseHeaders, includeCredentials),
+			Headers:     mapToNameValues(entry.ResponseHeaders, includeCredentials),
+			Content: &HARContent{
+				Size:     entry.ContentSize,
+				MimeType: entry.MimeType,
+			},
+			RedirectURL:  entry.RedirectURL,
+			HeadersSize:  -1,
+			BodySize:     -1,
+			TransferSize: entry.TransferSize,
+		},
+		Timings:         timings,
+		ServerIPAddress: strings.Trim(entry.RemoteIPAddress, "[]"),
+		Connection:      entry.ConnectionID,
+		ResourceType:    entry.ResourceType,
+		Error:           entry.ErrorText,
+	}
+
+	// Posted bodies carry login forms and tokens, so they are redacted
+	// like credential headers
+	if entry.PostData != "" {
+		text := entry.PostData
+		if !includeCredentials {
+			text = redactedValue
+		}
+		harEntry.Request.PostData = &HARPostData{
+			MimeType: headerValue(entry.RequestHeaders, "Content-Type"),
+			Text:     text,
+		}
+	}
+
+	if entry.FromCache {
+		harEntry.Response.BodySize = 0
+	}
+
+	return harEntry
+}
+
+// buildHARTimings converts Chromium resource timing into HAR phases.
+func buildHARTimings(entry *NetworkEntry) *HARTimings {
+	timings := &HARTimings{Blocked: -1, DNS: -1, Connect: -1, SSL: -1, Send: 0, Wait: 0, Receive: 0}
+
+	t := entry.Timing
+	if t == nil {
+		timings.Wait = durationMillis(entry.Duration())
+		return timings
+	}
+
+	// Time spent queued before the first network phase started
+	blockedEnd := t.SendStart
+	for _, start := range []float64{t.ConnectStart, t.DNSStart} {
+		if start >= 0 {
+			blockedEnd = start
+		}
+	}
+	if blockedEnd > 0 {
+		timings.Blocked = blockedEnd
+	}
+
+	if t.DNSStart >= 0 {
+		timings.DNS = t.DNSEnd - t.DNSStart
+	}
+	if t.ConnectStart >= 0 {
+		timings.Connect = t.ConnectEnd - t.ConnectStart
+	}
+	if t.SslStart >= 0 {
+		timings.SSL = t.SslEnd - t.SslStart
+	}
+
+	timings.Send = t.SendEnd - t.SendStart
+	timings.Wait = t.ReceiveHeadersEnd - t.SendEnd
+
+	if !entry.finishedTime.IsZero() {
+		requestStart := monotonicSeconds(t.RequestTime)
+		total := durationMillis(entry.finishedTime.Sub(requestStart))
+		if receive := total - t.ReceiveHeadersEnd; receive > 0 {
+			timings.Receive = receive
+		}
+	}
+
+	return timings
+}
+
+// Helper functions
+
+func headersToMap(headers network.Headers) map[string]string {
+	result := make(map[string]string, len(headers))
+	for k, v := range headers {
+		if str, ok := v.(string); ok {
+			result[k] = str
+		}
+	}
+	return result
+}
+
+// mapToNameValues converts headers to HAR pairs, redacting credential
+// headers unless includeCredentials is set.
+func mapToNameValues(m map[string]string, includeCredentials bool) []*HARNameValue {
+	pairs := make([]*HARNameValue, 0, len(m))
+	for name, value := range m {
+		if !includeCredentials && credentialHeaders[strings.ToLower(name)] {
+			pairs = append(pairs, &HARNameValue{Name: name, Value: redactedValue})
+			continue
+		}
+		// Chromium joins repeated headers with newlines
+		for _, v := range strings.Split(value, "\n") {
+			pairs = append(pairs, &HARNameValue{Name: name, Value: v})
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		return pairs[i].Name < pairs[j].Name
+	})
+	return pairs
+}
+
+// headerValue returns the value of a header, matching its name
+// case-insensitively.
+func headerValue(m map[string]string, name string) string {
+	for k, v := range m {
+		if strings.EqualFold(k, name) {
+			return v
+		}
+	}
+	return ""
+}
+
+// requestCookies parses the Cookie request header into HAR cookies.
+func requestCookies(headers map[string]string, includeCredentials bool) []*HARCookie {
+	cookies := make([]*HARCookie, 0)
+	header := headerValue(headers, "Cookie")
+	if header == "" {
+		return cookies
+	}
+	req := &http.Request{Header: http.Header{"Cookie": strings.Split(header, "\n")}}
+	for _, c := range req.Cookies() {
+		value := c.Value
+		if !includeCredentials {
+			value = redactedValue
+		}
+		cookies = append(cookies, &HARCookie{Name: c.Name, Value: value})
+	}
+	return cookies
+}
+
+// responseCookies parses the Set-Cookie response headers into HAR cookies.
+func responseCookies(headers map[string]string, includeCredentials bool) []*HARCookie {
+	cookies := make([]*HARCookie, 0)
+	header := headerValue(headers, "Set-Cookie")
+	if header == "" {
+		return cookies
+	}
+	// Chromium joins repeated headers with newlines
+	resp := &http.Response{Header: http.Header{"Set-Cookie": strings.Split(header, "\n")}}
+	for _, c := range resp.Cookies() {
+		cookie := &HARCookie{
+			Name:     c.Name,
+			Value:    c.Value,
+			Path:     c.Path,
+			Domain:   c.Domain,
+			HTTPOnly: c.HttpOnly,
+			Secure:   c.Secure,
+		}
+		if !includeCredentials {
+			cookie.Value = redactedValue
+		}
+		if !c.Expires.IsZero() {
+			cookie.Expires = formatHARTime(c.Expires)
+		}
+		cookies = append(cookies, cookie)
+	}
+	return cookies
+}
+
+func queryToNameValues(rawURL string) []*HARNameValue {
+	pairs := make([]*HARNameValue, 0)
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return pairs
+	}
+	for name, values := range u.Query() {
+		for _, v := range values {
+			pairs = append(pairs, &HARNameValue{Name: name, Value: v})
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		return pairs[i].Name < pairs[j].Name
+	})
+	return pairs
+}
+
+func harHTTPVersion(protocol string) string {
+	switch strings.ToLower(protocol) {
+	case "h2":
+		return "HTTP/2"
+	case "h3", "h3-29", "quic":
+		return "HTTP/3"
+	case "":
+		return "HTTP/1.1"
+	default:
+		return strings.ToUpper(protocol)
+	}
+}
+
+func monotonicTime(ts *cdp.MonotonicTime) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.Time()
+}
+
+func monotonicSeconds(seconds float64) time.Time {
+	return cdp.MonotonicTimeEpoch.Add(time.Duration(seconds * float64(time.Second)))
+}
+
+func durationMillis(d time.Duration) float64 {
+	if d < 0 {
+		return -1
+	}
+	return float64(d) / float64(time.Millisecond)
+}
+
+func formatHARTime(t time.Time) string {
+	if t.IsZero() {
+		t = time.Now()
+	}
+	return t.UTC().Format("2006-01-02T15:04:05.000Z")
+}
+
+var harFilenameUnsafe = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+func harFilename(pageURL string) string {
+	name := pageURL
+	if u, err := url.Parse(pageURL); err == nil {
+		name = u.Host + u.Path
+		if u.RawQuery != "" {
+			name += "_" + u.RawQuery
+		}
+	}
+	name = strings.Trim(harFilenameUnsafe.ReplaceAllString(name, "_"), "_")
+	if len(name) > 150 {
+		name = name[:150]
+	}
+	if name == "" {
+		name = "page"
+	}
+	return name
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/chromedp/chromedp"
 
 	"github.com/spider-crawler/spider/internal/config"
+	"github.com/spider-crawler/spider/internal/screenshot"
 )
 
 // RenderResult holds the result of rendering a page.
@@ -38,6 +39,21 @@ type RenderResult struct {
 	// Resources loaded
 	Resources []*ResourceInfo
 
+	// Network activity for HAR export (only when CaptureHAR is enabled)
+	NetworkLog *NetworkLog
+
+	// JPEG screenshot (only when Screenshots is enabled)
+	Screenshot []byte
+
+	// Error capturing or storing the screenshot; the page itself rendered
+	ScreenshotError error
+
+	// Content revealed by scrolling (only when ScrollPage is enabled)
+	Scroll *ScrollReport
+
+	// Client-side routes not linked with <a href> (only when DiscoverJSRoutes is enabled)
+	Routes []string
+
 	// Console messages
 	ConsoleMessages []string
 
@@ -65,6 +81,18 @@ type PerformanceMetrics struct {
 	RecalcStyleCount int64
 	ScriptDuration float64
 	TaskDuration   float64
+
+	// Lab Core Web Vitals (milliseconds, CLS is unitless)
+	TTFB float64
+	LCP  float64
+	CLS  float64
+	TBT  float64 // Total Blocking Time
+
+	// Element responsible for LCP (CSS selector)
+	LCPElement string
+
+	// Elements that moved during layout shifts (CSS selectors)
+	LayoutShiftElements []string
 }
 
 // ResourceInfo holds information about a loaded resource.
@@ -89,6 +117,12 @@ type Renderer struct {
 	// Browser pool for concurrent rendering
 	browserPool chan context.Context
 	poolSize    int
+
+	// Credentials for rendered pages, nil if none
+	authorizer Authorizer
+
+	// Screenshots of rendered pages, nil if Screenshots is off
+	screenshots *screenshot.Store
 }
 
 // NewRenderer creates a new renderer instance.
@@ -98,6 +132,19 @@ func NewRenderer(cfg *config.CrawlConfig) (*Renderer, error) {
 		poolSize: cfg.Concurrency,
 	}
 
+	// Store screenshots as pages are rendered, in a directory of their own
+	if cfg.Screenshots != config.ScreenshotOff {
+		dir, err := screenshot.NewCrawlDir(cfg.ScreenshotDir)
+		if err != nil {
+			return nil, err
+		}
+		store, err := screenshot.Open(dir, cfg.ScreenshotQuality)
+		if err != nil {
+			return nil, err
+		}
+		r.screenshots = store
+	}
+
 	// Create allocator options
 	opts := append(chromedp.DefaultExecAllocatorOptions[:],
 		chromedp.Flag("headless", true),
@@ -136,6 +183,11 @@ func NewRenderer(cfg *config.CrawlConfig) (*Renderer, error) {
 
 // Render renders a page and returns the result.
 func (r *Renderer) Render(urlStr string) *RenderResult {
+	return r.render(urlStr, r.config.DeviceProfile())
+}
+
+// render renders a page under the given device profile.
+func (r *Renderer) render(urlStr string, device *config.DeviceProfile) *RenderResult {
 	result := &RenderResult{
 		Headers:   make(map[string]string),
 		Resources: make([]*ResourceInfo, 0),
@@ -157,8 +209,18 @@ func (r *Renderer) Render(urlStr string) *RenderResult {
 	resources := make(map[string]*ResourceInfo)
 	var resourcesMu sync.Mutex
 
+	// Record full network activity for HAR export
+	var recorder *networkRecorder
+	if r.config.CaptureHAR {
+		recorder = newNetworkRecorder()
+	}
+
 	// Listen for network events
 	chromedp.ListenTarget(timeoutCtx, func(ev interface{}) {
+		if recorder != nil {
+			recorder.handleEvent(ev)
+		}
+
 		switch e := ev.(type) {
 		case *network.EventResponseReceived:
 			resourcesMu.Lock()
@@ -187,6 +249,16 @@ func (r *Renderer) Render(urlStr string) *RenderResult {
 			}
 			resourcesMu.Unlock()
 
+		case *page.EventDomContentEventFired:
+			if recorder != nil {
+				recorder.markContentLoaded(e.Timestamp)
+			}
+
+		case *page.EventLoadEventFired:
+			if recorder != nil {
+				recorder.markLoaded(e.Timestamp)
+			}
+
 		case *page.EventJavascriptDialogOpening:
 			// Dismiss any dialogs
 			go chromedp.Run(timeoutCtx, page.HandleJavaScriptDialog(true))
@@ -199,6 +271,38 @@ func (r *Renderer) Render(urlStr string) *RenderResult {
 		return result
 	}
 
+	// Add host-bound credentials and answer authentication challenges
+	resetAuth, authErr := r.applyAuth(timeoutCtx)
+	if authErr != nil {
+		result.Error = fmt.Errorf("failed to apply authentication: %w", authErr)
+		return result
+	}
+	defer resetAuth(ctx)
+
+	// Emulate the device profile
+	if err := r.applyDevice(timeoutCtx, device); err != nil {
+		result.Error = fmt.Errorf("failed to emulate device: %w", err)
+		return result
+	}
+
+	// Apply CPU/network throttling for lab measurements
+	if err := r.applyThrottling(timeoutCtx); err != nil {
+		result.Error = fmt.Errorf("failed to apply throttling: %w", err)
+		return result
+	}
+
+	// Observe Web Vitals from the start of navigation
+	if scriptID, err := installVitalsObserver(timeoutCtx); err == nil {
+		defer removeInjectedScript(ctx, scriptID)
+	}
+
+	// Record client-side route changes
+	if r.config.DiscoverJSRoutes {
+		if scriptID, err := installRouteObserver(timeoutCtx); err == nil {
+			defer removeInjectedScript(ctx, scriptID)
+		}
+	}
+
 	// Build navigation actions based on wait condition
 	var waitAction chromedp.Action
 	switch r.config.WaitCondition {
@@ -223,9 +327,27 @@ func (r *Renderer) Render(urlStr string) *RenderResult {
 	var title string
 	var finalURL string
 
+	// Trigger lazy loading and infinite scroll before reading the DOM
+	scrollAction := chromedp.ActionFunc(func(ctx context.Context) error {
+		if !r.config.ScrollPage {
+			return nil
+		}
+		// A failed scroll leaves the page as loaded so far
+		report, err := r.scrollPage(ctx)
+		if err != nil {
+			if report == nil {
+				report = &ScrollReport{}
+			}
+			report.Error = err
+		}
+		result.Scroll = report
+		return nil
+	})
+
 	err := chromedp.Run(timeoutCtx,
 		chromedp.Navigate(urlStr),
 		waitAction,
+		scrollAction,
 		chromedp.Location(&finalURL),
 		chromedp.Title(&title),
 		chromedp.ActionFunc(func(ctx context.Context) error {
@@ -255,9 +377,31 @@ func (r *Renderer) Render(urlStr string) *RenderResult {
 	}
 	resourcesMu.Unlock()
 
+	if recorder != nil {
+		result.NetworkLog = recorder.log()
+	}
+
 	// Get performance metrics
 	result.Metrics = r.getPerformanceMetrics(timeoutCtx)
 
+	// Collect SPA routes
+	if r.config.DiscoverJSRoutes {
+		result.Routes = collectRoutes(timeoutCtx)
+	}
+
+	// Capture screenshot
+	if r.config.Screenshots != config.ScreenshotOff {
+		shot, err := r.captureScreenshot(timeoutCtx)
+		if err != nil {
+			result.ScreenshotError = fmt.Errorf("screenshot failed: %w", err)
+		} else {
+			result.Screenshot = shot
+			if _, err := r.screenshots.Save(result.FinalURL, shot); err != nil {
+				result.ScreenshotError = fmt.Errorf("failed to store screenshot: %w", err)
+			}
+		}
+	}
+
 	return result
 }
 
@@ -265,24 +409,8 @@ func (r *Renderer) Render(urlStr string) *RenderResult {
 func (r *Renderer) getPerformanceMetrics(ctx context.Context) *PerformanceMetrics {
 	metrics := &PerformanceMetrics{}
 
-	var perfJSON string
-	err := chromedp.Run(ctx,
-		chromedp.Evaluate(`JSON.stringify(performance.timing)`, &perfJSON),
-	)
-	if err != nil {
-		return metrics
-	}
-
-	// Parse timing data (simplified)
-	var timing map[string]float64
-	// Note: Would need to parse JSON properly
-	_ = timing
-
-	// Get paint timing
-	var paintEntries string
-	chromedp.Run(ctx,
-		chromedp.Evaluate(`JSON.stringify(performance.getEntriesByType('paint'))`, &paintEntries),
-	)
+	collectLabVitals(ctx, metrics)
+	collectRuntimeMetrics(ctx, metrics)
 
 	return metrics
 }
@@ -347,6 +475,45 @@ func (r *Renderer) Screenshot(urlStr string, quality int) ([]byte, error) {
 	return buf, nil
 }
 
+// Screenshots returns the store screenshots are saved to while rendering,
+// or nil if screenshots are off.
+func (r *Renderer) Screenshots() *screenshot.Store {
+	return r.screenshots
+}
+
+// captureScreenshot captures the current page as JPEG according to the
+// configured screenshot mode.
+func (r *Renderer) captureScreenshot(ctx context.Context) ([]byte, error) {
+	var buf []byte
+	quality := r.config.ScreenshotQuality
+	if quality < 1 || quality > 100 {
+		quality = 80
+	}
+
+	var action chromedp.Action
+	if r.config.Screenshots == config.ScreenshotFullPage {
+		// FullScreenshot encodes as JPEG when quality < 100
+		if quality == 100 {
+			quality = 99
+		}
+		action = chromedp.FullScreenshot(&buf, quality)
+	} else {
+		action = chromedp.ActionFunc(func(ctx context.Context) error {
+			var err error
+			buf, err = page.CaptureScreenshot().
+				WithFormat(page.CaptureScreenshotFormatJpeg).
+				WithQuality(int64(quality)).
+				Do(ctx)
+			return err
+		})
+	}
+
+	if err := chromedp.Run(ctx, action); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
 // PDF generates a PDF of the page.
 func (r *Renderer) PDF(urlStr string) ([]byte, error) {
 	ctx := <-r.browserPool
@@ -0,0 +1,77 @@
+package renderer
+
+import (
+	"context"
+
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+)
+
+// routeObserverScript wraps the History API before any page script runs so
+// that client-side navigations are recorded in window.__spiderRoutes.
+const routeObserverScript = `(() => {
+	if (window.__spiderRoutes) return;
+	const routes = window.__spiderRoutes = [];
+	const record = (url) => {
+		if (url === undefined || url === null) return;
+		try { routes.push(new URL(String(url), document.baseURI).href); } catch (e) {}
+	};
+	['pushState', 'replaceState'].forEach(name => {
+		const original = history[name];
+		history[name] = function (state, title, url) {
+			record(url);
+			return original.apply(this, arguments);
+		};
+	});
+	window.addEventListener('hashchange', () => record(location.href));
+})()`
+
+// routeCollectScript returns recorded History API routes plus URLs found in
+// router attributes and onclick handlers. URLs already linked with a real
+// <a href> are left to the HTML parser.
+const routeCollectScript = `(() => {
+	const found = new Set();
+	const add = (value) => {
+		if (!value) return;
+		value = value.trim();
+		if (!value || value.startsWith('javascript:')) return;
+		try { found.add(new URL(value, document.baseURI).href); } catch (e) {}
+	};
+
+	(window.__spiderRoutes || []).forEach(add);
+
+	const attrs = ['routerlink', 'ng-reflect-router-link', 'data-href', 'data-url', 'data-link', 'data-route'];
+	document.querySelectorAll(attrs.map(a => '[' + a + ']').join(',')).forEach(el => {
+		attrs.forEach(a => add(el.getAttribute(a)));
+	});
+
+	const handler = /(?:location(?:\.href)?\s*=|location\.(?:assign|replace)\(|navigate(?:ByUrl)?\(|history\.push\(|router\.push\()\s*['"` + "`" + `]([^'"` + "`" + `]+)['"` + "`" + `]/g;
+	document.querySelectorAll('[onclick]').forEach(el => {
+		for (const m of (el.getAttribute('onclick') || '').matchAll(handler)) add(m[1]);
+	});
+
+	const anchors = new Set(Array.from(document.querySelectorAll('a[href]'), a => a.href));
+	const current = location.href;
+	return Array.from(found).filter(u => /^https?:/.test(u) && u !== current && !anchors.has(u));
+})()`
+
+// installRouteObserver injects the History API hook for the next navigation.
+// The returned identifier must be passed to removeInjectedScript.
+func installRouteObserver(ctx context.Context) (page.ScriptIdentifier, error) {
+	var id page.ScriptIdentifier
+	err := chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		var err error
+		id, err = page.AddScriptToEvaluateOnNewDocument(routeObserverScript).Do(ctx)
+		return err
+	}))
+	return id, err
+}
+
+// collectRoutes returns client-side routes discovered on the current page.
+func collectRoutes(ctx context.Context) []string {
+	var routes []string
+	if err := chromedp.Run(ctx, chromedp.Evaluate(routeCollectScript, &routes)); err != nil {
+		return nil
+	}
+	return routes
+}
@@ -0,0 +1,184 @@
+package renderer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/chromedp/chromedp"
+)
+
+// ScrollReport records content that appeared while scrolling a page.
+type ScrollReport struct {
+	// Links and images present before any interaction
+	InitialLinks  int
+	InitialImages int
+
+	// Links and images that appeared only after scrolling or clicking
+	InteractionLinks  []string
+	InteractionImages []string
+
+	Steps []*ScrollStep
+
+	// Error that stopped scrolling early; the steps before it are kept
+	Error error
+}
+
+// ScrollStep is the state after one scroll step.
+type ScrollStep struct {
+	Index     int
+	ScrollY   float64
+	Height    float64
+	Clicked   bool // "load more" element was clicked
+	NewLinks  []string
+	NewImages []string
+}
+
+// pageSnapshot mirrors the JSON produced by snapshotScript.
+type pageSnapshot struct {
+	Links    []string `json:"links"`
+	Images   []string `json:"images"`
+	ScrollY  float64  `json:"y"`
+	Height   float64  `json:"height"`
+	Viewport float64  `json:"viewport"`
+}
+
+// snapshotScript collects the current links, images and scroll position.
+const snapshotScript = `JSON.stringify({
+	links: Array.from(document.querySelectorAll('a[href]'), a => a.href).filter(h => /^https?:/.test(h)),
+	images: Array.from(document.images, i => i.currentSrc || i.src).filter(s => s && !s.startsWith('data:')),
+	y: window.scrollY,
+	height: document.documentElement.scrollHeight,
+	viewport: window.innerHeight
+})`
+
+// clickScript clicks the first visible, enabled element matching a selector.
+const clickScript = `(() => {
+	const el = document.querySelector(%s);
+	if (!el || el.disabled || el.offsetParent === null) return false;
+	el.scrollIntoView({block: 'center'});
+	el.click();
+	return true;
+})()`
+
+// scrollPage scrolls to the bottom step by step, optionally clicking a
+// "load more" element, and records what each step added to the page.
+func (r *Renderer) scrollPage(ctx context.Context) (*ScrollReport, error) {
+	maxScrolls := r.config.MaxScrolls
+	if maxScrolls < 1 {
+		maxScrolls = 20
+	}
+
+	scroll := "window.scrollBy(0, window.innerHeight)"
+	if r.config.ScrollStep > 0 {
+		scroll = fmt.Sprintf("window.scrollBy(0, %d)", r.config.ScrollStep)
+	}
+
+	var click string
+	if r.config.LoadMoreSelector != "" {
+		selector, _ := json.Marshal(r.config.LoadMoreSelector)
+		click = fmt.Sprintf(clickScript, selector)
+	}
+
+	initial, err := takeSnapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ScrollReport{
+		InitialLinks:      len(initial.Links),
+		InitialImages:     len(initial.Images),
+		InteractionLinks:  make([]string, 0),
+		InteractionImages: make([]string, 0),
+		Steps:             make([]*ScrollStep, 0),
+	}
+	seenLinks := stringSet(initial.Links)
+	seenImages := stringSet(initial.Images)
+	prevHeight := initial.Height
+
+	for i := 1; i <= maxScrolls; i++ {
+		if err := chromedp.Run(ctx, chromedp.Evaluate(scroll, nil)); err != nil {
+			return report, err
+		}
+
+		step := &ScrollStep{Index: i}
+		if click != "" {
+			if err := chromedp.Run(ctx, chromedp.Evaluate(click, &step.Clicked)); err != nil {
+				return report, err
+			}
+		}
+
+		if err := sleepContext(ctx, r.config.ScrollIdleWait); err != nil {
+			return report, err
+		}
+
+		snap, err := takeSnapshot(ctx)
+		if err != nil {
+			return report, err
+		}
+		step.ScrollY = snap.ScrollY
+		step.Height = snap.Height
+		step.NewLinks = addNew(seenLinks, snap.Links)
+		step.NewImages = addNew(seenImages, snap.Images)
+		report.InteractionLinks = append(report.InteractionLinks, step.NewLinks...)
+		report.InteractionImages = append(report.InteractionImages, step.NewImages...)
+		report.Steps = append(report.Steps, step)
+
+		// Stop once at the bottom and nothing more is loading
+		atBottom := snap.ScrollY+snap.Viewport >= snap.Height-1
+		grew := snap.Height > prevHeight || len(step.NewLinks) > 0 || len(step.NewImages) > 0
+		if atBottom && !grew && !step.Clicked {
+			break
+		}
+		prevHeight = snap.Height
+	}
+
+	return report, nil
+}
+
+func takeSnapshot(ctx context.Context) (*pageSnapshot, error) {
+	var raw string
+	if err := chromedp.Run(ctx, chromedp.Evaluate(snapshotScript, &raw)); err != nil {
+		return nil, err
+	}
+	snap := &pageSnapshot{}
+	if err := json.Unmarshal([]byte(raw), snap); err != nil {
+		return nil, err
+	}
+	return snap, nil
+}
+
+// addNew adds unseen values to seen and returns them in page order.
+func addNew(seen map[string]bool, values []string) []string {
+	added := make([]string, 0)
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			added = append(added, v)
+		}
+	}
+	return added
+}
+
+func stringSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[v] = true
+	}
+	return set
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
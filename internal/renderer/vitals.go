@@ -0,0 +1,217 @@
+package renderer
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/chromedp/cdproto/emulation"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/cdproto/performance"
+	"github.com/chromedp/chromedp"
+
+	"github.com/spider-crawler/spider/internal/config"
+)
+
+// vitalsObserverScript is injected before any page script runs. It registers
+// PerformanceObservers that accumulate lab Core Web Vitals in
+// window.__spiderVitals while the page loads.
+const vitalsObserverScript = `(() => {
+	if (window.__spiderVitals) return;
+	const v = window.__spiderVitals = {fcp: 0, lcp: 0, lcpElement: '', cls: 0, longTasks: [], shiftElements: []};
+	const selector = (el) => {
+		const parts = [];
+		while (el && el.nodeType === 1 && parts.length < 8) {
+			if (el.id) { parts.unshift('#' + CSS.escape(el.id)); break; }
+			let part = el.localName;
+			const parent = el.parentElement;
+			if (parent) {
+				const same = Array.from(parent.children).filter(c => c.localName === el.localName);
+				if (same.length > 1) part += ':nth-of-type(' + (same.indexOf(el) + 1) + ')';
+			}
+			parts.unshift(part);
+			el = parent;
+		}
+		return parts.join(' > ');
+	};
+	const observe = (type, cb) => {
+		try {
+			new PerformanceObserver(list => list.getEntries().forEach(cb)).observe({type, buffered: true});
+		} catch (e) {}
+	};
+	observe('paint', e => {
+		if (e.name === 'first-contentful-paint') v.fcp = e.startTime;
+	});
+	observe('largest-contentful-paint', e => {
+		v.lcp = e.renderTime || e.loadTime || e.startTime;
+		v.lcpElement = e.element ? selector(e.element) : '';
+	});
+	let session = 0, sessionStart = 0, sessionLast = 0;
+	observe('layout-shift', e => {
+		if (e.hadRecentInput) return;
+		if (session && (e.startTime - sessionLast > 1000 || e.startTime - sessionStart > 5000)) session = 0;
+		if (!session) sessionStart = e.startTime;
+		session += e.value;
+		sessionLast = e.startTime;
+		if (session > v.cls) v.cls = session;
+		(e.sources || []).forEach(s => {
+			if (!s.node || s.node.nodeType !== 1) return;
+			const sel = selector(s.node);
+			if (v.shiftElements.indexOf(sel) < 0) v.shiftElements.push(sel);
+		});
+	});
+	observe('longtask', e => {
+		v.longTasks.push([e.startTime, e.startTime + e.duration]);
+	});
+})()`
+
+// vitalsCollectScript returns the accumulated metrics together with
+// navigation timing as a JSON string. Total Blocking Time is computed here,
+// once FCP is known: only the part of a long task after FCP counts, as in
+// Lighthouse, and there is no blocking time without a contentful paint.
+const vitalsCollectScript = `(() => {
+	const v = window.__spiderVitals || {};
+	let tbt = 0;
+	if (v.fcp) {
+		(v.longTasks || []).forEach(([start, end]) => {
+			const blocking = end - Math.max(start, v.fcp) - 50;
+			if (blocking > 0) tbt += blocking;
+		});
+	}
+	const nav = performance.getEntriesByType('navigation')[0] || {};
+	const fp = performance.getEntriesByName('first-paint')[0];
+	return JSON.stringify({
+		ttfb: nav.responseStart || 0,
+		domContentLoaded: nav.domContentLoadedEventEnd || 0,
+		loadEventEnd: nav.loadEventEnd || 0,
+		firstPaint: fp ? fp.startTime : 0,
+		fcp: v.fcp || 0,
+		lcp: v.lcp || 0,
+		lcpElement: v.lcpElement || '',
+		cls: v.cls || 0,
+		tbt: tbt,
+		shiftElements: v.shiftElements || []
+	});
+})()`
+
+// labVitals mirrors the JSON produced by vitalsCollectScript.
+type labVitals struct {
+	TTFB             float64  `json:"ttfb"`
+	DOMContentLoaded float64  `json:"domContentLoaded"`
+	LoadEventEnd     float64  `json:"loadEventEnd"`
+	FirstPaint       float64  `json:"firstPaint"`
+	FCP              float64  `json:"fcp"`
+	LCP              float64  `json:"lcp"`
+	LCPElement       string   `json:"lcpElement"`
+	CLS              float64  `json:"cls"`
+	TBT              float64  `json:"tbt"`
+	ShiftElements    []string `json:"shiftElements"`
+}
+
+// networkProfile describes emulated network conditions.
+type networkProfile struct {
+	Latency  float64 // ms
+	Download float64 // bytes/sec
+	Upload   float64 // bytes/sec
+}
+
+// networkProfiles maps configured throttling names to CDP conditions.
+var networkProfiles = map[config.NetworkThrottling]networkProfile{
+	config.ThrottleSlow4G: {Latency: 150, Download: 1.6 * 1024 * 1024 / 8, Upload: 750 * 1024 / 8},
+	config.ThrottleFast3G: {Latency: 562.5, Download: 1.44 * 1024 * 1024 / 8, Upload: 675 * 1024 / 8},
+	config.ThrottleSlow3G: {Latency: 2000, Download: 400 * 1024 / 8, Upload: 400 * 1024 / 8},
+}
+
+// applyThrottling configures CPU and network emulation for the tab.
+// Settings are re-applied on every render because tabs are pooled.
+func (r *Renderer) applyThrottling(ctx context.Context) error {
+	rate := r.config.CPUThrottlingRate
+	if rate < 1 {
+		rate = 1
+	}
+
+	profile, ok := networkProfiles[r.config.NetworkThrottling]
+	if !ok {
+		// No throttling: -1 disables the download/upload limits
+		profile = networkProfile{Download: -1, Upload: -1}
+	}
+
+	return chromedp.Run(ctx,
+		emulation.SetCPUThrottlingRate(rate),
+		network.EmulateNetworkConditions(false, profile.Latency, profile.Download, profile.Upload),
+	)
+}
+
+// installVitalsObserver injects the observer script for the next navigation.
+// The returned identifier must be passed to removeInjectedScript.
+func installVitalsObserver(ctx context.Context) (page.ScriptIdentifier, error) {
+	var id page.ScriptIdentifier
+	err := chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		var err error
+		id, err = page.AddScriptToEvaluateOnNewDocument(vitalsObserverScript).Do(ctx)
+		return err
+	}))
+	return id, err
+}
+
+// removeInjectedScript removes an injected script so pooled tabs do not
+// accumulate copies.
+func removeInjectedScript(ctx context.Context, id page.ScriptIdentifier) {
+	chromedp.Run(ctx, page.RemoveScriptToEvaluateOnNewDocument(id))
+}
+
+// collectLabVitals reads the lab metrics gathered by the observer script.
+func collectLabVitals(ctx context.Context, metrics *PerformanceMetrics) {
+	var raw string
+	if err := chromedp.Run(ctx, chromedp.Evaluate(vitalsCollectScript, &raw)); err != nil {
+		return
+	}
+
+	var v labVitals
+	if err := json.Unmarshal([]byte(raw), &v); err != nil {
+		return
+	}
+
+	metrics.DOMContentLoaded = v.DOMContentLoaded
+	metrics.LoadEventEnd = v.LoadEventEnd
+	metrics.FirstPaint = v.FirstPaint
+	metrics.FirstContentfulPaint = v.FCP
+	metrics.TTFB = v.TTFB
+	metrics.LCP = v.LCP
+	metrics.LCPElement = v.LCPElement
+	metrics.CLS = v.CLS
+	metrics.TBT = v.TBT
+	metrics.LayoutShiftElements = v.ShiftElements
+}
+
+// collectRuntimeMetrics reads layout and script counters from the
+// Performance domain.
+func collectRuntimeMetrics(ctx context.Context, metrics *PerformanceMetrics) {
+	var values []*performance.Metric
+	err := chromedp.Run(ctx,
+		performance.Enable(),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			var err error
+			values, err = performance.GetMetrics().Do(ctx)
+			return err
+		}),
+	)
+	if err != nil {
+		return
+	}
+
+	for _, m := range values {
+		switch m.Name {
+		case "NavigationStart":
+			metrics.NavigationStart = m.Value
+		case "LayoutCount":
+			metrics.LayoutCount = int64(m.Value)
+		case "RecalcStyleCount":
+			metrics.RecalcStyleCount = int64(m.Value)
+		case "ScriptDuration":
+			metrics.ScriptDuration = m.Value
+		case "TaskDuration":
+			metrics.TaskDuration = m.Value
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package report
+
+import (
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spider-crawler/spider/internal/config"
+)
+
+// ParamHandling is the recommended handling of a query parameter.
+type ParamHandling string
+
+const (
+	ParamKeep    ParamHandling = "keep"    // Changes content that should be crawled
+	ParamIgnore  ParamHandling = "ignore"  // Strip from URLs (IgnoreQueryParams)
+	ParamExclude ParamHandling = "exclude" // Do not crawl URLs with it (ExcludePatterns)
+)
+
+// trackingParams never change page content.
+var trackingParams = map[string]bool{
+	"gclid": true, "dclid": true, "fbclid": true, "msclkid": true, "yclid": true,
+	"mc_cid": true, "mc_eid": true, "_ga": true, "_gl": true, "igshid": true,
+	"sessionid": true, "sid": true, "phpsessid": true, "jsessionid": true,
+}
+
+// canonicalCollapseRatio is the share of URLs whose canonical drops a
+// parameter for it to count as canonicalised.
+const canonicalCollapseRatio = 0.8
+
+// ParamStats summarises one query parameter across the crawl.
+type ParamStats struct {
+	Name           string
+	URLCount       int
+	ContentSame    int // URLs with the same content hash as without the parameter
+	ContentChanged int // URLs with a different content hash
+	Canonicalised  int // URLs whose canonical omits the parameter
+	Handling       ParamHandling
+	Reason         string
+	ExampleURL     string
+}
+
+// ChangesContent reports "Yes", "No" or "Unknown" if no URL could be
+// compared with its parameter-less counterpart.
+func (p *ParamStats) ChangesContent() string {
+	switch {
+	case p.ContentChanged > 0:
+		return "Yes"
+	case p.ContentSame > 0:
+		return "No"
+	default:
+		return "Unknown"
+	}
+}
+
+// paramPage is a crawled internal URL with a query string.
+type paramPage struct {
+	url       *url.URL
+	hash      string
+	canonical string
+}
+
+// QueryParams builds the inventory of query parameters used by internal
+// URLs, sorted by usage.
+func (g *Generator) QueryParams() ([]*ParamStats, error) {
+	urls, err := g.db.GetAllURLs()
+	if err != nil {
+		return nil, err
+	}
+
+	// Content hash of every crawled URL by query-independent key
+	hashes := make(map[string]string)
+	pages := make([]*paramPage, 0)
+	for _, u := range urls {
+		if !u.IsInternal {
+			continue
+		}
+		parsed, err := url.Parse(u.NormalizedURL)
+		if err != nil {
+			continue
+		}
+
+		page := &paramPage{url: parsed}
+		if features, err := g.db.GetHTMLFeatures(u.ID); err == nil && features != nil {
+			page.hash = features.ContentHash
+			page.canonical = features.Canonical
+		}
+		if page.hash != "" {
+			hashes[paramKey(parsed, parsed.Query())] = page.hash
+		}
+		if parsed.RawQuery != "" {
+			pages = append(pages, page)
+		}
+	}
+
+	stats := make(map[string]*ParamStats)
+	for _, page := range pages {
+		query := page.url.Query()
+		for name := range query {
+			ps, ok := stats[name]
+			if !ok {
+				ps = &ParamStats{Name: name, ExampleURL: page.url.String()}
+				stats[name] = ps
+			}
+			ps.URLCount++
+
+			// Compare with the same URL without the parameter
+			if page.hash != "" {
+				without := cloneValues(query)
+				without.Del(name)
+				if hash, ok := hashes[paramKey(page.url, without)]; ok {
+					if hash == page.hash {
+						ps.ContentSame++
+					} else {
+						ps.ContentChanged++
+					}
+				}
+			}
+
+			if page.canonical != "" && !canonicalHasParam(page.url, page.canonical, name) {
+				ps.Canonicalised++
+			}
+		}
+	}
+
+	result := make([]*ParamStats, 0, len(stats))
+	for _, ps := range stats {
+		ps.Handling, ps.Reason = recommendParam(ps)
+		result = append(result, ps)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].URLCount != result[j].URLCount {
+			return result[i].URLCount > result[j].URLCount
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result, nil
+}
+
+// recommendParam picks the handling for a parameter.
+func recommendParam(ps *ParamStats) (ParamHandling, string) {
+	name := strings.ToLower(ps.Name)
+	canonicalised := float64(ps.Canonicalised) >= canonicalCollapseRatio*float64(ps.URLCount)
+
+	switch {
+	case strings.HasPrefix(name, "utm_") || trackingParams[name]:
+		return ParamIgnore, "tracking or session parameter"
+	case ps.ContentSame > 0 && ps.ContentChanged == 0:
+		return ParamIgnore, "does not change content"
+	case canonicalised && ps.ContentChanged > 0:
+		return ParamExclude, "changes content but is canonicalised away (faceted navigation)"
+	case canonicalised:
+		return ParamIgnore, "canonical tags drop the parameter"
+	case ps.ContentChanged > 0:
+		return ParamKeep, "changes content and is indexable"
+	default:
+		return ParamKeep, "no evidence it can be dropped"
+	}
+}
+
+// QueryParamPatch returns the config patch applying the ignore and
+// exclude recommendations.
+func QueryParamPatch(stats []*ParamStats) *config.Patch {
+	patch := &config.Patch{}
+	for _, ps := range stats {
+		switch ps.Handling {
+		case ParamIgnore:
+			patch.IgnoreQueryParams = append(patch.IgnoreQueryParams, ps.Name)
+		case ParamExclude:
+			patch.ExcludePatterns = append(patch.ExcludePatterns, `[?&]`+regexp.QuoteMeta(url.QueryEscape(ps.Name))+`=`)
+		}
+	}
+	return patch
+}
+
+// ExportQueryParamPatch writes the query parameter recommendations as a
+// config patch file (see "spider apply-patch").
+func (g *Generator) ExportQueryParamPatch(filePath string) error {
+	stats, err := g.QueryParams()
+	if err != nil {
+		return err
+	}
+	return QueryParamPatch(stats).Save(filePath)
+}
+
+// paramKey identifies a URL by scheme, host, path and sorted query.
+func paramKey(u *url.URL, query url.Values) string {
+	return u.Scheme + "://" + u.Host + u.EscapedPath() + "?" + query.Encode()
+}
+
+// canonicalHasParam reports whether the canonical URL of a page keeps the
+// parameter.
+func canonicalHasParam(page *url.URL, canonical, name string) bool {
+	c, err := url.Parse(canonical)
+	if err != nil {
+		return true
+	}
+	return page.ResolveReference(c).Query().Has(name)
+}
+
+func cloneValues(v url.Values) url.Values {
+	c := make(url.Values, len(v))
+	for k, values := range v {
+		c[k] = append([]string(nil), values...)
+	}
+	return c
+}
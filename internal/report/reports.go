@@ -2,6 +2,7 @@
 package report
 
 import (
+	"encoding/json"
 	"fmt"
 	"sort"
 	"strings"
@@ -24,6 +25,7 @@ const (
 	ReportDuplicateTitles     ReportType = "duplicate_titles"
 	ReportDuplicateMetaDesc   ReportType = "duplicate_meta_desc"
 	ReportDuplicateContent    ReportType = "duplicate_content"
+	ReportNearDuplicates      ReportType = "near_duplicates"
 	ReportMissingAlt          ReportType = "missing_alt"
 	ReportOrphanURLs          ReportType = "orphan_urls"
 	ReportNonIndexable        ReportType = "non_indexable"
@@ -32,6 +34,11 @@ const (
 	ReportAllIssues           ReportType = "all_issues"
 	ReportSEOOverview         ReportType = "seo_overview"
 	ReportCrawlSummary        ReportType = "crawl_summary"
+	ReportURLRewrites         ReportType = "url_rewrites"
+	ReportCrawlTraps          ReportType = "crawl_traps"
+	ReportQueryParams         ReportType = "query_parameters"
+	ReportHostCanonical       ReportType = "host_canonicalisation"
+	ReportSoft404             ReportType = "soft_404"
 )
 
 // ReportDefinition defines a report type.
@@ -51,6 +58,8 @@ func AllReports() []*ReportDefinition {
 		{ReportRedirectChains, "Redirect Chains", "URLs with redirect chains", "Response Codes", []string{"Source URL", "Chain Length", "Final URL", "Chain"}},
 		{ReportClientErrors, "Client Errors (4xx)", "All URLs returning 4xx status codes", "Response Codes", []string{"URL", "Status Code", "Found On", "Anchor Text"}},
 		{ReportServerErrors, "Server Errors (5xx)", "All URLs returning 5xx status codes", "Response Codes", []string{"URL", "Status Code", "Found On"}},
+		{ReportSoft404, "Soft 404", "2xx pages that look like error pages", "Response Codes", []string{"URL", "Confidence", "Signals"}},
+		{ReportHostCanonical, "Host Canonicalisation", "Duplicate URL variants of each seed and whether they 301 to the canonical form in one hop", "Response Codes", []string{"Host", "Canonical URL", "Variant", "Variant URL", "Status Code", "Redirects To", "Hops", "Verdict", "Pass", "Detail"}},
 
 		// On-Page
 		{ReportMissingTitles, "Missing Titles", "Pages without title tags", "On-Page", []string{"URL", "Status Code", "Indexability"}},
@@ -58,7 +67,8 @@ func AllReports() []*ReportDefinition {
 		{ReportMissingH1, "Missing H1", "Pages without H1 headings", "On-Page", []string{"URL", "Status Code", "Title"}},
 		{ReportDuplicateTitles, "Duplicate Titles", "Pages with duplicate title tags", "On-Page", []string{"Title", "Count", "URLs"}},
 		{ReportDuplicateMetaDesc, "Duplicate Meta Descriptions", "Pages with duplicate meta descriptions", "On-Page", []string{"Meta Description", "Count", "URLs"}},
-		{ReportDuplicateContent, "Duplicate Content", "Pages with duplicate content", "On-Page", []string{"Content Hash", "Count", "URLs"}},
+		{ReportDuplicateContent, "Duplicate Content", "Pages with duplicate content or duplicate main content", "On-Page", []string{"Scope", "Content Hash", "Count", "URLs"}},
+		{ReportNearDuplicates, "Near Duplicates", "Clusters of pages with near-identical visible text or main content", "On-Page", []string{"Scope", "Cluster", "Cluster Size", "URL", "Closest Match", "Similarity"}},
 
 		// Canonicals
 		{ReportCanonicalErrors, "Canonical Errors", "Pages with canonical issues", "Canonicals", []string{"URL", "Canonical", "Issue Type", "Details"}},
@@ -74,6 +84,11 @@ func AllReports() []*ReportDefinition {
 		// Indexability
 		{ReportNonIndexable, "Non-Indexable Pages", "Pages blocked from indexing", "Indexability", []string{"URL", "Reason", "Meta Robots", "X-Robots-Tag"}},
 
+		// URL
+		{ReportURLRewrites, "URL Rewrites", "URLs changed by rewrite rules before crawling", "URL", []string{"Original URL", "Rewritten URL", "Rules", "Found On"}},
+		{ReportQueryParams, "Query Parameters", "Query parameter inventory with recommended handling", "URL", []string{"Parameter", "URLs", "Changes Content", "Canonicalised", "Recommendation", "Reason", "Example URL"}},
+		{ReportCrawlTraps, "Crawl Traps", "URL patterns flagged as crawl traps", "URL", []string{"Pattern", "Reason", "Detail", "URLs Queued", "URLs Excluded", "Sample URLs"}},
+
 		// Summary
 		{ReportAllIssues, "All Issues", "Complete list of all detected issues", "Summary", []string{"URL", "Issue Type", "Severity", "Category", "Message"}},
 		{ReportSEOOverview, "SEO Overview", "High-level SEO metrics", "Summary", []string{"Metric", "Value", "Status"}},
@@ -140,6 +155,8 @@ func (g *Generator) Generate(reportType ReportType) (*Report, error) {
 		err = g.generateDuplicateMetaDesc(report)
 	case ReportDuplicateContent:
 		err = g.generateDuplicateContent(report)
+	case ReportNearDuplicates:
+		err = g.generateNearDuplicates(report)
 	case ReportMissingAlt:
 		err = g.generateMissingAlt(report)
 	case ReportOrphanURLs:
@@ -156,6 +173,16 @@ func (g *Generator) Generate(reportType ReportType) (*Report, error) {
 		err = g.generateSEOOverview(report)
 	case ReportCrawlSummary:
 		err = g.generateCrawlSummary(report)
+	case ReportURLRewrites:
+		err = g.generateURLRewrites(report)
+	case ReportCrawlTraps:
+		err = g.generateCrawlTraps(report)
+	case ReportQueryParams:
+		err = g.generateQueryParams(report)
+	case ReportHostCanonical:
+		err = g.generateHostCanonical(report)
+	case ReportSoft404:
+		err = g.generateSoft404(report)
 	default:
 		err = fmt.Errorf("report generator not implemented: %s", reportType)
 	}
@@ -208,6 +235,134 @@ func (g *Generator) generateAllRedirects(report *Report) error {
 	return nil
 }
 
+func (g *Generator) generateURLRewrites(report *Report) error {
+	rewrites, err := g.db.GetURLRewrites()
+	if err != nil {
+		return err
+	}
+
+	for _, rw := range rewrites {
+		report.Rows = append(report.Rows, &ReportRow{
+			Values: map[string]interface{}{
+				"Original URL":  rw.OriginalURL,
+				"Rewritten URL": rw.RewrittenURL,
+				"Rules":         strings.ReplaceAll(rw.Rules, "\n", "; "),
+				"Found On":      rw.DiscoveredFrom,
+			},
+		})
+	}
+	return nil
+}
+
+func (g *Generator) generateQueryParams(report *Report) error {
+	params, err := g.QueryParams()
+	if err != nil {
+		return err
+	}
+
+	for _, p := range params {
+		report.Rows = append(report.Rows, &ReportRow{
+			Values: map[string]interface{}{
+				"Parameter":       p.Name,
+				"URLs":            p.URLCount,
+				"Changes Content": p.ChangesContent(),
+				"Canonicalised":   fmt.Sprintf("%d/%d", p.Canonicalised, p.URLCount),
+				"Recommendation":  string(p.Handling),
+				"Reason":          p.Reason,
+				"Example URL":     p.ExampleURL,
+			},
+		})
+	}
+	return nil
+}
+
+func (g *Generator) generateCrawlTraps(report *Report) error {
+	traps, err := g.db.GetCrawlTraps()
+	if err != nil {
+		return err
+	}
+
+	for _, trap := range traps {
+		report.Rows = append(report.Rows, &ReportRow{
+			Values: map[string]interface{}{
+				"Pattern":       trap.Pattern,
+				"Reason":        trap.Reason,
+				"Detail":        trap.Detail,
+				"URLs Queued":   trap.URLCount,
+				"URLs Excluded": trap.ExcludedCount,
+				"Sample URLs":   strings.ReplaceAll(trap.SampleURLs, "\n", " | "),
+			},
+		})
+	}
+	return nil
+}
+
+func (g *Generator) generateSoft404(report *Report) error {
+	issues, err := g.db.GetAllIssues()
+	if err != nil {
+		return err
+	}
+
+	type soft404 struct {
+		url        string
+		Confidence float64  `json:"confidence"`
+		Signals    []string `json:"signals"`
+	}
+	pages := make([]*soft404, 0)
+	for _, issue := range issues {
+		if issue.IssueCode != storage.IssueSoft404 {
+			continue
+		}
+		page := &soft404{}
+		json.Unmarshal([]byte(issue.Details), page)
+		if url, _ := g.db.GetURLByID(issue.URLID); url != nil {
+			page.url = url.URL
+		}
+		pages = append(pages, page)
+	}
+	sort.SliceStable(pages, func(i, j int) bool { return pages[i].Confidence > pages[j].Confidence })
+
+	for _, page := range pages {
+		report.Rows = append(report.Rows, &ReportRow{
+			Values: map[string]interface{}{
+				"URL":        page.url,
+				"Confidence": fmt.Sprintf("%.0f%%", page.Confidence*100),
+				"Signals":    strings.Join(page.Signals, "; "),
+			},
+		})
+	}
+	return nil
+}
+
+func (g *Generator) generateHostCanonical(report *Report) error {
+	variants, err := g.db.GetHostVariants()
+	if err != nil {
+		return err
+	}
+
+	for _, v := range variants {
+		pass := "No"
+		if v.Passed {
+			pass = "Yes"
+		}
+		report.Rows = append(report.Rows, &ReportRow{
+			Values: map[string]interface{}{
+				"Host":          v.Host,
+				"Canonical URL": v.CanonicalURL,
+				"Variant":       v.Variant,
+				"Variant URL":   v.URL,
+				"Status Code":   v.StatusCode,
+				"Redirects To":  v.RedirectURL,
+				"Hops":          v.Hops,
+				"Verdict":       v.Verdict,
+				"Pass":          pass,
+				"Detail":        v.Detail,
+			},
+		})
+	}
+	return nil
+}
+
 func (g *Generator) generateRedirectChains(report *Report) error {
 	chains, err := g.db.GetRedirectChains()
 	if err != nil {
@@ -550,19 +705,27 @@ func (g *Generator) generateDuplicateContent(report *Report) error {
 		return err
 	}
 
-	// Group by content hash
+	// Group by content hash and by main content hash
 	hashURLs := make(map[string][]string)
+	mainHashURLs := make(map[string][]string)
+	fullHashes := make(map[string]string) // URL -> content hash
 	for _, url := range urls {
 		if !url.IsInternal {
 			continue
 		}
 
 		features, err := g.db.GetHTMLFeatures(url.ID)
-		if err != nil || features == nil || features.ContentHash == "" {
+		if err != nil || features == nil {
 			continue
 		}
 
-		hashURLs[features.ContentHash] = append(hashURLs[features.ContentHash], url.URL)
+		if features.ContentHash != "" {
+			hashURLs[features.ContentHash] = append(hashURLs[features.ContentHash], url.URL)
+			fullHashes[url.URL] = features.ContentHash
+		}
+		if features.MainContentHash != "" {
+			mainHashURLs[features.MainContentHash] = append(mainHashURLs[features.MainContentHash], url.URL)
+		}
 	}
 
 	// Find duplicates
@@ -570,6 +733,7 @@ func (g *Generator) generateDuplicateContent(report *Report) error {
 		if len(urlList) > 1 {
 			report.Rows = append(report.Rows, &ReportRow{
 				Values: map[string]interface{}{
+					"Scope":        "Full Text",
 					"Content Hash": hash[:16] + "...", // Truncate for display
 					"Count":        len(urlList),
 					"URLs":         strings.Join(urlList, "\n"),
@@ -577,6 +741,70 @@ func (g *Generator) generateDuplicateContent(report *Report) error {
 			})
 		}
 	}
+
+	// Main content duplicates not already listed as full duplicates
+	for hash, urlList := range mainHashURLs {
+		if len(urlList) < 2 {
+			continue
+		}
+		sameFull := fullHashes[urlList[0]] != ""
+		for _, u := range urlList[1:] {
+			if fullHashes[u] != fullHashes[urlList[0]] {
+				sameFull = false
+				break
+			}
+		}
+		if sameFull {
+			continue
+		}
+		report.Rows = append(report.Rows, &ReportRow{
+			Values: map[string]interface{}{
+				"Scope":        "Main Content",
+				"Content Hash": hash[:16] + "...",
+				"Count":        len(urlList),
+				"URLs":         strings.Join(urlList, "\n"),
+			},
+		})
+	}
+	return nil
+}
+
+func (g *Generator) generateNearDuplicates(report *Report) error {
+	matches, err := g.db.GetNearDuplicates()
+	if err != nil {
+		return err
+	}
+
+	urls := make(map[int64]string)
+	lookup := func(id int64) string {
+		if u, ok := urls[id]; ok {
+			return u
+		}
+		if url, _ := g.db.GetURLByID(id); url != nil {
+			urls[id] = url.URL
+		}
+		return urls[id]
+	}
+
+	scopes := map[string]string{
+		storage.NearDuplicateScopeFull: "Full Text",
+		storage.NearDuplicateScopeMain: "Main Content",
+	}
+	for _, m := range matches {
+		if m.ClusterID == 0 {
+			continue
+		}
+		report.Rows = append(report.Rows, &ReportRow{
+			Values: map[string]interface{}{
+				"Scope":         scopes[m.Scope],
+				"Cluster":       m.ClusterID,
+				"Cluster Size":  m.ClusterSize,
+				"URL":           lookup(m.URLID),
+				"Closest Match": lookup(m.ClosestURLID),
+				"Similarity":    fmt.Sprintf("%.0f%%", m.Similarity*100),
+			},
+		})
+	}
 	return nil
 }
 
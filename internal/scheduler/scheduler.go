@@ -2,16 +2,22 @@ package scheduler
 
 import (
 	"context"
+	"fmt"
+	"net/url"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/spider-crawler/spider/internal/config"
 	"github.com/spider-crawler/spider/internal/frontier"
+	"github.com/spider-crawler/spider/internal/parser"
+	"github.com/spider-crawler/spider/internal/storage"
 	"github.com/spider-crawler/spider/internal/urlutil"
 )
 
-// WorkerFunc is the function signature for URL processing workers.
+// WorkerFunc is the function signature for URL processing workers. Items
+// with a Device must be fetched as that profile (see config.ForDevice).
 type WorkerFunc func(ctx context.Context, item *frontier.URLItem) (*CrawlResult, error)
 
 // CrawlResult represents the result of crawling a URL.
@@ -26,6 +32,23 @@ type CrawlResult struct {
 	Error         error
 	Retry         bool
 	DiscoveredURLs []string
+
+	// Client-side routes found while rendering, queued as js_route links
+	DiscoveredRoutes []string
+
+	// Hash of the page content, used to detect crawl traps
+	ContentHash string
+
+	// The response was a logged-out page (see auth.Authenticator.SessionExpired)
+	SessionExpired bool
+
+	// Parsed page, needed for dual-device crawls
+	Page *parser.PageData
+
+	// Dual-device crawls: the URL fetched as CompareDevice, and the
+	// desktop/mobile comparison of both versions (nil if either failed)
+	Comparison *CrawlResult
+	Parity     *parser.DeviceParity
 }
 
 // SchedulerStats holds scheduler statistics.
@@ -38,6 +61,11 @@ type SchedulerStats struct {
 	URLsVisited    int
 	ActiveWorkers  int32
 	TotalDuplicates int
+	Reauthentications int64
+	URLsRewritten  int64
+	TrapsDetected  int
+	URLsTrapExcluded int64
+	Quotas         []frontier.QuotaUsage
 	StartTime      time.Time
 	ElapsedTime    time.Duration
 }
@@ -47,9 +75,13 @@ type Scheduler struct {
 	config      *config.CrawlConfig
 	frontier    *frontier.MemoryFrontier
 	normalizer  *urlutil.Normalizer
+	scope       *urlutil.Scope
 	rateLimiter *HostRateLimiter
 	workerFunc  WorkerFunc
 
+	// Normalizer for hash routes, which keeps the fragment
+	routeNormalizer *urlutil.Normalizer
+
 	// State
 	running       atomic.Bool
 	paused        atomic.Bool
@@ -70,20 +102,161 @@ type Scheduler struct {
 
 	// Results channel
 	resultsCh chan *CrawlResult
+
+	// Session renewal
+	session     SessionRefresher
+	onReauth    func(*ReauthEvent)
+	reauthMu    sync.Mutex
+	lastReauth  time.Time
+	reauthCount atomic.Int64
+
+	// URL rewriting
+	onRewrite    func(*RewriteEvent)
+	rewriteCount atomic.Int64
+
+	// Crawl trap detection (nil if disabled)
+	traps         *TrapDetector
+	onTrap        func(*Trap)
+	trapExcluded  atomic.Int64
+	trapMu        sync.Mutex
+	trapNext      map[string]time.Time // pattern -> earliest crawl of its next URL
+	trapsDetected []*Trap              // detected while queueing, not yet reported
+
+	// Crawl trap persistence (nil if not stored)
+	trapStore    TrapStore
+	trapStoreMu  sync.Mutex
+	trapStoreErr error
+}
+
+// TrapStore persists crawl traps for the Crawl Traps report.
+// It is implemented by storage.Database.
+type TrapStore interface {
+	SaveCrawlTrap(trap *storage.CrawlTrap) error
+}
+
+// SessionRefresher renews an expired login session.
+// It is implemented by auth.Authenticator.
+type SessionRefresher interface {
+	ExpireSession()
+	RefreshAuth() error
+}
+
+// ReauthEvent records a re-authentication during the crawl.
+type ReauthEvent struct {
+	Time     time.Time
+	URL      string // URL whose response showed the expired session
+	Duration time.Duration
+	Error    error
+}
+
+// SessionEvent converts the event for storage in the crawl session log.
+func (e *ReauthEvent) SessionEvent(sessionID int64) *storage.SessionEvent {
+	event := &storage.SessionEvent{
+		SessionID: sessionID,
+		EventType: storage.SessionEventReauth,
+		URL:       e.URL,
+		Message:   fmt.Sprintf("session expired, logged in again in %v", e.Duration.Round(time.Millisecond)),
+		CreatedAt: e.Time,
+	}
+	if e.Error != nil {
+		event.EventType = storage.SessionEventReauthFailed
+		event.Message = fmt.Sprintf("session expired, login failed: %v", e.Error)
+	}
+	return event
+}
+
+// URLRecord converts the crawled URL for storage.
+func (r *CrawlResult) URLRecord() *storage.URL {
+	item := r.Item
+	record := &storage.URL{
+		URL:           item.URL,
+		NormalizedURL: item.NormalizedURL,
+		Host:          item.Host,
+		Depth:         item.Depth,
+		FirstSeen:     item.AddedAt,
+		LastSeen:      time.Now(),
+		CrawlStatus:   "crawled",
+		IsInternal:    item.IsInternal,
+	}
+	if u, err := url.Parse(item.URL); err == nil {
+		record.Path = u.Path
+	}
+	if r.Error != nil {
+		record.CrawlStatus = "failed"
+	}
+	return record
+}
+
+// LinkRecords converts the links of the crawled page for storage.
+func (r *CrawlResult) LinkRecords(fromURLID int64) []*storage.Link {
+	if r.Page == nil {
+		return nil
+	}
+	links := make([]*storage.Link, 0, len(r.Page.Links))
+	for _, link := range r.Page.Links {
+		links = append(links, &storage.Link{
+			FromURLID:  fromURLID,
+			ToURL:      link.URL,
+			AnchorText: link.Text,
+			LinkType:   link.Type,
+			Rel:        link.Rel,
+			IsInternal: link.IsInternal,
+			IsFollow:   !link.NoFollow,
+		})
+	}
+	return links
+}
+
+// RewriteEvent records a URL changed by the configured rewrite rules.
+type RewriteEvent struct {
+	OriginalURL    string
+	RewrittenURL   string
+	Rules          []string // Patterns of the rules that changed the URL
+	DiscoveredFrom string
 }
 
+// URLRewrite converts the event for storage.
+func (e *RewriteEvent) URLRewrite() *storage.URLRewrite {
+	return &storage.URLRewrite{
+		OriginalURL:    e.OriginalURL,
+		RewrittenURL:   e.RewrittenURL,
+		Rules:          strings.Join(e.Rules, "\n"),
+		DiscoveredFrom: e.DiscoveredFrom,
+	}
+}
+
+// maxSessionRetries limits how often one URL is re-queued after logouts,
+// so a page that always looks logged out cannot loop forever.
+const maxSessionRetries = 2
+
 // NewScheduler creates a new scheduler.
 func NewScheduler(cfg *config.CrawlConfig) *Scheduler {
-	return &Scheduler{
+	s := &Scheduler{
 		config:     cfg,
 		frontier:   frontier.NewMemoryFrontier(cfg.TraversalMode, cfg.MaxDepth, cfg.MaxURLs),
 		normalizer: urlutil.DefaultNormalizer(cfg.IgnoreQueryParams),
+		scope:      urlutil.NewScope(cfg.IncludeSubdomains),
 		rateLimiter: NewHostRateLimiter(cfg.CrawlDelay, cfg.RequestsPerSecond),
 		pauseCh:    make(chan struct{}),
 		resumeCh:   make(chan struct{}),
 		stopCh:     make(chan struct{}),
 		resultsCh:  make(chan *CrawlResult, cfg.Concurrency*2),
 	}
+	routeNormalizer := *s.normalizer
+	routeNormalizer.RemoveFragment = false
+	s.routeNormalizer = &routeNormalizer
+	if cfg.DetectTraps {
+		quota := 0
+		if cfg.TrapAutoExclude {
+			quota = cfg.TrapQuota
+		}
+		s.traps = NewTrapDetector(quota)
+		s.trapNext = make(map[string]time.Time)
+		s.frontier.SetAdmitFunc(s.checkTrap)
+	}
+	// Quota patterns were already checked by config.Validate
+	_ = s.frontier.SetQuotas(cfg.QuotaRules)
+	return s
 }
 
 // SetWorkerFunc sets the worker function for processing URLs.
@@ -91,8 +264,143 @@ func (s *Scheduler) SetWorkerFunc(fn WorkerFunc) {
 	s.workerFunc = fn
 }
 
+// SetSessionRefresher enables re-authentication when a worker reports an
+// expired session. A refresher that binds credentials to hosts is given
+// the crawl scope, so that they follow the seeds added with AddSeed.
+func (s *Scheduler) SetSessionRefresher(r SessionRefresher) {
+	s.session = r
+	if scoped, ok := r.(interface{ SetScope(*urlutil.Scope) }); ok {
+		scoped.SetScope(s.scope)
+	}
+}
+
+// OnReauth sets a callback invoked after every re-authentication attempt.
+func (s *Scheduler) OnReauth(fn func(*ReauthEvent)) {
+	s.onReauth = fn
+}
+
+// OnTrap sets a callback invoked when a crawl trap is detected.
+func (s *Scheduler) OnTrap(fn func(*Trap)) {
+	s.onTrap = fn
+}
+
+// SetTrapStore stores crawl traps as they are detected, and their final
+// URL counts when the crawl ends.
+func (s *Scheduler) SetTrapStore(store TrapStore) {
+	s.trapStore = store
+}
+
+// TrapStoreError returns the first error storing a crawl trap, if any.
+func (s *Scheduler) TrapStoreError() error {
+	s.trapStoreMu.Lock()
+	defer s.trapStoreMu.Unlock()
+	return s.trapStoreErr
+}
+
+// reportTrap notifies the OnTrap callback and the trap store of a newly
+// detected trap.
+func (s *Scheduler) reportTrap(trap *Trap) {
+	if s.onTrap != nil {
+		s.onTrap(trap)
+	}
+	s.saveTrap(trap)
+}
+
+// saveTrap stores a trap, keeping the first error.
+func (s *Scheduler) saveTrap(trap *Trap) {
+	if s.trapStore == nil {
+		return
+	}
+	if err := s.trapStore.SaveCrawlTrap(trap.CrawlTrap()); err != nil {
+		s.trapStoreMu.Lock()
+		if s.trapStoreErr == nil {
+			s.trapStoreErr = fmt.Errorf("failed to store crawl trap %s: %w", trap.Pattern, err)
+		}
+		s.trapStoreMu.Unlock()
+	}
+}
+
+// Traps returns the crawl traps detected so far.
+func (s *Scheduler) Traps() []*Trap {
+	if s.traps == nil {
+		return nil
+	}
+	return s.traps.Traps()
+}
+
+// checkTrap applies crawl trap throttling to an item the frontier is about
+// to queue, after its depth, URL limit and quota checks. It returns false
+// if the item's trap pattern has reached its quota, and otherwise spaces
+// the URLs of a trap pattern TrapDelay apart. It runs with the frontier
+// locked, so new traps are reported later by reportDetectedTraps.
+func (s *Scheduler) checkTrap(item *frontier.URLItem) bool {
+	trap, detected, admit := s.traps.Check(item.NormalizedURL)
+	if trap == nil {
+		return true
+	}
+
+	s.trapMu.Lock()
+	defer s.trapMu.Unlock()
+	if detected {
+		s.trapsDetected = append(s.trapsDetected, trap)
+	}
+	if !admit {
+		s.trapExcluded.Add(1)
+		return false
+	}
+
+	next := s.trapNext[trap.Pattern]
+	if next.After(item.ScheduledAt) {
+		item.ScheduledAt = next
+	}
+	s.trapNext[trap.Pattern] = item.ScheduledAt.Add(s.config.TrapDelay)
+	return true
+}
+
+// reportDetectedTraps reports the traps detected by checkTrap.
+func (s *Scheduler) reportDetectedTraps() {
+	if s.traps == nil {
+		return
+	}
+	s.trapMu.Lock()
+	traps := s.trapsDetected
+	s.trapsDetected = nil
+	s.trapMu.Unlock()
+
+	for _, trap := range traps {
+		s.reportTrap(trap)
+	}
+}
+
+// OnRewrite sets a callback invoked for every URL changed by a rewrite rule.
+func (s *Scheduler) OnRewrite(fn func(*RewriteEvent)) {
+	s.onRewrite = fn
+}
+
+// rewrite applies the configured rewrite rules to a discovered URL and
+// reports the change. It returns the original URL if a rule applied.
+func (s *Scheduler) rewrite(rawURL, discoveredFrom string) (rewritten, original string) {
+	rewritten, rules := s.config.RewriteURL(rawURL)
+	if len(rules) == 0 {
+		return rawURL, ""
+	}
+
+	s.rewriteCount.Add(1)
+	if s.onRewrite != nil {
+		s.onRewrite(&RewriteEvent{
+			OriginalURL:    rawURL,
+			RewrittenURL:   rewritten,
+			Rules:          rules,
+			DiscoveredFrom: discoveredFrom,
+		})
+	}
+	return rewritten, rawURL
+}
+
 // AddSeed adds a seed URL to the frontier.
 func (s *Scheduler) AddSeed(rawURL string) error {
+	rawURL, original := s.rewrite(rawURL, "")
+
 	normalized, err := s.normalizer.Normalize(rawURL)
 	if err != nil {
 		return err
@@ -103,14 +411,47 @@ func (s *Scheduler) AddSeed(rawURL string) error {
 		return err
 	}
 
+	if err := s.scope.AddSeed(rawURL); err != nil {
+		return err
+	}
+
 	item := frontier.NewURLItem(rawURL, normalized, host, 0, "")
+	item.OriginalURL = original
+	item.IsInternal = true
 	s.frontier.Push(item)
 	return nil
 }
 
+// IsInternal checks if a URL is on a seed host or, with IncludeSubdomains,
+// on a seed's registrable domain.
+func (s *Scheduler) IsInternal(rawURL string) bool {
+	return s.scope.IsInternal(rawURL)
+}
+
+// classifyLinks marks the links of a page that are inside the crawl scope.
+func (s *Scheduler) classifyLinks(page *parser.PageData) {
+	for i := range page.Links {
+		page.Links[i].IsInternal = s.IsInternal(page.Links[i].URL)
+	}
+}
+
 // AddURL adds a discovered URL to the frontier.
 func (s *Scheduler) AddURL(rawURL, discoveredFrom string, depth int) error {
-	normalized, err := s.normalizer.Normalize(rawURL)
+	return s.AddURLWithType(rawURL, discoveredFrom, depth, "")
+}
+
+// AddURLWithType adds a discovered URL and records how it was found.
+func (s *Scheduler) AddURLWithType(rawURL, discoveredFrom string, depth int, linkType string) error {
+	rawURL, original := s.rewrite(rawURL, discoveredFrom)
+
+	// Hash routes such as "/#/about" are distinct pages of a client-side
+	// app; other fragments are anchors within one page
+	normalizer := s.normalizer
+	if linkType == parser.LinkTypeJSRoute && isHashRoute(rawURL) {
+		normalizer = s.routeNormalizer
+	}
+
+	normalized, err := normalizer.Normalize(rawURL)
 	if err != nil {
 		return err
 	}
@@ -121,10 +462,21 @@ func (s *Scheduler) AddURL(rawURL, discoveredFrom string, depth int) error {
 	}
 
 	item := frontier.NewURLItem(rawURL, normalized, host, depth, discoveredFrom)
+	item.LinkType = linkType
+	item.OriginalURL = original
+	item.IsInternal = s.IsInternal(rawURL)
 	s.frontier.Push(item)
+	s.reportDetectedTraps()
 	return nil
 }
 
+// isHashRoute reports whether the fragment of a URL is a client-side route,
+// as in "#/about" or "#!/about".
+func isHashRoute(rawURL string) bool {
+	_, fragment, ok := strings.Cut(rawURL, "#")
+	return ok && (strings.HasPrefix(fragment, "/") || strings.HasPrefix(fragment, "!/"))
+}
+
 // Start begins the crawling process.
 func (s *Scheduler) Start(ctx context.Context) error {
 	if s.workerFunc == nil {
@@ -156,10 +508,12 @@ func (s *Scheduler) worker(ctx context.Context, id int) {
 		default:
 		}
 
-		// Check if paused
-		if s.paused.Load() {
+		// Check if paused. Poll as well, since Resume does not block on
+		// workers that have not reached the select yet.
+		for s.paused.Load() {
 			select {
 			case <-s.resumeCh:
+			case <-time.After(100 * time.Millisecond):
 			case <-ctx.Done():
 				return
 			case <-s.stopCh:
@@ -191,6 +545,7 @@ func (s *Scheduler) worker(ctx context.Context, id int) {
 		s.rateLimiter.Wait(item.Host)
 
 		// Process the URL
+		requestStart := time.Now()
 		s.activeWorkers.Add(1)
 		result, err := s.workerFunc(ctx, item)
 		s.activeWorkers.Add(-1)
@@ -198,6 +553,16 @@ func (s *Scheduler) worker(ctx context.Context, id int) {
 		// Record host access
 		s.rateLimiter.RecordAccess(item.Host)
 
+		// Discard logged-out responses and crawl the URL again after login
+		if err == nil && result != nil && result.SessionExpired && s.session != nil &&
+			item.SessionRetries < maxSessionRetries {
+			if s.renewSession(ctx, item, requestStart) {
+				item.SessionRetries++
+				s.frontier.Requeue(item)
+				continue
+			}
+		}
+
 		// Mark as visited
 		s.frontier.MarkVisited(item.NormalizedURL)
 		s.urlsProcessed.Add(1)
@@ -215,11 +580,30 @@ func (s *Scheduler) worker(ctx context.Context, id int) {
 		} else {
 			s.urlsSucceeded.Add(1)
 
+			// Content shared by many URLs of one pattern marks a trap
+			if result != nil && s.traps != nil {
+				if trap := s.traps.ObserveContent(item.NormalizedURL, result.ContentHash); trap != nil {
+					s.reportTrap(trap)
+				}
+			}
+
+			if result != nil && result.Page != nil {
+				s.classifyLinks(result.Page)
+			}
+
+			// Fetch the URL again as the comparison device
+			if result != nil && result.Page != nil && s.config.CompareDevice != "" {
+				s.compareDevice(ctx, item, result)
+			}
+
 			// Add discovered URLs to frontier
 			if result != nil {
 				for _, discoveredURL := range result.DiscoveredURLs {
 					s.AddURL(discoveredURL, item.URL, item.Depth+1)
 				}
+				for _, route := range result.DiscoveredRoutes {
+					s.AddURLWithType(route, item.URL, item.Depth+1, parser.LinkTypeJSRoute)
+				}
 			}
 		}
 
@@ -234,6 +618,75 @@ func (s *Scheduler) worker(ctx context.Context, id int) {
 	}
 }
 
+// compareDevice fetches a crawled URL again as the comparison device and
+// compares both versions. Whichever profile is mobile is compared as the
+// mobile version.
+func (s *Scheduler) compareDevice(ctx context.Context, item *frontier.URLItem, result *CrawlResult) {
+	compareItem := *item
+	compareItem.Device = s.config.CompareDevice
+
+	s.rateLimiter.Wait(item.Host)
+	s.activeWorkers.Add(1)
+	compare, err := s.workerFunc(ctx, &compareItem)
+	s.activeWorkers.Add(-1)
+	s.rateLimiter.RecordAccess(item.Host)
+
+	if compare == nil {
+		compare = &CrawlResult{Item: &compareItem, Error: err}
+	}
+	result.Comparison = compare
+	if err != nil || compare.Error != nil || compare.Page == nil {
+		return
+	}
+
+	desktop, mobile := result.Page, compare.Page
+	if primary := s.config.DeviceProfile(); primary != nil && primary.Mobile {
+		if other, ok := s.config.LookupDevice(s.config.CompareDevice); ok && !other.Mobile {
+			desktop, mobile = mobile, desktop
+		}
+	}
+	result.Parity = parser.CompareDevices(desktop, mobile)
+}
+
+// renewSession logs in again after a worker saw an expired session. Other
+// workers are paused and in-flight requests drained first. If another worker
+// already renewed the session after this request started, the URL is only
+// re-queued. Returns false if the login failed.
+func (s *Scheduler) renewSession(ctx context.Context, item *frontier.URLItem, requestStart time.Time) bool {
+	s.reauthMu.Lock()
+	defer s.reauthMu.Unlock()
+
+	if s.lastReauth.After(requestStart) {
+		return true
+	}
+
+	// Leave the scheduler paused if the user paused it meanwhile
+	wasPaused := s.paused.Swap(true)
+	for s.activeWorkers.Load() > 0 {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+
+	event := &ReauthEvent{Time: time.Now(), URL: item.URL}
+	s.session.ExpireSession()
+	event.Error = s.session.RefreshAuth()
+	event.Duration = time.Since(event.Time)
+
+	s.lastReauth = time.Now()
+	s.reauthCount.Add(1)
+	if s.onReauth != nil {
+		s.onReauth(event)
+	}
+
+	if !wasPaused {
+		s.Resume()
+	}
+	return event.Error == nil
+}
+
 // Stop stops the scheduler.
 func (s *Scheduler) Stop() {
 	s.running.Store(false)
@@ -243,6 +696,11 @@ func (s *Scheduler) Stop() {
 // Wait waits for all workers to complete.
 func (s *Scheduler) Wait() {
 	s.wg.Wait()
+
+	// Store the final URL counts of the traps
+	for _, trap := range s.Traps() {
+		s.saveTrap(trap)
+	}
 	close(s.resultsCh)
 }
 
@@ -290,6 +748,11 @@ func (s *Scheduler) Stats() SchedulerStats {
 		URLsVisited:     frontierStats.Visited,
 		ActiveWorkers:   s.activeWorkers.Load(),
 		TotalDuplicates: frontierStats.Duplicates,
+		Reauthentications: s.reauthCount.Load(),
+		URLsRewritten:  s.rewriteCount.Load(),
+		TrapsDetected:  s.traps.Count(),
+		URLsTrapExcluded: s.trapExcluded.Load(),
+		Quotas:          frontierStats.Quotas,
 		StartTime:       s.startTime,
 		ElapsedTime:     time.Since(s.startTime),
 	}
@@ -0,0 +1,348 @@
+package scheduler
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spider-crawler/spider/internal/storage"
+)
+
+// TrapReason describes why a URL pattern was flagged as a crawl trap.
+type TrapReason string
+
+const (
+	TrapRepeatedSegments  TrapReason = "repeated_segments"  // Relative-link loops such as /a/b/a/b/
+	TrapQueryCombinations TrapReason = "query_combinations" // Faceted filters producing endless parameter sets
+	TrapDateSequence      TrapReason = "date_sequence"      // Calendars paging through dates
+	TrapDuplicateContent  TrapReason = "duplicate_content"  // Many URLs serving the same content
+)
+
+// Trap detection thresholds
+const (
+	maxSegmentRepeats    = 3  // occurrences of one path segment
+	maxQueryCombinations = 50 // distinct parameter-name sets on one path
+	maxDistinctDates     = 30 // distinct dates in one URL pattern
+	maxSameContentURLs   = 20 // distinct URLs of one pattern with one content hash
+	maxTrapSamples       = 5
+)
+
+// Trap is a URL pattern flagged by the TrapDetector.
+type Trap struct {
+	Pattern    string // e.g. "example.com/a/b/a/b/*" or "example.com/calendar/{date}"
+	Reason     TrapReason
+	Detail     string
+	DetectedAt time.Time
+	URLs       int // URLs of the pattern queued since detection
+	Excluded   int // URLs dropped because the quota was reached
+	Samples    []string
+
+	match func(sig *urlSignature) bool
+}
+
+// CrawlTrap converts the trap for storage.
+func (t *Trap) CrawlTrap() *storage.CrawlTrap {
+	return &storage.CrawlTrap{
+		Pattern:       t.Pattern,
+		Reason:        string(t.Reason),
+		Detail:        t.Detail,
+		URLCount:      t.URLs,
+		ExcludedCount: t.Excluded,
+		SampleURLs:    strings.Join(t.Samples, "\n"),
+		DetectedAt:    t.DetectedAt,
+	}
+}
+
+// TrapDetector flags URL patterns that show the warning signs of crawl
+// traps, and throttles or excludes further URLs matching them.
+type TrapDetector struct {
+	mu          sync.Mutex
+	quota       int // 0 = no quota
+	traps       []*Trap
+	queryCombos map[string]map[string]struct{} // host+path -> parameter-name sets
+	dates       map[string]map[string]struct{} // pattern -> distinct dates
+	contentURLs map[string]map[string]struct{} // pattern+hash -> URLs
+}
+
+// NewTrapDetector creates a detector. With a quota, at most quota URLs of
+// each flagged pattern are admitted after detection.
+func NewTrapDetector(quota int) *TrapDetector {
+	return &TrapDetector{
+		quota:       quota,
+		queryCombos: make(map[string]map[string]struct{}),
+		dates:       make(map[string]map[string]struct{}),
+		contentURLs: make(map[string]map[string]struct{}),
+	}
+}
+
+// urlSignature is a URL with its numeric, date and ID parts generalized.
+type urlSignature struct {
+	host     string
+	segments []string // raw path segments
+	path     string   // generalized path, e.g. /calendar/{date}
+	params   string   // sorted parameter names, e.g. color&size
+	dates    []string // dates found in the path and query values
+}
+
+var (
+	datePattern  = regexp.MustCompile(`^(19|20)\d{2}[-_.]?(0[1-9]|1[0-2])([-_.]?(0[1-9]|[12]\d|3[01]))?$`)
+	yearPattern  = regexp.MustCompile(`^(19|20)\d{2}$`)
+	monthPattern = regexp.MustCompile(`^(0?[1-9]|1[0-2])$`)
+	dayPattern   = regexp.MustCompile(`^(0?[1-9]|[12]\d|3[01])$`)
+	numPattern   = regexp.MustCompile(`^\d+$`)
+	idPattern    = regexp.MustCompile(`^([0-9a-fA-F]{16,}|[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12})$`)
+)
+
+func newURLSignature(u *url.URL) *urlSignature {
+	sig := &urlSignature{host: strings.ToLower(u.Host)}
+	sig.segments = strings.Split(strings.Trim(u.Path, "/"), "/")
+
+	generalized := make([]string, 0, len(sig.segments))
+	for i := 0; i < len(sig.segments); i++ {
+		segment := sig.segments[i]
+		switch {
+		case datePattern.MatchString(segment) && (!numPattern.MatchString(segment) || len(segment) == 8):
+			// 2024-05-12, 2024_05 or 20240512
+			sig.dates = append(sig.dates, segment)
+			generalized = append(generalized, "{date}")
+		case yearPattern.MatchString(segment) && i+1 < len(sig.segments) && monthPattern.MatchString(sig.segments[i+1]):
+			// /2024/05 or /2024/05/12
+			date := segment + "-" + sig.segments[i+1]
+			i++
+			if i+1 < len(sig.segments) && dayPattern.MatchString(sig.segments[i+1]) {
+				date += "-" + sig.segments[i+1]
+				i++
+			}
+			sig.dates = append(sig.dates, date)
+			generalized = append(generalized, "{date}")
+		case numPattern.MatchString(segment):
+			generalized = append(generalized, "{n}")
+		case idPattern.MatchString(segment):
+			generalized = append(generalized, "{id}")
+		default:
+			generalized = append(generalized, segment)
+		}
+	}
+	sig.path = "/" + strings.Join(generalized, "/")
+
+	query := u.Query()
+	names := make([]string, 0, len(query))
+	for name, values := range query {
+		names = append(names, name)
+		for _, v := range values {
+			if datePattern.MatchString(v) {
+				sig.dates = append(sig.dates, name+"="+v)
+			}
+		}
+	}
+	sort.Strings(names)
+	sig.params = strings.Join(names, "&")
+	return sig
+}
+
+// key identifies the generalized URL without its query.
+func (sig *urlSignature) key() string {
+	return sig.host + sig.path
+}
+
+// Check inspects a URL about to be queued, flagging new traps as needed.
+// It returns a copy of the trap the URL belongs to (nil if none), whether
+// the trap was detected by this URL, and whether the URL may be queued.
+func (d *TrapDetector) Check(rawURL string) (trap *Trap, detected, admit bool) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, false, true
+	}
+	sig := newURLSignature(u)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	t := d.matchLocked(sig)
+	if t == nil {
+		t = d.detectLocked(sig)
+		detected = t != nil
+	}
+	if t == nil {
+		return nil, false, true
+	}
+
+	admit = d.quota == 0 || t.URLs < d.quota
+	if admit {
+		t.URLs++
+		if len(t.Samples) < maxTrapSamples {
+			t.Samples = append(t.Samples, rawURL)
+		}
+	} else {
+		t.Excluded++
+	}
+	return t.copy(), detected, admit
+}
+
+// ObserveContent records the content hash of a crawled URL. It returns a
+// copy of the trap if this flagged the URL's pattern because too many
+// distinct URLs of it serve the same content.
+func (d *TrapDetector) ObserveContent(rawURL, contentHash string) *Trap {
+	if contentHash == "" {
+		return nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil
+	}
+	sig := newURLSignature(u)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.matchLocked(sig) != nil {
+		return nil
+	}
+
+	key := sig.key()
+	urls := addToSet(d.contentURLs, key+"\x00"+contentHash, rawURL)
+	if urls < maxSameContentURLs {
+		return nil
+	}
+	delete(d.contentURLs, key+"\x00"+contentHash)
+	trap := d.flagLocked(key, TrapDuplicateContent,
+		fmt.Sprintf("%d URLs return identical content", urls),
+		func(s *urlSignature) bool { return s.key() == key })
+	return trap.copy()
+}
+
+// Traps returns copies of the flagged traps in detection order.
+func (d *TrapDetector) Traps() []*Trap {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	traps := make([]*Trap, len(d.traps))
+	for i, t := range d.traps {
+		traps[i] = t.copy()
+	}
+	return traps
+}
+
+// Count returns the number of flagged traps. It is safe on a nil detector.
+func (d *TrapDetector) Count() int {
+	if d == nil {
+		return 0
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.traps)
+}
+
+func (t *Trap) copy() *Trap {
+	trapCopy := *t
+	trapCopy.Samples = append([]string(nil), t.Samples...)
+	return &trapCopy
+}
+
+func (d *TrapDetector) matchLocked(sig *urlSignature) *Trap {
+	for _, t := range d.traps {
+		if t.match(sig) {
+			return t
+		}
+	}
+	return nil
+}
+
+// detectLocked looks for warning signs in a URL not yet matching a trap.
+func (d *TrapDetector) detectLocked(sig *urlSignature) *Trap {
+	if prefix, segment, ok := repeatedSegments(sig.segments); ok {
+		pattern := sig.host + prefix + "*"
+		return d.flagLocked(pattern, TrapRepeatedSegments,
+			fmt.Sprintf("path segment %q repeats", segment),
+			func(s *urlSignature) bool {
+				return strings.HasPrefix(s.host+"/"+strings.Join(s.segments, "/"), sig.host+prefix)
+			})
+	}
+
+	key := sig.key()
+	if sig.params != "" {
+		if n := addToSet(d.queryCombos, key, sig.params); n > maxQueryCombinations {
+			delete(d.queryCombos, key)
+			return d.flagLocked(key+"?*", TrapQueryCombinations,
+				fmt.Sprintf("%d distinct parameter combinations", n),
+				func(s *urlSignature) bool { return s.key() == key && s.params != "" })
+		}
+	}
+
+	if len(sig.dates) > 0 {
+		dateKey := key
+		if sig.params != "" {
+			dateKey += "?" + sig.params
+		}
+		if n := addToSet(d.dates, dateKey, strings.Join(sig.dates, "|")); n > maxDistinctDates {
+			delete(d.dates, dateKey)
+			return d.flagLocked(dateKey, TrapDateSequence,
+				fmt.Sprintf("%d distinct dates", n),
+				func(s *urlSignature) bool {
+					k := s.key()
+					if s.params != "" {
+						k += "?" + s.params
+					}
+					return k == dateKey && len(s.dates) > 0
+				})
+		}
+	}
+
+	return nil
+}
+
+func (d *TrapDetector) flagLocked(pattern string, reason TrapReason, detail string, match func(*urlSignature) bool) *Trap {
+	trap := &Trap{
+		Pattern:    pattern,
+		Reason:     reason,
+		Detail:     detail,
+		DetectedAt: time.Now(),
+		match:      match,
+	}
+	d.traps = append(d.traps, trap)
+	return trap
+}
+
+// repeatedSegments reports a path in which a segment occurs
+// maxSegmentRepeats times or a run of segments repeats back to back. The
+// returned prefix ends after the first repetition, so that it covers every
+// deeper URL of the loop but not the legitimate pages beside it.
+func repeatedSegments(segments []string) (prefix, segment string, ok bool) {
+	counts := make(map[string]int)
+	for i, s := range segments {
+		if s == "" {
+			continue
+		}
+		counts[s]++
+		if counts[s] >= maxSegmentRepeats {
+			return "/" + strings.Join(segments[:i+1], "/") + "/", s, true
+		}
+	}
+
+	// Back-to-back runs of two or more segments: /a/b/a/b
+	for size := 2; size*2 <= len(segments); size++ {
+		for start := 0; start+size*2 <= len(segments); start++ {
+			first := segments[start : start+size]
+			second := segments[start+size : start+size*2]
+			if strings.Join(first, "/") == strings.Join(second, "/") {
+				return "/" + strings.Join(segments[:start+size*2], "/") + "/", strings.Join(first, "/"), true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// addToSet adds value to the set stored under key and returns its size.
+func addToSet(sets map[string]map[string]struct{}, key, value string) int {
+	set, ok := sets[key]
+	if !ok {
+		set = make(map[string]struct{})
+		sets[key] = set
+	}
+	set[value] = struct{}{}
+	return len(set)
+}
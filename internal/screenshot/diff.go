@@ -0,0 +1,229 @@
+package screenshot
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math/bits"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// pixelTolerance is the per-channel difference (0-255) ignored as JPEG noise.
+const pixelTolerance = 32
+
+// perceptualThreshold is the perceptual distance that marks a page as
+// changed whatever the pixel ratio: 7 of the 64 hash bits, a change in the
+// layout rather than in a few pixels.
+const perceptualThreshold = 0.1
+
+// Diff describes how much two screenshots differ.
+type Diff struct {
+	// Fraction of pixels that changed (0-1). Area present in only one of the
+	// images counts as changed.
+	PixelRatio float64
+
+	// Perceptual distance between difference hashes (0-1)
+	Perceptual float64
+
+	// Whether the size of the page changed
+	SizeChanged bool
+}
+
+// Compare computes the pixel and perceptual difference between two images.
+func Compare(a, b image.Image) *Diff {
+	ab, bb := a.Bounds(), b.Bounds()
+	w, h := max(ab.Dx(), bb.Dx()), max(ab.Dy(), bb.Dy())
+	ow, oh := min(ab.Dx(), bb.Dx()), min(ab.Dy(), bb.Dy())
+
+	d := &Diff{SizeChanged: ab.Dx() != bb.Dx() || ab.Dy() != bb.Dy()}
+	if w == 0 || h == 0 {
+		return d
+	}
+
+	changed := w*h - ow*oh
+	for y := 0; y < oh; y++ {
+		for x := 0; x < ow; x++ {
+			if pixelChanged(a.At(ab.Min.X+x, ab.Min.Y+y), b.At(bb.Min.X+x, bb.Min.Y+y)) {
+				changed++
+			}
+		}
+	}
+
+	d.PixelRatio = float64(changed) / float64(w*h)
+	d.Perceptual = float64(bits.OnesCount64(dHash(a)^dHash(b))) / 64
+	return d
+}
+
+// Changed reports whether the fraction of changed pixels exceeds the
+// threshold or the layout changed perceptibly.
+func (d *Diff) Changed(threshold float64) bool {
+	return d.PixelRatio > threshold || d.Perceptual >= perceptualThreshold
+}
+
+func pixelChanged(a, b color.Color) bool {
+	r1, g1, b1, _ := a.RGBA()
+	r2, g2, b2, _ := b.RGBA()
+	return channelDelta(r1, r2) > pixelTolerance ||
+		channelDelta(g1, g2) > pixelTolerance ||
+		channelDelta(b1, b2) > pixelTolerance
+}
+
+func channelDelta(a, b uint32) uint32 {
+	a, b = a>>8, b>>8
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+// dHash computes a 64-bit difference hash: the image is reduced to a 9x8
+// grayscale grid and each bit records whether brightness increases to the
+// right. Small rendering differences leave the hash unchanged.
+func dHash(img image.Image) uint64 {
+	b := img.Bounds()
+	if b.Dx() == 0 || b.Dy() == 0 {
+		return 0
+	}
+
+	var grid [8][9]float64
+	for gy := 0; gy < 8; gy++ {
+		for gx := 0; gx < 9; gx++ {
+			grid[gy][gx] = averageLuma(img,
+				b.Min.X+gx*b.Dx()/9, b.Min.Y+gy*b.Dy()/8,
+				b.Min.X+(gx+1)*b.Dx()/9, b.Min.Y+(gy+1)*b.Dy()/8)
+		}
+	}
+
+	var hash uint64
+	for gy := 0; gy < 8; gy++ {
+		for gx := 0; gx < 8; gx++ {
+			hash <<= 1
+			if grid[gy][gx] < grid[gy][gx+1] {
+				hash |= 1
+			}
+		}
+	}
+	return hash
+}
+
+// averageLuma returns the mean brightness of a region, sampling at most
+// 16x16 points.
+func averageLuma(img image.Image, x0, y0, x1, y1 int) float64 {
+	if x1 <= x0 {
+		x1 = x0 + 1
+	}
+	if y1 <= y0 {
+		y1 = y0 + 1
+	}
+	stepX, stepY := max(1, (x1-x0)/16), max(1, (y1-y0)/16)
+
+	var sum float64
+	var n int
+	for y := y0; y < y1; y += stepY {
+		for x := x0; x < x1; x += stepX {
+			r, g, b, _ := img.At(x, y).RGBA()
+			sum += 0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)
+			n++
+		}
+	}
+	return sum / float64(n)
+}
+
+// PageDiff is the comparison of one URL between two crawls.
+type PageDiff struct {
+	URL    string
+	Before *Entry
+	After  *Entry
+	Diff   *Diff
+
+	// Path of the highlighted diff image, if written
+	DiffImage string
+}
+
+// CompareStores compares every URL present in both stores. URLs whose
+// screenshots are byte-identical are skipped without decoding.
+func CompareStores(before, after *Store) ([]*PageDiff, error) {
+	diffs := make([]*PageDiff, 0)
+	for _, b := range before.Entries() {
+		a, ok := after.Get(b.URL)
+		if !ok {
+			continue
+		}
+
+		pd := &PageDiff{URL: b.URL, Before: b, After: a, Diff: &Diff{}}
+		if a.Hash != b.Hash {
+			imgBefore, err := before.Image(b)
+			if err != nil {
+				return nil, err
+			}
+			imgAfter, err := after.Image(a)
+			if err != nil {
+				return nil, err
+			}
+			pd.Diff = Compare(imgBefore, imgAfter)
+		}
+		diffs = append(diffs, pd)
+	}
+	return diffs, nil
+}
+
+// WriteDiffImage writes the after screenshot with changed pixels highlighted
+// in red to dir and returns the file path.
+func WriteDiffImage(dir string, pd *PageDiff, before, after *Store) (string, error) {
+	imgBefore, err := before.Image(pd.Before)
+	if err != nil {
+		return "", err
+	}
+	imgAfter, err := after.Image(pd.After)
+	if err != nil {
+		return "", err
+	}
+
+	ab, bb := imgAfter.Bounds(), imgBefore.Bounds()
+	out := image.NewRGBA(image.Rect(0, 0, ab.Dx(), ab.Dy()))
+	draw.Draw(out, out.Bounds(), imgAfter, ab.Min, draw.Src)
+
+	highlight := color.RGBA{R: 255, A: 255}
+	for y := 0; y < ab.Dy(); y++ {
+		for x := 0; x < ab.Dx(); x++ {
+			if x >= bb.Dx() || y >= bb.Dy() ||
+				pixelChanged(imgBefore.At(bb.Min.X+x, bb.Min.Y+y), imgAfter.At(ab.Min.X+x, ab.Min.Y+y)) {
+				out.Set(x, y, highlight)
+			}
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, diffFilename(pd))
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if err := png.Encode(f, out); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// diffFilename derives a file name from the URL, suffixed with the image
+// hash so that URLs differing only in punctuation do not collide.
+func diffFilename(pd *PageDiff) string {
+	name := strings.TrimPrefix(strings.TrimPrefix(pd.URL, "https://"), "http://")
+	name = strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '-' {
+			return r
+		}
+		return '_'
+	}, name)
+	if len(name) > 150 {
+		name = name[:150]
+	}
+	return name + "_" + pd.After.Hash[:12] + ".diff.png"
+}
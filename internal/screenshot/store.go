@@ -0,0 +1,235 @@
+// Package screenshot stores page screenshots and compares them across crawls.
+package screenshot
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/jpeg"
+	_ "image/png" // decode PNG captures
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	manifestFile = "manifest.jsonl"
+	imagesDir    = "images"
+)
+
+// Entry describes the screenshot stored for a URL.
+type Entry struct {
+	URL        string    `json:"url"`
+	Hash       string    `json:"hash"`
+	Width      int       `json:"width"`
+	Height     int       `json:"height"`
+	Size       int64     `json:"size"`
+	CapturedAt time.Time `json:"captured_at"`
+}
+
+// Store keeps one screenshot per URL in a directory. Images are stored once
+// per content hash, so identical screenshots share a file.
+type Store struct {
+	mu      sync.RWMutex
+	dir     string
+	quality int
+	pages   map[string]*Entry
+}
+
+// Open opens or creates a screenshot store in dir. Non-JPEG images are
+// re-encoded as JPEG with the given quality.
+func Open(dir string, quality int) (*Store, error) {
+	if err := os.MkdirAll(filepath.Join(dir, imagesDir), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create screenshot directory: %w", err)
+	}
+
+	if quality < 1 || quality > 100 {
+		quality = 80
+	}
+
+	s := &Store{
+		dir:     dir,
+		quality: quality,
+		pages:   make(map[string]*Entry),
+	}
+
+	if err := s.readManifest(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// NewCrawlDir creates a directory for the screenshots of one crawl under
+// root, named after the current time, so that crawls do not overwrite
+// each other's screenshots.
+func NewCrawlDir(root string) (string, error) {
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return "", fmt.Errorf("failed to create screenshot directory: %w", err)
+	}
+	base := time.Now().Format("20060102-150405")
+	name := base
+	for i := 2; ; i++ {
+		dir := filepath.Join(root, name)
+		err := os.Mkdir(dir, 0755)
+		if err == nil {
+			return dir, nil
+		}
+		if !os.IsExist(err) {
+			return "", fmt.Errorf("failed to create screenshot directory: %w", err)
+		}
+		name = fmt.Sprintf("%s-%d", base, i)
+	}
+}
+
+// Dir returns the store directory.
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Save stores the screenshot for a URL, replacing any previous one.
+func (s *Store) Save(pageURL string, data []byte) (*Entry, error) {
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode screenshot: %w", err)
+	}
+
+	// Compress lossless captures
+	if format != "jpeg" {
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: s.quality}); err != nil {
+			return nil, err
+		}
+		data = buf.Bytes()
+	}
+
+	sum := sha256.Sum256(data)
+	entry := &Entry{
+		URL:        pageURL,
+		Hash:       hex.EncodeToString(sum[:]),
+		Width:      img.Bounds().Dx(),
+		Height:     img.Bounds().Dy(),
+		Size:       int64(len(data)),
+		CapturedAt: time.Now(),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path := s.imagePath(entry.Hash)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			return nil, err
+		}
+	}
+
+	s.pages[pageURL] = entry
+	return entry, s.appendManifest(entry)
+}
+
+// Get returns the entry for a URL.
+func (s *Store) Get(pageURL string) (*Entry, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	e, ok := s.pages[pageURL]
+	return e, ok
+}
+
+// Entries returns all entries sorted by URL.
+func (s *Store) Entries() []*Entry {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entries := make([]*Entry, 0, len(s.pages))
+	for _, e := range s.pages {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].URL < entries[j].URL
+	})
+	return entries
+}
+
+// Path returns the image file path for an entry.
+func (s *Store) Path(e *Entry) string {
+	return s.imagePath(e.Hash)
+}
+
+// Image decodes the stored screenshot for an entry.
+func (s *Store) Image(e *Entry) (image.Image, error) {
+	f, err := os.Open(s.imagePath(e.Hash))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	return img, err
+}
+
+// Stats returns the number of pages and unique image files.
+func (s *Store) Stats() (pages, unique int) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	hashes := make(map[string]bool)
+	for _, e := range s.pages {
+		hashes[e.Hash] = true
+	}
+	return len(s.pages), len(hashes)
+}
+
+func (s *Store) imagePath(hash string) string {
+	return filepath.Join(s.dir, imagesDir, hash+".jpg")
+}
+
+// readManifest loads the index, one entry per line. A later entry for a
+// URL replaces an earlier one, and a line cut short by a crash is ignored.
+func (s *Store) readManifest() error {
+	f, err := os.Open(filepath.Join(s.dir, manifestFile))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil || e.URL == "" {
+			continue
+		}
+		s.pages[e.URL] = &e
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("invalid screenshot manifest: %w", err)
+	}
+	return nil
+}
+
+// appendManifest adds an entry to the index, so that saving a screenshot
+// costs the same however many the store holds. Caller must hold s.mu.
+func (s *Store) appendManifest(e *Entry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(filepath.Join(s.dir, manifestFile), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
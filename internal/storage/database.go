@@ -54,6 +54,11 @@ func (d *Database) Initialize() error {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
+	// Add new columns to existing tables first: the schema indexes them
+	if err := d.migrateColumns(); err != nil {
+		return err
+	}
+
 	// Create tables
 	if _, err := d.db.Exec(Schema); err != nil {
 		return fmt.Errorf("failed to create schema: %w", err)
@@ -67,6 +72,56 @@ func (d *Database) Initialize() error {
 	return nil
 }
 
+// migrateColumns adds the columns of ColumnMigrations missing from
+// existing tables. Tables that do not exist yet are left to the schema.
+func (d *Database) migrateColumns() error {
+	columns := make(map[string]map[string]bool)
+	for _, m := range ColumnMigrations {
+		if columns[m.Table] == nil {
+			existing, err := d.tableColumns(m.Table)
+			if err != nil {
+				return fmt.Errorf("failed to read columns of %s: %w", m.Table, err)
+			}
+			columns[m.Table] = existing
+		}
+		existing := columns[m.Table]
+		if len(existing) == 0 || existing[m.Column] {
+			continue
+		}
+
+		if _, err := d.db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", m.Table, m.Column, m.Definition)); err != nil {
+			return fmt.Errorf("failed to add column %s.%s: %w", m.Table, m.Column, err)
+		}
+		existing[m.Column] = true
+	}
+	return nil
+}
+
+// tableColumns returns the columns of a table, or none if it does not exist.
+func (d *Database) tableColumns(table string) (map[string]bool, error) {
+	rows, err := d.db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var (
+			cid        int
+			name, kind string
+			notNull    bool
+			dflt       sql.NullString
+			pk         int
+		)
+		if err := rows.Scan(&cid, &name, &kind, &notNull, &dflt, &pk); err != nil {
+			return nil, err
+		}
+		columns[name] = true
+	}
+	return columns, rows.Err()
+}
+
 // Close closes the database connection.
 func (d *Database) Close() error {
 	// Close prepared statements
@@ -195,9 +250,9 @@ func (d *Database) InsertHTMLFeatures(features *HTMLFeatures) (int64, error) {
 	result, err := d.db.Exec(`
 		INSERT INTO html_features (url_id, title, title_length, meta_description, meta_desc_length,
 			meta_keywords, meta_robots, canonical, canonical_url_id, h1_count, h1_first, h1_all,
-			h2_count, h2_all, word_count, content_hash, language, hreflangs, og_title, og_description,
-			og_image, is_indexable, index_status)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			h2_count, h2_all, word_count, char_count, content_hash, main_word_count, main_char_count, main_content_hash,
+			language, hreflangs, og_title, og_description, og_image, is_indexable, index_status)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(url_id) DO UPDATE SET
 			title = excluded.title,
 			title_length = excluded.title_length,
@@ -213,7 +268,11 @@ func (d *Database) InsertHTMLFeatures(features *HTMLFeatures) (int64, error) {
 			h2_count = excluded.h2_count,
 			h2_all = excluded.h2_all,
 			word_count = excluded.word_count,
+			char_count = excluded.char_count,
 			content_hash = excluded.content_hash,
+			main_word_count = excluded.main_word_count,
+			main_char_count = excluded.main_char_count,
+			main_content_hash = excluded.main_content_hash,
 			language = excluded.language,
 			hreflangs = excluded.hreflangs,
 			og_title = excluded.og_title,
@@ -224,8 +283,9 @@ func (d *Database) InsertHTMLFeatures(features *HTMLFeatures) (int64, error) {
 	`, features.URLID, features.Title, features.TitleLength, features.MetaDescription, features.MetaDescLength,
 		features.MetaKeywords, features.MetaRobots, features.Canonical, features.CanonicalURLID,
 		features.H1Count, features.H1First, features.H1All, features.H2Count, features.H2All,
-		features.WordCount, features.ContentHash, features.Language, features.Hreflangs,
-		features.OGTitle, features.OGDescription, features.OGImage, features.IsIndexable, features.IndexStatus)
+		features.WordCount, features.CharCount, features.ContentHash, features.MainWordCount, features.MainCharCount,
+		features.MainContentHash, features.Language, features.Hreflangs, features.OGTitle, features.OGDescription, features.OGImage,
+		features.IsIndexable, features.IndexStatus)
 
 	if err != nil {
 		return 0, err
@@ -462,6 +522,252 @@ func (d *Database) CompleteSession(id int64, status string) error {
 	return err
 }
 
+// AddSessionEvent records an event for a crawl session.
+func (d *Database) AddSessionEvent(event *SessionEvent) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	_, err := d.db.Exec(`
+		INSERT INTO session_events (session_id, event_type, url, message)
+		VALUES (?, ?, ?, ?)
+	`, event.SessionID, event.EventType, event.URL, event.Message)
+
+	return err
+}
+
+// GetSessionEvents returns the events of a crawl session in order.
+func (d *Database) GetSessionEvents(sessionID int64) ([]*SessionEvent, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	rows, err := d.db.Query(`
+		SELECT id, session_id, event_type, url, message, created_at
+		FROM session_events
+		WHERE session_id = ?
+		ORDER BY id
+	`, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := make([]*SessionEvent, 0)
+	for rows.Next() {
+		e := &SessionEvent{}
+		if err := rows.Scan(&e.ID, &e.SessionID, &e.EventType, &e.URL, &e.Message, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+
+	return events, rows.Err()
+}
+
+// AddURLRewrite records a rewritten URL. Only the first discovery of an
+// original URL is kept.
+func (d *Database) AddURLRewrite(rw *URLRewrite) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	_, err := d.db.Exec(`
+		INSERT OR IGNORE INTO url_rewrites (original_url, rewritten_url, rules, discovered_from)
+		VALUES (?, ?, ?, ?)
+	`, rw.OriginalURL, rw.RewrittenURL, rw.Rules, rw.DiscoveredFrom)
+
+	return err
+}
+
+// SaveCrawlTrap inserts a crawl trap or updates its counts and samples.
+func (d *Database) SaveCrawlTrap(trap *CrawlTrap) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	_, err := d.db.Exec(`
+		INSERT INTO crawl_traps (pattern, reason, detail, url_count, excluded_count, sample_urls, detected_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(pattern) DO UPDATE SET
+			url_count = excluded.url_count,
+			excluded_count = excluded.excluded_count,
+			sample_urls = excluded.sample_urls
+	`, trap.Pattern, trap.Reason, trap.Detail, trap.URLCount, trap.ExcludedCount, trap.SampleURLs, trap.DetectedAt)
+
+	return err
+}
+
+// GetCrawlTraps returns all crawl traps in detection order.
+func (d *Database) GetCrawlTraps() ([]*CrawlTrap, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	rows, err := d.db.Query(`
+		SELECT id, pattern, reason, COALESCE(detail, ''), url_count, excluded_count, COALESCE(sample_urls, ''), detected_at
+		FROM crawl_traps
+		ORDER BY detected_at, id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	traps := make([]*CrawlTrap, 0)
+	for rows.Next() {
+		t := &CrawlTrap{}
+		if err := rows.Scan(&t.ID, &t.Pattern, &t.Reason, &t.Detail, &t.URLCount, &t.ExcludedCount, &t.SampleURLs, &t.DetectedAt); err != nil {
+			return nil, err
+		}
+		traps = append(traps, t)
+	}
+
+	return traps, rows.Err()
+}
+
+// SaveNearDuplicates replaces the stored near-duplicate matches.
+func (d *Database) SaveNearDuplicates(matches []*NearDuplicate) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM near_duplicates`); err != nil {
+		return err
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO near_duplicates (url_id, scope, closest_url_id, similarity, cluster_id, cluster_size)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, m := range matches {
+		scope := m.Scope
+		if scope == "" {
+			scope = NearDuplicateScopeFull
+		}
+		if _, err := stmt.Exec(m.URLID, scope, m.ClosestURLID, m.Similarity, m.ClusterID, m.ClusterSize); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetNearDuplicates returns the stored near-duplicate matches by scope,
+// clustered pages first.
+func (d *Database) GetNearDuplicates() ([]*NearDuplicate, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	rows, err := d.db.Query(`
+		SELECT id, url_id, scope, closest_url_id, similarity, cluster_id, cluster_size
+		FROM near_duplicates
+		ORDER BY scope, cluster_id = 0, cluster_id, similarity DESC, url_id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	matches := make([]*NearDuplicate, 0)
+	for rows.Next() {
+		m := &NearDuplicate{}
+		if err := rows.Scan(&m.ID, &m.URLID, &m.Scope, &m.ClosestURLID, &m.Similarity, &m.ClusterID, &m.ClusterSize); err != nil {
+			return nil, err
+		}
+		matches = append(matches, m)
+	}
+
+	return matches, rows.Err()
+}
+
+// SaveHostVariant inserts a host variant probe or replaces the previous
+// probe of the same URL.
+func (d *Database) SaveHostVariant(v *HostVariant) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	_, err := d.db.Exec(`
+		INSERT INTO host_variants (host, canonical_url, variant, url, status_code, redirect_url, final_url, hops, verdict, passed, detail, checked_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(url) DO UPDATE SET
+			host = excluded.host,
+			canonical_url = excluded.canonical_url,
+			variant = excluded.variant,
+			status_code = excluded.status_code,
+			redirect_url = excluded.redirect_url,
+			final_url = excluded.final_url,
+			hops = excluded.hops,
+			verdict = excluded.verdict,
+			passed = excluded.passed,
+			detail = excluded.detail,
+			checked_at = excluded.checked_at
+	`, v.Host, v.CanonicalURL, v.Variant, v.URL, v.StatusCode, v.RedirectURL, v.FinalURL, v.Hops, v.Verdict, v.Passed, v.Detail, v.CheckedAt)
+
+	return err
+}
+
+// GetHostVariants returns all host variant probes grouped by host.
+func (d *Database) GetHostVariants() ([]*HostVariant, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	rows, err := d.db.Query(`
+		SELECT id, host, canonical_url, variant, url, COALESCE(status_code, 0), COALESCE(redirect_url, ''),
+			COALESCE(final_url, ''), hops, verdict, passed, COALESCE(detail, ''), checked_at
+		FROM host_variants
+		ORDER BY host, id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	variants := make([]*HostVariant, 0)
+	for rows.Next() {
+		v := &HostVariant{}
+		if err := rows.Scan(&v.ID, &v.Host, &v.CanonicalURL, &v.Variant, &v.URL, &v.StatusCode, &v.RedirectURL,
+			&v.FinalURL, &v.Hops, &v.Verdict, &v.Passed, &v.Detail, &v.CheckedAt); err != nil {
+			return nil, err
+		}
+		variants = append(variants, v)
+	}
+
+	return variants, rows.Err()
+}
+
+// GetURLRewrites returns all recorded rewrites in discovery order.
+func (d *Database) GetURLRewrites() ([]*URLRewrite, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	rows, err := d.db.Query(`
+		SELECT id, original_url, rewritten_url, COALESCE(rules, ''), COALESCE(discovered_from, ''), created_at
+		FROM url_rewrites
+		ORDER BY id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rewrites := make([]*URLRewrite, 0)
+	for rows.Next() {
+		rw := &URLRewrite{}
+		if err := rows.Scan(&rw.ID, &rw.OriginalURL, &rw.RewrittenURL, &rw.Rules, &rw.DiscoveredFrom, &rw.CreatedAt); err != nil {
+			return nil, err
+		}
+		rewrites = append(rewrites, rw)
+	}
+
+	return rewrites, rows.Err()
+}
+
 // --- Statistics ---
 
 // Stats holds database statistics.
@@ -637,15 +943,18 @@ func (d *Database) GetHTMLFeatures(urlID int64) (*HTMLFeatures, error) {
 	var features HTMLFeatures
 	err := d.db.QueryRow(`
 		SELECT id, url_id, title, title_length, meta_description, meta_desc_length, meta_keywords, meta_robots,
-			canonical, canonical_url_id, h1_count, h1_first, h1_all, h2_count, h2_all, word_count, content_hash,
-			language, hreflangs, og_title, og_description, og_image, is_indexable, index_status
+			canonical, canonical_url_id, h1_count, h1_first, h1_all, h2_count, h2_all, word_count, char_count, content_hash,
+			main_word_count, main_char_count, COALESCE(main_content_hash, ''), language, hreflangs, og_title, og_description, og_image,
+			is_indexable, index_status
 		FROM html_features
 		WHERE url_id = ?
 	`, urlID).Scan(
 		&features.ID, &features.URLID, &features.Title, &features.TitleLength, &features.MetaDescription,
 		&features.MetaDescLength, &features.MetaKeywords, &features.MetaRobots, &features.Canonical,
 		&features.CanonicalURLID, &features.H1Count, &features.H1First, &features.H1All, &features.H2Count,
-		&features.H2All, &features.WordCount, &features.ContentHash, &features.Language, &features.Hreflangs,
+		&features.H2All, &features.WordCount, &features.CharCount, &features.ContentHash, &features.MainWordCount,
+		&features.MainCharCount, &features.MainContentHash,
+		&features.Language, &features.Hreflangs,
 		&features.OGTitle, &features.OGDescription, &features.OGImage, &features.IsIndexable, &features.IndexStatus,
 	)
 
@@ -66,8 +66,14 @@ type HTMLFeatures struct {
 
 	// Content metrics
 	WordCount   int    `json:"word_count"`
+	CharCount   int    `json:"char_count"`   // Letters and digits
 	ContentHash string `json:"content_hash"` // For duplicate detection
 
+	// Main content metrics (boilerplate removed)
+	MainWordCount   int    `json:"main_word_count"`
+	MainCharCount   int    `json:"main_char_count"`
+	MainContentHash string `json:"main_content_hash"`
+
 	// Language
 	Language string `json:"language"`
 
@@ -91,7 +97,7 @@ type Link struct {
 	ToURL      string `json:"to_url"`
 	ToURLID    *int64 `json:"to_url_id,omitempty"`
 	AnchorText string `json:"anchor_text"`
-	LinkType   string `json:"link_type"` // a, link, area, form
+	LinkType   string `json:"link_type"` // a, link, area, form, js_route
 	Rel        string `json:"rel"`       // nofollow, sponsored, ugc, etc.
 	IsInternal bool   `json:"is_internal"`
 	IsFollow   bool   `json:"is_follow"`
@@ -168,6 +174,80 @@ type CrawlSession struct {
 	LastCheckpoint  time.Time `json:"last_checkpoint"`
 }
 
+// Session event types.
+const (
+	SessionEventReauth       = "reauth"        // Logged in again after the session expired
+	SessionEventReauthFailed = "reauth_failed" // Re-login after session expiry failed
+)
+
+// SessionEvent records a notable event during a crawl session.
+type SessionEvent struct {
+	ID        int64     `json:"id"`
+	SessionID int64     `json:"session_id"`
+	EventType string    `json:"event_type"`
+	URL       string    `json:"url,omitempty"`
+	Message   string    `json:"message,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// URLRewrite records a URL changed by a rewrite rule before it was queued.
+type URLRewrite struct {
+	ID             int64     `json:"id"`
+	OriginalURL    string    `json:"original_url"`
+	RewrittenURL   string    `json:"rewritten_url"`
+	Rules          string    `json:"rules"` // Applied rule patterns, newline-separated
+	DiscoveredFrom string    `json:"discovered_from,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// CrawlTrap records a URL pattern flagged as a crawl trap.
+type CrawlTrap struct {
+	ID            int64     `json:"id"`
+	Pattern       string    `json:"pattern"`
+	Reason        string    `json:"reason"` // repeated_segments, query_combinations, date_sequence, duplicate_content
+	Detail        string    `json:"detail,omitempty"`
+	URLCount      int       `json:"url_count"`      // URLs queued after detection
+	ExcludedCount int       `json:"excluded_count"` // URLs dropped by the quota
+	SampleURLs    string    `json:"sample_urls"`    // Newline-separated
+	DetectedAt    time.Time `json:"detected_at"`
+}
+
+// Near duplicate scopes: the text compared between pages.
+const (
+	NearDuplicateScopeFull = "full" // All visible text
+	NearDuplicateScopeMain = "main" // Main content, without boilerplate
+)
+
+// NearDuplicate stores the closest match of a page by visible text and
+// the near-duplicate cluster it belongs to.
+type NearDuplicate struct {
+	ID           int64   `json:"id"`
+	URLID        int64   `json:"url_id"`
+	Scope        string  `json:"scope"`
+	ClosestURLID int64   `json:"closest_url_id"`
+	Similarity   float64 `json:"similarity"` // Estimated Jaccard similarity of word shingles (0-1)
+	ClusterID    int     `json:"cluster_id"` // 0 = not a near duplicate at the threshold
+	ClusterSize  int     `json:"cluster_size"`
+}
+
+// HostVariant stores the probe of one duplicate variant of a seed URL,
+// such as its http or non-www form.
+type HostVariant struct {
+	ID           int64     `json:"id"`
+	Host         string    `json:"host"`
+	CanonicalURL string    `json:"canonical_url"`
+	Variant      string    `json:"variant"` // scheme, www, trailing_slash, uppercase, index_file, empty_query
+	URL          string    `json:"url"`
+	StatusCode   int       `json:"status_code"`
+	RedirectURL  string    `json:"redirect_url,omitempty"` // Target of the first hop
+	FinalURL     string    `json:"final_url,omitempty"`
+	Hops         int       `json:"hops"`
+	Verdict      string    `json:"verdict"` // ok, duplicate, not_served, chain, not_permanent, wrong_target, error
+	Passed       bool      `json:"passed"`
+	Detail       string    `json:"detail,omitempty"`
+	CheckedAt    time.Time `json:"checked_at"`
+}
+
 // Sitemap stores sitemap information.
 type Sitemap struct {
 	ID          int64     `json:"id"`
@@ -228,8 +308,26 @@ const (
 	IssueLargeImage   = "large_image"
 
 	// Content issues
-	IssueThinContent      = "thin_content"
-	IssueDuplicateContent = "duplicate_content"
+	IssueThinContent          = "thin_content"
+	IssueDuplicateContent     = "duplicate_content"
+	IssueNearDuplicate        = "near_duplicate_content"
+	IssueDuplicateMainContent = "duplicate_main_content"
+	IssueNearDuplicateMain    = "near_duplicate_main_content"
+	IssueDifficultToRead      = "difficult_to_read"
+
+	// Language issues
+	IssueLangMismatch            = "lang_mismatch"
+	IssueContentLanguageMismatch = "content_language_mismatch"
+
+	// Mobile parity issues
+	IssueMobileMissingContent        = "mobile_missing_content"
+	IssueMobileMissingLinks          = "mobile_missing_links"
+	IssueMobileMissingStructuredData = "mobile_missing_structured_data"
+	IssueMobileMetadataMismatch      = "mobile_metadata_mismatch"
+
+	// Rendering issues
+	IssueLinksAfterInteraction  = "links_after_interaction"
+	IssueImagesAfterInteraction = "images_after_interaction"
 
 	// Response issues
 	IssueServerError    = "server_error"
@@ -237,6 +335,11 @@ const (
 	IssueRedirectLoop   = "redirect_loop"
 	IssueRedirectChain  = "redirect_chain"
 	IssueSlowResponse   = "slow_response"
+	IssueSoft404        = "soft_404"
+
+	// Host canonicalisation issues
+	IssueHostVariantDuplicate = "host_variant_duplicate"
+	IssueHostVariantRedirect  = "host_variant_redirect"
 
 	// Indexability issues
 	IssueNoindex       = "noindex"
@@ -66,7 +66,11 @@ CREATE TABLE IF NOT EXISTS html_features (
     h2_count INTEGER DEFAULT 0,
     h2_all TEXT,
     word_count INTEGER DEFAULT 0,
+    char_count INTEGER DEFAULT 0,
     content_hash TEXT,
+    main_word_count INTEGER DEFAULT 0,
+    main_char_count INTEGER DEFAULT 0,
+    main_content_hash TEXT,
     language TEXT,
     hreflangs TEXT,
     og_title TEXT,
@@ -78,6 +82,7 @@ CREATE TABLE IF NOT EXISTS html_features (
 
 CREATE INDEX IF NOT EXISTS idx_html_features_url_id ON html_features(url_id);
 CREATE INDEX IF NOT EXISTS idx_html_features_content_hash ON html_features(content_hash);
+CREATE INDEX IF NOT EXISTS idx_html_features_main_content_hash ON html_features(main_content_hash);
 CREATE INDEX IF NOT EXISTS idx_html_features_title ON html_features(title);
 
 -- Links table: stores link relationships
@@ -174,6 +179,77 @@ CREATE TABLE IF NOT EXISTS crawl_sessions (
     last_checkpoint DATETIME DEFAULT CURRENT_TIMESTAMP
 );
 
+-- Session Events table: notable events during a crawl session (e.g. re-authentication)
+CREATE TABLE IF NOT EXISTS session_events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    session_id INTEGER NOT NULL REFERENCES crawl_sessions(id),
+    event_type TEXT NOT NULL,
+    url TEXT,
+    message TEXT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_session_events_session ON session_events(session_id);
+
+-- URL Rewrites table: URLs changed by rewrite rules before queueing
+CREATE TABLE IF NOT EXISTS url_rewrites (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    original_url TEXT NOT NULL UNIQUE,
+    rewritten_url TEXT NOT NULL,
+    rules TEXT,
+    discovered_from TEXT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_url_rewrites_rewritten ON url_rewrites(rewritten_url);
+
+-- Crawl Traps table: URL patterns flagged as crawl traps
+CREATE TABLE IF NOT EXISTS crawl_traps (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    pattern TEXT NOT NULL UNIQUE,
+    reason TEXT NOT NULL,
+    detail TEXT,
+    url_count INTEGER DEFAULT 0,
+    excluded_count INTEGER DEFAULT 0,
+    sample_urls TEXT,
+    detected_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Near Duplicates table: closest match and cluster of each page by visible text
+CREATE TABLE IF NOT EXISTS near_duplicates (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    url_id INTEGER NOT NULL,
+    scope TEXT NOT NULL DEFAULT 'full', -- full, main
+    closest_url_id INTEGER NOT NULL,
+    similarity REAL NOT NULL,
+    cluster_id INTEGER DEFAULT 0,
+    cluster_size INTEGER DEFAULT 0,
+    UNIQUE(url_id, scope),
+    FOREIGN KEY (url_id) REFERENCES urls(id),
+    FOREIGN KEY (closest_url_id) REFERENCES urls(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_near_duplicates_cluster ON near_duplicates(scope, cluster_id);
+
+-- Host Variants table: duplicate URL variants probed for each seed host
+CREATE TABLE IF NOT EXISTS host_variants (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    host TEXT NOT NULL,
+    canonical_url TEXT NOT NULL,
+    variant TEXT NOT NULL,
+    url TEXT NOT NULL UNIQUE,
+    status_code INTEGER,
+    redirect_url TEXT,
+    final_url TEXT,
+    hops INTEGER DEFAULT 0,
+    verdict TEXT NOT NULL,
+    passed BOOLEAN DEFAULT 0,
+    detail TEXT,
+    checked_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_host_variants_host ON host_variants(host);
+
 -- Sitemaps table
 CREATE TABLE IF NOT EXISTS sitemaps (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -215,6 +291,24 @@ CREATE INDEX IF NOT EXISTS idx_crawl_queue_status ON crawl_queue(status);
 CREATE INDEX IF NOT EXISTS idx_crawl_queue_priority ON crawl_queue(priority);
 `
 
+// ColumnMigration is a column added to a table after the table was first
+// released.
+type ColumnMigration struct {
+	Table      string
+	Column     string
+	Definition string
+}
+
+// ColumnMigrations lists the columns that Initialize adds to databases
+// created before them. CREATE TABLE IF NOT EXISTS leaves existing tables
+// unchanged, so new columns must also be listed here.
+var ColumnMigrations = []ColumnMigration{
+	{"html_features", "main_word_count", "INTEGER DEFAULT 0"},
+	{"html_features", "main_content_hash", "TEXT"},
+	{"html_features", "char_count", "INTEGER DEFAULT 0"},
+	{"html_features", "main_char_count", "INTEGER DEFAULT 0"},
+}
+
 // ViewsSchema contains SQL for useful views
 const ViewsSchema = `
 -- View: Internal pages with their fetch status
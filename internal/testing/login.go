@@ -0,0 +1,161 @@
+// Package testing provides test utilities for the spider crawler.
+package testing
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	"github.com/spider-crawler/spider/internal/config"
+)
+
+// LoginServer is a fixture site with a form login. Pages under /account
+// require a session cookie; without one they redirect to /login.
+type LoginServer struct {
+	Server   *httptest.Server
+	Username string
+	Password string
+
+	// CookieName is the session cookie set on successful login
+	CookieName string
+
+	mu       sync.Mutex
+	csrf     map[string]bool
+	sessions map[string]bool
+	logins   int
+}
+
+// NewLoginServer creates a login fixture accepting the given credentials.
+func NewLoginServer(username, password string) *LoginServer {
+	ls := &LoginServer{
+		Username:   username,
+		Password:   password,
+		CookieName: "session_id",
+		csrf:       make(map[string]bool),
+		sessions:   make(map[string]bool),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login", ls.handleLogin)
+	mux.HandleFunc("/account", ls.handleAccount)
+	mux.HandleFunc("/account/", ls.handleAccount)
+	ls.Server = httptest.NewServer(mux)
+	return ls
+}
+
+func (ls *LoginServer) handleLogin(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		token := randomToken()
+		ls.mu.Lock()
+		ls.csrf[token] = true
+		ls.mu.Unlock()
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprintf(w, `<!DOCTYPE html>
+<html><head><title>Sign in</title></head><body>
+<form id="login" method="post" action="/login">
+<input type="hidden" name="csrf_token" value="%s">
+<input type="text" id="username" name="username">
+<input type="password" id="password" name="password">
+<button type="submit" id="submit">Sign in</button>
+</form>
+</body></html>`, token)
+		return
+	}
+
+	r.ParseForm()
+	token := r.FormValue("csrf_token")
+
+	ls.mu.Lock()
+	validToken := ls.csrf[token]
+	delete(ls.csrf, token)
+	ls.mu.Unlock()
+
+	if !validToken {
+		http.Error(w, "invalid CSRF token", http.StatusForbidden)
+		return
+	}
+	if r.FormValue("username") != ls.Username || r.FormValue("password") != ls.Password {
+		http.Redirect(w, r, "/login?error=1", http.StatusFound)
+		return
+	}
+
+	session := randomToken()
+	ls.mu.Lock()
+	ls.sessions[session] = true
+	ls.logins++
+	ls.mu.Unlock()
+
+	http.SetCookie(w, &http.Cookie{Name: ls.CookieName, Value: session, Path: "/", HttpOnly: true})
+	http.Redirect(w, r, "/account", http.StatusFound)
+}
+
+func (ls *LoginServer) handleAccount(w http.ResponseWriter, r *http.Request) {
+	if !ls.validSession(r) {
+		http.Redirect(w, r, "/login", http.StatusFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprintf(w, `<!DOCTYPE html>
+<html><head><title>Account</title></head><body>
+<h1 id="welcome">Welcome, %s</h1>
+<a href="/account/orders">Orders</a>
+<a href="/account/settings">Settings</a>
+</body></html>`, ls.Username)
+}
+
+func (ls *LoginServer) validSession(r *http.Request) bool {
+	c, err := r.Cookie(ls.CookieName)
+	if err != nil {
+		return false
+	}
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	return ls.sessions[c.Value]
+}
+
+// Expire invalidates all sessions, simulating a server-side timeout.
+func (ls *LoginServer) Expire() {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	ls.sessions = make(map[string]bool)
+}
+
+// Logins returns the number of successful logins.
+func (ls *LoginServer) Logins() int {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	return ls.logins
+}
+
+// URL returns the server URL.
+func (ls *LoginServer) URL() string {
+	return ls.Server.URL
+}
+
+// Close closes the server.
+func (ls *LoginServer) Close() {
+	ls.Server.Close()
+}
+
+// LoginMacro returns a macro that signs in to this server.
+func (ls *LoginServer) LoginMacro() []*config.LoginStep {
+	return []*config.LoginStep{
+		{Action: config.LoginNavigate, Value: ls.URL() + "/login"},
+		{Action: config.LoginType, Selector: "#username", Value: ls.Username},
+		{Action: config.LoginType, Selector: "#password", Value: ls.Password},
+		{Action: config.LoginClick, Selector: "#submit"},
+		{Action: config.LoginWaitURL, Value: "/account"},
+		{Action: config.LoginExtractCookie, Value: ls.CookieName},
+	}
+}
+
+func randomToken() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -9,6 +9,7 @@ import (
 	"fyne.io/fyne/v2/layout"
 	"fyne.io/fyne/v2/widget"
 
+	"github.com/spider-crawler/spider/internal/screenshot"
 	"github.com/spider-crawler/spider/internal/ui/components"
 	"github.com/spider-crawler/spider/internal/ui/tabs"
 	spiderTheme "github.com/spider-crawler/spider/internal/ui/theme"
@@ -28,8 +29,12 @@ type App struct {
 	statsBar      *components.StatsBar
 	mainTabs      *container.AppTabs
 	detailsPanel  *components.URLDetailsPanel
+	gallery       *components.ScreenshotGallery
 	tabViews      map[tabs.TabID]*tabs.TabView
 
+	// Screenshot directory of the current crawl ("" if none)
+	screenshotDir string
+
 	// State
 	isCrawling bool
 	isPaused   bool
@@ -76,6 +81,10 @@ func (a *App) buildUI() {
 		a.detailsPanel,
 	)
 
+	// Screenshot gallery (shown in its own window)
+	a.gallery = components.NewScreenshotGallery()
+	a.gallery.OnSelected = a.showScreenshot
+
 	// Status bar
 	a.statsBar = components.NewStatsBar()
 	statusContainer := container.NewStack(
@@ -223,6 +232,9 @@ func (a *App) setIsCrawling(crawling bool) {
 		a.progressBar.Hide()
 		a.pauseButton.SetText("Pause")
 		a.isPaused = false
+		if err := a.reloadScreenshots(); err != nil {
+			a.ShowError("Screenshots", err.Error())
+		}
 	}
 }
 
@@ -250,6 +262,68 @@ func (a *App) GetTabView(tabID tabs.TabID) *tabs.TabView {
 	return a.tabViews[tabID]
 }
 
+// SetScreenshots updates the screenshot gallery.
+func (a *App) SetScreenshots(items []components.GalleryItem) {
+	a.gallery.SetItems(items)
+}
+
+// LoadScreenshots fills the screenshot gallery from the manifest of a
+// screenshot directory. Call it when a crawl starts or a saved crawl is
+// opened; the gallery is refreshed from the same directory when it is
+// shown and when the crawl ends.
+func (a *App) LoadScreenshots(dir string) error {
+	a.screenshotDir = dir
+	return a.reloadScreenshots()
+}
+
+// reloadScreenshots reads the current screenshot directory into the
+// gallery.
+func (a *App) reloadScreenshots() error {
+	if a.screenshotDir == "" {
+		a.SetScreenshots(nil)
+		return nil
+	}
+	store, err := screenshot.Open(a.screenshotDir, 0)
+	if err != nil {
+		return err
+	}
+
+	entries := store.Entries()
+	items := make([]components.GalleryItem, 0, len(entries))
+	for _, e := range entries {
+		items = append(items, components.GalleryItem{
+			URL:       e.URL,
+			ImagePath: store.Path(e),
+			Width:     e.Width,
+			Height:    e.Height,
+		})
+	}
+	a.SetScreenshots(items)
+	return nil
+}
+
+// ShowScreenshotGallery opens the screenshot gallery window.
+func (a *App) ShowScreenshotGallery() {
+	if err := a.reloadScreenshots(); err != nil {
+		a.ShowError("Screenshots", err.Error())
+	}
+	w := a.fyneApp.NewWindow("Screenshots")
+	w.SetContent(a.gallery)
+	w.Resize(fyne.NewSize(1100, 800))
+	w.Show()
+}
+
+// showScreenshot opens a single screenshot at full size.
+func (a *App) showScreenshot(item components.GalleryItem) {
+	img := canvas.NewImageFromFile(item.ImagePath)
+	img.FillMode = canvas.ImageFillOriginal
+
+	w := a.fyneApp.NewWindow(item.URL)
+	w.SetContent(container.NewScroll(img))
+	w.Resize(fyne.NewSize(1100, 800))
+	w.Show()
+}
+
 // ShowError shows an error dialog.
 func (a *App) ShowError(title, message string) {
 	dialog := widget.NewLabel(message)
@@ -309,6 +383,9 @@ func (a *App) buildMenuBar() *fyne.MainMenu {
 		fyne.NewMenuItem("Refresh", func() {
 			// TODO: Refresh current view
 		}),
+		fyne.NewMenuItem("Screenshots", func() {
+			a.ShowScreenshotGallery()
+		}),
 	)
 
 	// Help menu
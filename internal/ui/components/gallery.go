@@ -0,0 +1,91 @@
+package components
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// GalleryItem is a screenshot shown in the gallery.
+type GalleryItem struct {
+	URL       string
+	ImagePath string
+	Width     int
+	Height    int
+	Changed   bool // flagged by visual diff
+}
+
+// ScreenshotGallery shows page screenshots as a grid of thumbnails.
+type ScreenshotGallery struct {
+	widget.BaseWidget
+
+	items   []GalleryItem
+	grid    *fyne.Container
+	scroll  *container.Scroll
+	summary *widget.Label
+
+	// OnSelected is called when a thumbnail is clicked
+	OnSelected func(item GalleryItem)
+}
+
+// NewScreenshotGallery creates an empty screenshot gallery.
+func NewScreenshotGallery() *ScreenshotGallery {
+	g := &ScreenshotGallery{
+		grid:    container.NewGridWrap(fyne.NewSize(240, 200)),
+		summary: widget.NewLabel("No screenshots"),
+	}
+	g.scroll = container.NewVScroll(g.grid)
+	g.ExtendBaseWidget(g)
+	return g
+}
+
+// SetItems replaces the gallery contents.
+func (g *ScreenshotGallery) SetItems(items []GalleryItem) {
+	g.items = items
+
+	changed := 0
+	objects := make([]fyne.CanvasObject, 0, len(items))
+	for _, item := range items {
+		if item.Changed {
+			changed++
+		}
+		objects = append(objects, g.createThumbnail(item))
+	}
+	g.grid.Objects = objects
+	g.grid.Refresh()
+
+	if changed > 0 {
+		g.summary.SetText(fmt.Sprintf("%d screenshots, %d changed", len(items), changed))
+	} else {
+		g.summary.SetText(fmt.Sprintf("%d screenshots", len(items)))
+	}
+}
+
+// createThumbnail creates a clickable thumbnail with its URL.
+func (g *ScreenshotGallery) createThumbnail(item GalleryItem) fyne.CanvasObject {
+	img := canvas.NewImageFromFile(item.ImagePath)
+	img.FillMode = canvas.ImageFillContain
+	img.SetMinSize(fyne.NewSize(220, 150))
+
+	label := truncate(item.URL, 40)
+	if item.Changed {
+		label = "⚠ " + label
+	}
+
+	open := widget.NewButton(label, func() {
+		if g.OnSelected != nil {
+			g.OnSelected(item)
+		}
+	})
+
+	return container.NewBorder(nil, open, nil, nil, img)
+}
+
+// CreateRenderer creates the gallery renderer.
+func (g *ScreenshotGallery) CreateRenderer() fyne.WidgetRenderer {
+	content := container.NewBorder(g.summary, nil, nil, nil, g.scroll)
+	return widget.NewSimpleRenderer(content)
+}
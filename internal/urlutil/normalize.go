@@ -2,13 +2,20 @@
 package urlutil
 
 import (
+	"fmt"
+	"net"
 	"net/url"
 	"regexp"
 	"sort"
 	"strings"
+
+	"golang.org/x/net/idna"
 )
 
-// Normalizer handles URL normalization.
+// Normalizer handles URL normalization. Normalize applies the syntax-based
+// normalizations of RFC 3986 section 6.2.2 (case, percent-encoding and
+// dot-segments) and the scheme-based ones of section 6.2.3, plus the
+// options below.
 type Normalizer struct {
 	// Query parameters to remove (utm_*, gclid, etc.)
 	IgnoreParams map[string]struct{}
@@ -30,6 +37,12 @@ type Normalizer struct {
 
 	// Remove www prefix
 	RemoveWWW bool
+
+	// Convert internationalized host names to punycode (IDNA)
+	ASCIIHosts bool
+
+	// Uppercase percent-encodings and decode unreserved characters
+	NormalizeEncoding bool
 }
 
 // DefaultNormalizer returns a normalizer with default settings.
@@ -47,13 +60,21 @@ func DefaultNormalizer(ignoreParams []string) *Normalizer {
 		LowercaseSchemeHost: true,
 		SortQueryParams:     true,
 		RemoveWWW:           false, // Keep www by default
+		ASCIIHosts:          true,
+		NormalizeEncoding:   true,
 	}
 }
 
+// defaultPorts are removed by RemoveDefaultPort.
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+}
+
 // Normalize normalizes a URL string.
 func (n *Normalizer) Normalize(rawURL string) (string, error) {
-	// Parse the URL
-	u, err := url.Parse(strings.TrimSpace(rawURL))
+	// Parse the URL. Browsers treat a "%" that starts no escape literally.
+	u, err := url.Parse(escapeStrayPercent(strings.TrimSpace(rawURL)))
 	if err != nil {
 		return "", err
 	}
@@ -61,45 +82,55 @@ func (n *Normalizer) Normalize(rawURL string) (string, error) {
 	// Lowercase scheme
 	if n.LowercaseSchemeHost {
 		u.Scheme = strings.ToLower(u.Scheme)
-		u.Host = strings.ToLower(u.Host)
 	}
-
-	// Remove default ports
-	if n.RemoveDefaultPort {
-		host := u.Host
-		if u.Scheme == "http" && strings.HasSuffix(host, ":80") {
-			u.Host = strings.TrimSuffix(host, ":80")
-		} else if u.Scheme == "https" && strings.HasSuffix(host, ":443") {
-			u.Host = strings.TrimSuffix(host, ":443")
-		}
+	if u.Opaque != "" {
+		// mailto:, tel: and the like have no host or path to normalize
+		return u.String(), nil
 	}
 
-	// Remove www prefix if configured
-	if n.RemoveWWW {
-		u.Host = strings.TrimPrefix(u.Host, "www.")
+	// Host, port and www prefix
+	if u.Host != "" {
+		host, err := n.normalizeHost(u.Scheme, u.Hostname(), u.Port())
+		if err != nil {
+			return "", err
+		}
+		u.Host = host
 	}
 
 	// Remove fragment
 	if n.RemoveFragment {
 		u.Fragment = ""
+		u.RawFragment = ""
+	} else if u.Fragment != "" && n.NormalizeEncoding {
+		u.RawFragment = normalizeEncoding(u.EscapedFragment())
+		u.Fragment, _ = url.PathUnescape(u.RawFragment)
 	}
 
-	// Handle path
-	path := u.Path
-	if path == "" {
+	// Handle path. Work on the escaped form so that encoded delimiters
+	// such as %2F are preserved.
+	path := u.EscapedPath()
+	if path == "" && u.Host != "" {
 		path = "/"
 	}
+	if n.NormalizeEncoding {
+		path = normalizeEncoding(path)
+	}
+
+	// Normalize path (remove double slashes, resolve . and ..)
+	path = normalizePath(path)
 
 	// Remove trailing slash (except for root)
 	if n.RemoveTrailingSlash && len(path) > 1 && strings.HasSuffix(path, "/") {
 		path = strings.TrimSuffix(path, "/")
 	}
 
-	// Normalize path (remove double slashes, resolve . and ..)
-	path = normalizePath(path)
-	u.Path = path
+	if unescaped, err := url.PathUnescape(path); err == nil {
+		u.Path = unescaped
+		u.RawPath = path
+	}
 
 	// Handle query parameters
+	u.ForceQuery = false
 	if u.RawQuery != "" {
 		query := u.Query()
 		newQuery := url.Values{}
@@ -127,35 +158,170 @@ func (n *Normalizer) Normalize(rawURL string) (string, error) {
 	return u.String(), nil
 }
 
-// normalizePath removes double slashes and resolves . and ..
+// normalizeHost lowercases the host, converts IDNs to punycode, writes IPv6
+// addresses in canonical form (RFC 5952) and drops default and empty ports.
+func (n *Normalizer) normalizeHost(scheme, hostname, port string) (string, error) {
+	if strings.Contains(hostname, ":") {
+		// IPv6 literal, possibly with a zone ("fe80::1%eth0")
+		addr, zone, _ := strings.Cut(hostname, "%")
+		ip := net.ParseIP(addr)
+		if ip == nil {
+			return "", fmt.Errorf("invalid IPv6 host: %s", hostname)
+		}
+		canonical := ip.String()
+		if ip.To4() != nil {
+			canonical = "::ffff:" + canonical // keep IPv4-mapped addresses in IPv6 form
+		}
+		hostname = "[" + canonical
+		if zone != "" {
+			hostname += "%" + zone
+		}
+		hostname += "]"
+	} else {
+		if n.LowercaseSchemeHost {
+			hostname = strings.ToLower(hostname)
+		}
+		// A trailing dot names the same (fully qualified) host
+		if len(hostname) > 1 {
+			hostname = strings.TrimSuffix(hostname, ".")
+		}
+		if n.ASCIIHosts && !isASCII(hostname) {
+			ascii, err := idna.Lookup.ToASCII(hostname)
+			if err != nil {
+				return "", fmt.Errorf("invalid internationalized host %s: %w", hostname, err)
+			}
+			hostname = ascii
+		}
+	}
+
+	// Remove www prefix if configured
+	if n.RemoveWWW {
+		hostname = strings.TrimPrefix(hostname, "www.")
+	}
+
+	if port != "" {
+		port = strings.TrimLeft(port, "0")
+		if port == "" {
+			port = "0"
+		}
+	}
+	if n.RemoveDefaultPort && port == defaultPorts[scheme] {
+		port = ""
+	}
+	if port == "" {
+		return hostname, nil
+	}
+	return hostname + ":" + port, nil
+}
+
+// normalizeEncoding uppercases percent-encodings, decodes unreserved
+// characters (RFC 3986 section 2.3) and encodes bytes that may not appear
+// literally, such as non-ASCII characters. A stray "%" becomes "%25".
+func normalizeEncoding(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '%' && i+2 < len(s) && isHex(s[i+1]) && isHex(s[i+2]):
+			decoded := unhex(s[i+1])<<4 | unhex(s[i+2])
+			if isUnreserved(decoded) {
+				b.WriteByte(decoded)
+			} else {
+				fmt.Fprintf(&b, "%%%02X", decoded)
+			}
+			i += 2
+		case c == '%' || c <= ' ' || c >= 0x7f || strings.IndexByte(`"<>\^`+"`"+`{|}`, c) != -1:
+			fmt.Fprintf(&b, "%%%02X", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// escapeStrayPercent encodes "%" signs not followed by two hex digits.
+func escapeStrayPercent(s string) string {
+	if !strings.Contains(s, "%") {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '%' && (i+2 >= len(s) || !isHex(s[i+1]) || !isHex(s[i+2])) {
+			b.WriteString("%25")
+			continue
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+func isUnreserved(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' ||
+		c == '-' || c == '.' || c == '_' || c == '~'
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+func unhex(c byte) byte {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0'
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10
+	default:
+		return c - 'A' + 10
+	}
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
+
+// multipleSlashes matches runs of slashes in a path.
+var multipleSlashes = regexp.MustCompile(`/{2,}`)
+
+// normalizePath removes double slashes and resolves . and .. segments as
+// in RFC 3986 section 5.2.4. Relative paths are only cleaned of double
+// slashes.
 func normalizePath(path string) string {
 	// Replace multiple slashes with single slash
-	re := regexp.MustCompile(`/+`)
-	path = re.ReplaceAllString(path, "/")
-
-	// Split and resolve . and ..
-	parts := strings.Split(path, "/")
-	var result []string
+	path = multipleSlashes.ReplaceAllString(path, "/")
+	if !strings.HasPrefix(path, "/") {
+		return path
+	}
 
-	for _, part := range parts {
-		switch part {
+	segments := strings.Split(path[1:], "/")
+	result := make([]string, 0, len(segments))
+	for i, segment := range segments {
+		last := i == len(segments)-1
+		switch segment {
 		case ".":
-			// Skip current directory
+			// Skip current directory, keeping the trailing slash
+			if last {
+				result = append(result, "")
+			}
 		case "..":
 			// Go up one directory
-			if len(result) > 0 && result[len(result)-1] != "" {
+			if len(result) > 0 {
 				result = result[:len(result)-1]
 			}
+			if last {
+				result = append(result, "")
+			}
 		default:
-			result = append(result, part)
+			result = append(result, segment)
 		}
 	}
 
-	normalized := strings.Join(result, "/")
-	if normalized == "" {
-		return "/"
-	}
-	return normalized
+	return "/" + strings.Join(result, "/")
 }
 
 // sortedQueryString returns a sorted query string.
@@ -195,22 +361,15 @@ func ExtractHost(rawURL string) (string, error) {
 	return strings.ToLower(u.Host), nil
 }
 
-// ExtractDomain extracts the registrable domain from a host.
+// ExtractDomain extracts the registrable domain from a host using the
+// Public Suffix List, so that "www.example.co.uk" yields "example.co.uk".
+// Hosts that are themselves public suffixes and IP addresses are returned
+// without the port.
 func ExtractDomain(host string) string {
-	// Remove port if present
-	if idx := strings.LastIndex(host, ":"); idx != -1 {
-		// Check if it's IPv6
-		if !strings.Contains(host, "]") || idx > strings.LastIndex(host, "]") {
-			host = host[:idx]
-		}
+	if domain := DefaultSuffixList().Domain(host); domain != "" {
+		return domain
 	}
-
-	// Simple domain extraction (for more accurate results, use publicsuffix)
-	parts := strings.Split(host, ".")
-	if len(parts) >= 2 {
-		return strings.Join(parts[len(parts)-2:], ".")
-	}
-	return host
+	return canonicalHost(host)
 }
 
 // IsAbsoluteURL checks if a URL is absolute.
@@ -255,5 +414,48 @@ func IsSameDomain(url1, url2 string) bool {
 	if err1 != nil || err2 != nil {
 		return false
 	}
+	return IsSameSite(host1, host2)
+}
+
+// IsSameSite checks if two hosts share a registrable domain. Hosts under
+// different private suffixes ("a.github.io", "b.github.io") are different
+// sites.
+func IsSameSite(host1, host2 string) bool {
 	return ExtractDomain(host1) == ExtractDomain(host2)
 }
+
+// MatchHost checks if a host matches a host pattern. Patterns are an exact
+// host ("example.com"), "*.example.com" for subdomains only,
+// ".example.com" for the domain and its subdomains, or "*" for any host.
+// The port is ignored unless the pattern contains one.
+func MatchHost(host, pattern string) bool {
+	host = strings.ToLower(host)
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	if pattern == "*" {
+		return true
+	}
+	if !strings.Contains(pattern, ":") {
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+	}
+
+	switch {
+	case strings.HasPrefix(pattern, "*."):
+		return strings.HasSuffix(host, pattern[1:])
+	case strings.HasPrefix(pattern, "."):
+		return host == pattern[1:] || strings.HasSuffix(host, pattern)
+	default:
+		return host == pattern
+	}
+}
+
+// MatchAnyHost checks if a host matches any of the patterns.
+func MatchAnyHost(host string, patterns []string) bool {
+	for _, p := range patterns {
+		if MatchHost(host, p) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,315 @@
+package urlutil
+
+import (
+	"bufio"
+	"bytes"
+	_ "embed"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/idna"
+)
+
+// embeddedSuffixList is the snapshot of https://publicsuffix.org/list/
+// shipped with the binary. Run "spider psl update <file>" to use a newer
+// copy without rebuilding.
+//
+//go:embed public_suffix_list.dat
+var embeddedSuffixList []byte
+
+// EnvSuffixList overrides the path of the installed suffix list.
+const EnvSuffixList = "SPIDER_PSL"
+
+// Section markers of the list file.
+const (
+	pslBeginICANN   = "===BEGIN ICANN DOMAINS==="
+	pslBeginPrivate = "===BEGIN PRIVATE DOMAINS==="
+	pslEndPrivate   = "===END PRIVATE DOMAINS==="
+)
+
+type suffixRuleKind int
+
+const (
+	ruleNormal    suffixRuleKind = iota // "co.uk"
+	ruleWildcard                        // "*.ck": every child of ck is a suffix
+	ruleException                       // "!www.ck": www.ck is not a suffix
+)
+
+type suffixRule struct {
+	kind    suffixRuleKind
+	private bool // from the private section (e.g. github.io, blogspot.com)
+}
+
+// SuffixList is a parsed Public Suffix List. Rules are stored in ASCII
+// (punycode) form.
+type SuffixList struct {
+	rules    map[string]suffixRule // wildcard rules are keyed by their parent
+	wildcard map[string]suffixRule
+	Source   string // "embedded" or the file the list was loaded from
+	ICANN    int    // Number of ICANN section rules
+	Private  int    // Number of private section rules
+}
+
+// ParseSuffixList parses a list in the publicsuffix.org format.
+func ParseSuffixList(r io.Reader) (*SuffixList, error) {
+	l := &SuffixList{
+		rules:    make(map[string]suffixRule),
+		wildcard: make(map[string]suffixRule),
+	}
+
+	sawICANN := false
+	private := false
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "//") {
+			switch {
+			case strings.Contains(line, pslBeginICANN):
+				sawICANN = true
+			case strings.Contains(line, pslBeginPrivate):
+				private = true
+			case strings.Contains(line, pslEndPrivate):
+				private = false
+			}
+			continue
+		}
+		// Rules end at the first whitespace
+		if idx := strings.IndexAny(line, " \t"); idx != -1 {
+			line = line[:idx]
+		}
+		if line == "" {
+			continue
+		}
+
+		kind := ruleNormal
+		switch {
+		case strings.HasPrefix(line, "!"):
+			kind, line = ruleException, line[1:]
+		case strings.HasPrefix(line, "*."):
+			kind, line = ruleWildcard, line[2:]
+		}
+		name, err := idna.ToASCII(strings.ToLower(line))
+		if err != nil || name == "" {
+			continue
+		}
+
+		rule := suffixRule{kind: kind, private: private}
+		if kind == ruleWildcard {
+			l.wildcard[name] = rule
+		} else if _, exists := l.rules[name]; !exists {
+			l.rules[name] = rule
+		}
+		if private {
+			l.Private++
+		} else {
+			l.ICANN++
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if !sawICANN || l.ICANN == 0 {
+		return nil, fmt.Errorf("not a public suffix list: missing %q section", pslBeginICANN)
+	}
+	return l, nil
+}
+
+// LoadSuffixList reads a list from a file.
+func LoadSuffixList(path string) (*SuffixList, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	l, err := ParseSuffixList(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	l.Source = path
+	return l, nil
+}
+
+// PublicSuffix returns the public suffix of a host, e.g. "co.uk" for
+// "www.example.co.uk". Private section rules such as "github.io" are
+// honoured; private reports whether one decided the result.
+func (l *SuffixList) PublicSuffix(host string) (suffix string, private bool) {
+	return l.publicSuffix(host, true)
+}
+
+// ICANNSuffix is like PublicSuffix but ignores private section rules, so
+// that "user.github.io" has the suffix "io".
+func (l *SuffixList) ICANNSuffix(host string) string {
+	suffix, _ := l.publicSuffix(host, false)
+	return suffix
+}
+
+func (l *SuffixList) publicSuffix(host string, withPrivate bool) (string, bool) {
+	host = canonicalHost(host)
+	if host == "" || net.ParseIP(host) != nil {
+		return host, false
+	}
+
+	labels := strings.Split(host, ".")
+	for i := range labels {
+		name := strings.Join(labels[i:], ".")
+
+		// An exception makes its parent the suffix
+		if rule, ok := l.rules[name]; ok && rule.kind == ruleException && (withPrivate || !rule.private) {
+			return strings.Join(labels[i+1:], "."), rule.private
+		}
+		if rule, ok := l.rules[name]; ok && rule.kind == ruleNormal && (withPrivate || !rule.private) {
+			return name, rule.private
+		}
+		if i+1 < len(labels) {
+			if rule, ok := l.wildcard[strings.Join(labels[i+1:], ".")]; ok && (withPrivate || !rule.private) {
+				return name, rule.private
+			}
+		}
+	}
+
+	// The implicit "*" rule
+	return labels[len(labels)-1], false
+}
+
+// Domain returns the registrable domain (public suffix plus one label) of
+// a host, e.g. "example.co.uk" for "www.example.co.uk". It returns "" if
+// the host is itself a public suffix. IP addresses are returned as-is.
+func (l *SuffixList) Domain(host string) string {
+	suffix, _ := l.publicSuffix(host, true)
+	return registrable(canonicalHost(host), suffix)
+}
+
+// ICANNDomain is like Domain but ignores private section rules.
+func (l *SuffixList) ICANNDomain(host string) string {
+	suffix, _ := l.publicSuffix(host, false)
+	return registrable(canonicalHost(host), suffix)
+}
+
+func registrable(host, suffix string) string {
+	if host == "" || net.ParseIP(host) != nil {
+		return host
+	}
+	if host == suffix {
+		return ""
+	}
+	rest := strings.TrimSuffix(host, "."+suffix)
+	if idx := strings.LastIndex(rest, "."); idx != -1 {
+		rest = rest[idx+1:]
+	}
+	return rest + "." + suffix
+}
+
+// canonicalHost strips the port and trailing dot and converts the host to
+// lowercase ASCII.
+func canonicalHost(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.Trim(strings.ToLower(host), "[]")
+	host = strings.TrimSuffix(host, ".")
+	if ascii, err := idna.ToASCII(host); err == nil {
+		host = ascii
+	}
+	return host
+}
+
+var (
+	suffixListMu   sync.RWMutex
+	suffixList     *SuffixList
+	suffixListOnce sync.Once
+)
+
+// SuffixListPath returns the installed list path: SPIDER_PSL, else
+// public_suffix_list.dat in the user config directory.
+func SuffixListPath() string {
+	if path := os.Getenv(EnvSuffixList); path != "" {
+		return path
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "spider", "public_suffix_list.dat")
+}
+
+// DefaultSuffixList returns the list used by ExtractDomain: the installed
+// list if present and valid, else the embedded snapshot.
+func DefaultSuffixList() *SuffixList {
+	suffixListOnce.Do(func() {
+		var l *SuffixList
+		if path := SuffixListPath(); path != "" {
+			if installed, err := LoadSuffixList(path); err == nil {
+				l = installed
+			}
+		}
+		if l == nil {
+			l = EmbeddedSuffixList()
+		}
+
+		suffixListMu.Lock()
+		if suffixList == nil {
+			suffixList = l
+		}
+		suffixListMu.Unlock()
+	})
+
+	suffixListMu.RLock()
+	defer suffixListMu.RUnlock()
+	return suffixList
+}
+
+// SetSuffixList replaces the list used by ExtractDomain.
+func SetSuffixList(l *SuffixList) {
+	suffixListOnce.Do(func() {})
+	suffixListMu.Lock()
+	suffixList = l
+	suffixListMu.Unlock()
+}
+
+// EmbeddedSuffixList parses the snapshot shipped with the binary.
+func EmbeddedSuffixList() *SuffixList {
+	l, err := ParseSuffixList(bytes.NewReader(embeddedSuffixList))
+	if err != nil {
+		panic("embedded public suffix list: " + err.Error())
+	}
+	l.Source = "embedded"
+	return l
+}
+
+// InstallSuffixList validates a list file and copies it to SuffixListPath,
+// where it takes precedence over the embedded snapshot.
+func InstallSuffixList(src string) (*SuffixList, error) {
+	l, err := LoadSuffixList(src)
+	if err != nil {
+		return nil, err
+	}
+
+	dst := SuffixListPath()
+	if dst == "" {
+		return nil, fmt.Errorf("no install location: set %s", EnvSuffixList)
+	}
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return nil, err
+	}
+	tmp := dst + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		return nil, err
+	}
+
+	l.Source = dst
+	SetSuffixList(l)
+	return l, nil
+}
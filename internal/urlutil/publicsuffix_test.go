@@ -0,0 +1,118 @@
+package urlutil
+
+import "testing"
+
+func TestPublicSuffix(t *testing.T) {
+	l := EmbeddedSuffixList()
+	tests := []struct {
+		name        string
+		host        string
+		wantSuffix  string
+		wantPrivate bool
+		wantDomain  string
+	}{
+		// Plain and multi-label ICANN rules
+		{"com", "www.example.com", "com", false, "example.com"},
+		{"co.uk", "www.example.co.uk", "co.uk", false, "example.co.uk"},
+		{"suffix itself", "co.uk", "co.uk", false, ""},
+		{"unlisted TLD", "www.example.zzzz", "zzzz", false, "example.zzzz"},
+
+		// Wildcard and exception rules
+		{"wildcard", "www.example.foo.ck", "foo.ck", false, "example.foo.ck"},
+		{"wildcard child is suffix", "foo.ck", "foo.ck", false, ""},
+		{"exception", "www.ck", "ck", false, "www.ck"},
+		{"exception subdomain", "a.www.ck", "ck", false, "www.ck"},
+		{"nested wildcard", "a.b.kawasaki.jp", "b.kawasaki.jp", false, "a.b.kawasaki.jp"},
+		{"nested exception", "city.kawasaki.jp", "kawasaki.jp", false, "city.kawasaki.jp"},
+
+		// Private section
+		{"github.io", "user.github.io", "github.io", true, "user.github.io"},
+		{"github.io subdomain", "docs.user.github.io", "github.io", true, "user.github.io"},
+		{"github.io itself", "github.io", "github.io", true, ""},
+
+		// Internationalized suffixes, in Unicode and punycode
+		{"IDN suffix", "例子.公司.cn", "xn--55qx5d.cn", false, "xn--fsqu00a.xn--55qx5d.cn"},
+		{"IDN suffix punycode", "www.xn--fsqu00a.xn--55qx5d.cn", "xn--55qx5d.cn", false, "xn--fsqu00a.xn--55qx5d.cn"},
+		{"IDN TLD", "www.例子.香港", "xn--j6w193g", false, "xn--fsqu00a.xn--j6w193g"},
+
+		// Hosts needing canonicalization
+		{"uppercase", "WWW.Example.CO.UK", "co.uk", false, "example.co.uk"},
+		{"trailing dot", "www.example.com.", "com", false, "example.com"},
+		{"port", "www.example.co.uk:8080", "co.uk", false, "example.co.uk"},
+
+		// IP addresses have no suffix
+		{"IPv4", "192.168.0.1", "192.168.0.1", false, "192.168.0.1"},
+		{"IPv4 with port", "192.168.0.1:8080", "192.168.0.1", false, "192.168.0.1"},
+		{"IPv6", "[2001:db8::1]", "2001:db8::1", false, "2001:db8::1"},
+		{"IPv6 with port", "[2001:db8::1]:443", "2001:db8::1", false, "2001:db8::1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			suffix, private := l.PublicSuffix(tt.host)
+			if suffix != tt.wantSuffix || private != tt.wantPrivate {
+				t.Errorf("PublicSuffix(%q) = %q, %v, want %q, %v", tt.host, suffix, private, tt.wantSuffix, tt.wantPrivate)
+			}
+			if got := l.Domain(tt.host); got != tt.wantDomain {
+				t.Errorf("Domain(%q) = %q, want %q", tt.host, got, tt.wantDomain)
+			}
+		})
+	}
+}
+
+func TestICANNDomain(t *testing.T) {
+	l := EmbeddedSuffixList()
+	tests := []struct {
+		host string
+		want string
+	}{
+		{"user.github.io", "github.io"},
+		{"docs.user.github.io", "github.io"},
+		{"www.example.co.uk", "example.co.uk"},
+		{"www.ck", "www.ck"},
+	}
+
+	for _, tt := range tests {
+		if got := l.ICANNDomain(tt.host); got != tt.want {
+			t.Errorf("ICANNDomain(%q) = %q, want %q", tt.host, got, tt.want)
+		}
+	}
+}
+
+func TestScope(t *testing.T) {
+	SetSuffixList(EmbeddedSuffixList())
+
+	tests := []struct {
+		name              string
+		seed              string
+		includeSubdomains bool
+		url               string
+		want              bool
+	}{
+		{"seed host", "https://www.example.com/", false, "https://www.example.com/a", true},
+		{"seed host other port", "https://www.example.com/", false, "https://www.example.com:8443/a", true},
+		{"subdomain excluded", "https://www.example.com/", false, "https://blog.example.com/", false},
+		{"subdomain included", "https://www.example.com/", true, "https://blog.example.com/", true},
+		{"other domain", "https://www.example.com/", true, "https://example.org/", false},
+		{"co.uk sibling", "https://www.example.co.uk/", true, "https://other.co.uk/", false},
+		{"github.io sibling", "https://user.github.io/", true, "https://other.github.io/", false},
+		{"github.io subdomain", "https://user.github.io/", true, "https://docs.user.github.io/", true},
+		{"wildcard sibling", "https://a.foo.ck/", true, "https://b.foo.ck/", false},
+		{"IDN seed, punycode URL", "https://www.例子.公司.cn/", true, "https://blog.xn--fsqu00a.xn--55qx5d.cn/", true},
+		{"IP seed", "http://192.168.0.1/", true, "http://192.168.0.1:8080/", true},
+		{"other IP", "http://192.168.0.1/", true, "http://192.168.0.2/", false},
+		{"relative URL", "https://www.example.com/", true, "/a", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewScope(tt.includeSubdomains)
+			if err := s.AddSeed(tt.seed); err != nil {
+				t.Fatalf("AddSeed(%q): %v", tt.seed, err)
+			}
+			if got := s.IsInternal(tt.url); got != tt.want {
+				t.Errorf("IsInternal(%q) = %v, want %v", tt.url, got, tt.want)
+			}
+		})
+	}
+}
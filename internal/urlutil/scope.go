@@ -0,0 +1,66 @@
+package urlutil
+
+import (
+	"fmt"
+	"net/url"
+	"sync"
+)
+
+// Scope decides whether URLs are internal to a crawl: on a seed host, or,
+// with subdomains included, on any host sharing a seed's registrable domain.
+type Scope struct {
+	mu                sync.RWMutex
+	hosts             map[string]struct{}
+	domains           map[string]struct{}
+	includeSubdomains bool
+}
+
+// NewScope creates an empty scope.
+func NewScope(includeSubdomains bool) *Scope {
+	return &Scope{
+		hosts:             make(map[string]struct{}),
+		domains:           make(map[string]struct{}),
+		includeSubdomains: includeSubdomains,
+	}
+}
+
+// AddSeed adds the host of a seed URL to the scope.
+func (s *Scope) AddSeed(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	if u.Host == "" {
+		return fmt.Errorf("seed URL has no host: %s", rawURL)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hosts[canonicalHost(u.Host)] = struct{}{}
+	s.domains[ExtractDomain(u.Host)] = struct{}{}
+	return nil
+}
+
+// IsInternalHost checks if a host is inside the scope.
+func (s *Scope) IsInternalHost(host string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, ok := s.hosts[canonicalHost(host)]; ok {
+		return true
+	}
+	if !s.includeSubdomains {
+		return false
+	}
+	_, ok := s.domains[ExtractDomain(host)]
+	return ok
+}
+
+// IsInternal checks if a URL is inside the scope.
+func (s *Scope) IsInternal(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	return s.IsInternalHost(u.Host)
+}
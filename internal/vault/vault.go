@@ -0,0 +1,401 @@
+// Package vault provides an encrypted store for crawl credentials.
+//
+// Secrets are encrypted individually with AES-256-GCM. The key is derived
+// from a passphrase with scrypt, or from a key file. Secret names are stored
+// in plain text so that they can be listed without the key.
+package vault
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/scrypt"
+
+	"github.com/spider-crawler/spider/internal/config"
+)
+
+// Scheme prefixes secret references in config files, e.g.
+// "secret://client-a/staging".
+const Scheme = config.SecretRefPrefix
+
+// Environment variables that supply the vault key.
+const (
+	EnvPassphrase = "SPIDER_VAULT_PASSPHRASE"
+	EnvKeyFile    = "SPIDER_VAULT_KEY_FILE"
+	EnvVaultPath  = "SPIDER_VAULT"
+)
+
+// DefaultPath is the vault file used when SPIDER_VAULT is not set.
+const DefaultPath = "spider-vault.json"
+
+// checkPlaintext is encrypted with the key to detect a wrong key.
+const checkPlaintext = "spider-vault"
+
+// scrypt parameters for passphrase keys
+const (
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+)
+
+// ErrNotFound is returned for unknown secret names.
+var ErrNotFound = errors.New("secret not found")
+
+// ErrWrongKey is returned when the key does not decrypt the vault.
+var ErrWrongKey = errors.New("wrong vault passphrase or key file")
+
+// Key identifies the vault key: a passphrase or a key file.
+type Key struct {
+	Passphrase string
+	KeyFile    string
+}
+
+// KeyFromEnv reads the key from SPIDER_VAULT_KEY_FILE or
+// SPIDER_VAULT_PASSPHRASE.
+func KeyFromEnv() (Key, error) {
+	if path := os.Getenv(EnvKeyFile); path != "" {
+		return Key{KeyFile: path}, nil
+	}
+	if pass := os.Getenv(EnvPassphrase); pass != "" {
+		return Key{Passphrase: pass}, nil
+	}
+	return Key{}, fmt.Errorf("no vault key: set %s or %s", EnvPassphrase, EnvKeyFile)
+}
+
+// PathFromEnv returns SPIDER_VAULT or the default vault path.
+func PathFromEnv() string {
+	if path := os.Getenv(EnvVaultPath); path != "" {
+		return path
+	}
+	return DefaultPath
+}
+
+// Entry is the metadata of a stored secret.
+type Entry struct {
+	Name    string    `json:"-"`
+	Version int       `json:"version"`
+	Created time.Time `json:"created"`
+	Updated time.Time `json:"updated"`
+
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// vaultFile is the on-disk format.
+type vaultFile struct {
+	Version int               `json:"version"`
+	KDF     string            `json:"kdf"` // "scrypt" or "keyfile"
+	Salt    []byte            `json:"salt"`
+	Check   *Entry            `json:"check"`
+	Secrets map[string]*Entry `json:"secrets"`
+}
+
+// Vault is an open secrets store.
+type Vault struct {
+	mu   sync.Mutex
+	path string
+	file *vaultFile
+	aead cipher.AEAD
+}
+
+// Create creates a new, empty vault. It fails if the file exists.
+func Create(path string, key Key) (*Vault, error) {
+	if _, err := os.Stat(path); err == nil {
+		return nil, fmt.Errorf("vault already exists: %s", path)
+	}
+
+	v := &Vault{path: path}
+	if err := v.setKey(key); err != nil {
+		return nil, err
+	}
+	v.file.Secrets = make(map[string]*Entry)
+
+	if err := v.Save(); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// Open opens an existing vault and verifies the key.
+func Open(path string, key Key) (*Vault, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vault: %w", err)
+	}
+
+	file := &vaultFile{}
+	if err := json.Unmarshal(data, file); err != nil {
+		return nil, fmt.Errorf("failed to parse vault: %w", err)
+	}
+	if file.Check == nil {
+		return nil, fmt.Errorf("invalid vault: missing key check")
+	}
+	if file.Secrets == nil {
+		file.Secrets = make(map[string]*Entry)
+	}
+
+	aead, err := deriveAEAD(key, file.KDF, file.Salt)
+	if err != nil {
+		return nil, err
+	}
+	v := &Vault{path: path, file: file, aead: aead}
+	if _, err := v.decrypt("", file.Check); err != nil {
+		return nil, ErrWrongKey
+	}
+	return v, nil
+}
+
+// OpenOrCreate opens the vault, creating it if it does not exist.
+func OpenOrCreate(path string, key Key) (*Vault, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return Create(path, key)
+	}
+	return Open(path, key)
+}
+
+// setKey derives a new key with a fresh salt and re-encrypts the key check.
+func (v *Vault) setKey(key Key) error {
+	kdf := "scrypt"
+	if key.KeyFile != "" {
+		kdf = "keyfile"
+	}
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+	aead, err := deriveAEAD(key, kdf, salt)
+	if err != nil {
+		return err
+	}
+
+	if v.file == nil {
+		v.file = &vaultFile{Version: 1}
+	}
+	v.file.KDF = kdf
+	v.file.Salt = salt
+	v.aead = aead
+
+	check, err := v.encrypt("", checkPlaintext)
+	if err != nil {
+		return err
+	}
+	v.file.Check = check
+	return nil
+}
+
+// deriveAEAD derives the AES-256-GCM cipher for a key.
+func deriveAEAD(key Key, kdf string, salt []byte) (cipher.AEAD, error) {
+	var derived []byte
+	switch kdf {
+	case "scrypt":
+		if key.Passphrase == "" {
+			return nil, fmt.Errorf("vault requires a passphrase")
+		}
+		var err error
+		derived, err = scrypt.Key([]byte(key.Passphrase), salt, scryptN, scryptR, scryptP, 32)
+		if err != nil {
+			return nil, err
+		}
+	case "keyfile":
+		if key.KeyFile == "" {
+			return nil, fmt.Errorf("vault requires a key file")
+		}
+		material, err := os.ReadFile(key.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read key file: %w", err)
+		}
+		if len(material) < 32 {
+			return nil, fmt.Errorf("key file must contain at least 32 bytes")
+		}
+		h := sha256.New()
+		h.Write(salt)
+		h.Write(material)
+		derived = h.Sum(nil)
+	default:
+		return nil, fmt.Errorf("unknown vault key derivation: %s", kdf)
+	}
+
+	block, err := aes.NewCipher(derived)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// encrypt seals a value. The name is authenticated so that ciphertexts
+// cannot be swapped between secrets.
+func (v *Vault) encrypt(name, value string) (*Entry, error) {
+	nonce := make([]byte, v.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return &Entry{
+		Nonce:      nonce,
+		Ciphertext: v.aead.Seal(nil, nonce, []byte(value), []byte(name)),
+	}, nil
+}
+
+func (v *Vault) decrypt(name string, e *Entry) (string, error) {
+	plain, err := v.aead.Open(nil, e.Nonce, e.Ciphertext, []byte(name))
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
+
+// Set stores a secret, replacing an existing value and bumping its version.
+func (v *Vault) Set(name, value string) error {
+	name = strings.TrimPrefix(name, Scheme)
+	if name == "" {
+		return fmt.Errorf("secret name is empty")
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	e, err := v.encrypt(name, value)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	e.Version, e.Created, e.Updated = 1, now, now
+	if prev, ok := v.file.Secrets[name]; ok {
+		e.Version = prev.Version + 1
+		e.Created = prev.Created
+	}
+	v.file.Secrets[name] = e
+	return nil
+}
+
+// Get returns the value of a secret.
+func (v *Vault) Get(name string) (string, error) {
+	name = strings.TrimPrefix(name, Scheme)
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	e, ok := v.file.Secrets[name]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	value, err := v.decrypt(name, e)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt secret %s: %w", name, err)
+	}
+	return value, nil
+}
+
+// Delete removes a secret.
+func (v *Vault) Delete(name string) error {
+	name = strings.TrimPrefix(name, Scheme)
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if _, ok := v.file.Secrets[name]; !ok {
+		return fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	delete(v.file.Secrets, name)
+	return nil
+}
+
+// List returns the metadata of all secrets, sorted by name.
+func (v *Vault) List() []*Entry {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	entries := make([]*Entry, 0, len(v.file.Secrets))
+	for name, e := range v.file.Secrets {
+		entryCopy := *e
+		entryCopy.Name = name
+		entries = append(entries, &entryCopy)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name < entries[j].Name
+	})
+	return entries
+}
+
+// Rekey re-encrypts every secret under a new key.
+func (v *Vault) Rekey(newKey Key) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	plain := make(map[string]string, len(v.file.Secrets))
+	for name, e := range v.file.Secrets {
+		value, err := v.decrypt(name, e)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt secret %s: %w", name, err)
+		}
+		plain[name] = value
+	}
+
+	if err := v.setKey(newKey); err != nil {
+		return err
+	}
+
+	for name, value := range plain {
+		e, err := v.encrypt(name, value)
+		if err != nil {
+			return err
+		}
+		prev := v.file.Secrets[name]
+		e.Version, e.Created, e.Updated = prev.Version, prev.Created, prev.Updated
+		v.file.Secrets[name] = e
+	}
+	return nil
+}
+
+// Resolve returns the value of a secret reference. Values without the
+// secret:// scheme are returned unchanged.
+func (v *Vault) Resolve(ref string) (string, error) {
+	if !IsReference(ref) {
+		return ref, nil
+	}
+	return v.Get(ref)
+}
+
+// IsReference reports whether a value is a secret reference.
+func IsReference(value string) bool {
+	return strings.HasPrefix(value, Scheme)
+}
+
+// Save writes the vault atomically with owner-only permissions.
+func (v *Vault) Save() error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	data, err := json.MarshalIndent(v.file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal vault: %w", err)
+	}
+
+	if dir := filepath.Dir(v.path); dir != "" {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return err
+		}
+	}
+	tmp := v.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write vault: %w", err)
+	}
+	return os.Rename(tmp, v.path)
+}
+
+// Path returns the vault file path.
+func (v *Vault) Path() string {
+	return v.path
+}
@@ -0,0 +1,168 @@
+package vault
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// editVault rewrites the vault file on disk through fn.
+func editVault(t *testing.T, path string, fn func(f *vaultFile)) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &vaultFile{}
+	if err := json.Unmarshal(data, f); err != nil {
+		t.Fatal(err)
+	}
+	fn(f)
+	if data, err = json.Marshal(f); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// newTestVault creates a vault holding two secrets.
+func newTestVault(t *testing.T, key Key) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "vault.json")
+	v, err := Create(path, key)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := v.Set("client-a/user", "crawler"); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Set(Scheme+"client-a/password", "hunter2"); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	return path
+}
+
+func TestRoundTrip(t *testing.T) {
+	key := Key{Passphrase: "correct horse"}
+	path := newTestVault(t, key)
+
+	v, err := Open(path, key)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	tests := []struct {
+		ref  string
+		want string
+	}{
+		{Scheme + "client-a/user", "crawler"},
+		{Scheme + "client-a/password", "hunter2"},
+		{"plain value", "plain value"},
+	}
+	for _, tt := range tests {
+		got, err := v.Resolve(tt.ref)
+		if err != nil || got != tt.want {
+			t.Errorf("Resolve(%q) = %q, %v, want %q", tt.ref, got, err, tt.want)
+		}
+	}
+
+	if _, err := v.Get("client-b/user"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get unknown secret: err = %v, want ErrNotFound", err)
+	}
+
+	if err := v.Set("client-a/password", "hunter3"); err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range v.List() {
+		if e.Name == "client-a/password" && e.Version != 2 {
+			t.Errorf("version after update = %d, want 2", e.Version)
+		}
+	}
+}
+
+func TestWrongKey(t *testing.T) {
+	path := newTestVault(t, Key{Passphrase: "correct horse"})
+
+	if _, err := Open(path, Key{Passphrase: "battery staple"}); !errors.Is(err, ErrWrongKey) {
+		t.Fatalf("Open with wrong passphrase: err = %v, want ErrWrongKey", err)
+	}
+}
+
+func TestRekey(t *testing.T) {
+	oldKey := Key{Passphrase: "correct horse"}
+	path := newTestVault(t, oldKey)
+
+	keyFile := filepath.Join(t.TempDir(), "vault.key")
+	if err := os.WriteFile(keyFile, []byte("0123456789abcdef0123456789abcdef"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	newKey := Key{KeyFile: keyFile}
+
+	v, err := Open(path, oldKey)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if err := v.Rekey(newKey); err != nil {
+		t.Fatalf("Rekey: %v", err)
+	}
+	if err := v.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	if _, err := Open(path, oldKey); err == nil {
+		t.Error("vault still opens with the old key")
+	}
+	v, err = Open(path, newKey)
+	if err != nil {
+		t.Fatalf("Open with new key: %v", err)
+	}
+	if got, err := v.Get("client-a/password"); err != nil || got != "hunter2" {
+		t.Errorf("Get after rekey = %q, %v, want hunter2", got, err)
+	}
+}
+
+func TestTamperedVault(t *testing.T) {
+	key := Key{Passphrase: "correct horse"}
+
+	tests := []struct {
+		name    string
+		edit    func(f *vaultFile)
+		openErr error
+	}{
+		{"flipped ciphertext bit", func(f *vaultFile) {
+			f.Secrets["client-a/password"].Ciphertext[0] ^= 1
+		}, nil},
+		{"swapped name and value", func(f *vaultFile) {
+			f.Secrets["client-a/user"], f.Secrets["client-a/password"] = f.Secrets["client-a/password"], f.Secrets["client-a/user"]
+		}, nil},
+		{"tampered key check", func(f *vaultFile) {
+			f.Check.Ciphertext[0] ^= 1
+		}, ErrWrongKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := newTestVault(t, key)
+			editVault(t, path, tt.edit)
+
+			v, err := Open(path, key)
+			if tt.openErr != nil {
+				if !errors.Is(err, tt.openErr) {
+					t.Fatalf("Open: err = %v, want %v", err, tt.openErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Open: %v", err)
+			}
+			if got, err := v.Get("client-a/password"); err == nil {
+				t.Errorf("Get of tampered secret = %q, want an error", got)
+			}
+		})
+	}
+}